@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"time"
@@ -14,27 +15,12 @@ type Element int
 func consumer(w io.Writer, wq container.WaitableQueue[Element]) {
 	lq := wq.(container.Countable) // This implementation provides Countable, so the assertion cannot fail.
 
-	for {
-		_, ok := <-wq.WaitChan()
-		if !ok {
-			fmt.Fprintf(w, "Consumer exiting on WaitableQueue closure, %d remaining in queue\n", lq.Len())
-			return
-		}
-		fmt.Fprintln(w, "Queue might not be empty, looking for items")
-		var (
-			item Element
-			wqs  container.WaitableQueueState
-		)
-		for ok {
-			item, ok, wqs = wq.Dequeue()
-			if !ok {
-				fmt.Fprintln(w, "Back to wait")
-				break
-			}
-			fmt.Fprintf(w, "Received: %v, %d in queue, status %s\n", item, lq.Len(), wqs)
-			time.Sleep(30 * time.Millisecond) // Consume more slowly than producer
-		}
+	// The loop blocks while the queue is empty, and ends once it is closed and drained.
+	for item, wqs := range queue.AllWithState(context.Background(), wq) {
+		fmt.Fprintf(w, "Received: %v, %d in queue, status %s\n", item, lq.Len(), wqs)
+		time.Sleep(30 * time.Millisecond) // Consume more slowly than producer
 	}
+	fmt.Fprintf(w, "Consumer exiting on WaitableQueue closure, %d remaining in queue\n", lq.Len())
 }
 
 func producer(w io.Writer, wq container.WaitableQueue[Element]) {
@@ -0,0 +1,112 @@
+package types
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"math"
+
+	"github.com/fgm/container"
+)
+
+// These errors are re-exported by the packages providing waitable containers.
+var (
+	ErrCapacityIsNegative                  = errors.New("container: initial capacity cannot be negative")
+	ErrLowWatermarkIsNegative              = errors.New("container: low watermark cannot be negative")
+	ErrHighWatermarkIsNegative             = errors.New("container: high watermark cannot be negative")
+	ErrHighWatermarkIsLessThanLowWatermark = errors.New("container: high watermark cannot be less than low watermark")
+)
+
+// Watermarks holds the thresholds used to derive a WaitableQueueState from a container length.
+type Watermarks struct {
+	hi, lo, sat int // Low and high watermarks, possible saturation
+}
+
+// NewWatermarks validates the waitable containers constructor arguments and builds the matching Watermarks.
+func NewWatermarks(initialCapacity int, lowWatermark, highWatermark int) (Watermarks, error) {
+	if initialCapacity < 0 {
+		return Watermarks{}, fmt.Errorf("%w: got %d", ErrCapacityIsNegative, initialCapacity)
+	}
+	if lowWatermark < 0 {
+		return Watermarks{}, fmt.Errorf("%w: got %d", ErrLowWatermarkIsNegative, lowWatermark)
+	}
+	if highWatermark < 0 {
+		return Watermarks{}, fmt.Errorf("%w: got %d", ErrHighWatermarkIsNegative, highWatermark)
+	}
+	if lowWatermark > highWatermark {
+		return Watermarks{}, fmt.Errorf("%w: low is %d high is %d", ErrHighWatermarkIsLessThanLowWatermark, lowWatermark, highWatermark)
+	}
+	return Watermarks{
+		hi:  highWatermark,
+		lo:  lowWatermark,
+		sat: (highWatermark + 3*initialCapacity) / 4,
+	}, nil
+}
+
+// Unbounded returns a copy of the watermarks without a saturation threshold,
+// for containers without a capacity, which therefore never report QueueIsNearSaturation.
+func (w Watermarks) Unbounded() Watermarks {
+	w.sat = math.MaxInt
+	return w
+}
+
+// State returns the WaitableQueueState matching a container holding l elements.
+func (w Watermarks) State(l int) container.WaitableQueueState {
+	switch {
+	case l <= w.lo:
+		return container.QueueIsBelowLowWatermark
+	case l >= w.hi && l < w.sat:
+		return container.QueueIsAboveHighWatermark
+	case l >= w.sat:
+		return container.QueueIsNearSaturation
+	default:
+		return container.QueueIsNominal
+	}
+}
+
+// All implements the All functions of waitable containers on top of their
+// non-blocking take method (Dequeue, Pop) and their wait channel.
+func All[E any](ctx context.Context, take func() (E, bool, container.WaitableQueueState), wait <-chan container.Unit) iter.Seq[E] {
+	return func(yield func(E) bool) {
+		for e := range AllWithState(ctx, take, wait) {
+			if !yield(e) {
+				return
+			}
+		}
+	}
+}
+
+// AllWithState implements the AllWithState method of waitable containers
+// on top of their non-blocking take method (Dequeue, Pop) and their wait channel.
+func AllWithState[E any](ctx context.Context, take func() (E, bool, container.WaitableQueueState), wait <-chan container.Unit) iter.Seq2[E, container.WaitableQueueState] {
+	return func(yield func(E, container.WaitableQueueState) bool) {
+		closed := false
+		for {
+			// Take elements until the container is empty.
+			for {
+				if ctx.Err() != nil {
+					return
+				}
+				e, ok, wqs := take()
+				if !ok {
+					break
+				}
+				if !yield(e, wqs) {
+					return
+				}
+			}
+			if closed {
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case _, ok := <-wait:
+				// On closure, loop once more to drain the container, then end.
+				closed = !ok
+			}
+		}
+	}
+}
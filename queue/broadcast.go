@@ -0,0 +1,300 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/fgm/container"
+	types "github.com/fgm/container/internal"
+)
+
+var (
+	ErrBroadcastIsClosed     = errors.New("container: broadcast is closed")
+	ErrRetentionIsNegative   = errors.New("container: retention cannot be negative")
+	ErrSubscriptionIsClosed  = errors.New("container: subscription is closed")
+	ErrUnknownSlowSubscriber = errors.New("container: unknown slow subscriber policy")
+)
+
+// SlowSubscriberPolicy defines what Publish does when a subscription is full.
+type SlowSubscriberPolicy int
+
+const (
+	// DropNewest does not deliver the published element to the full subscription.
+	DropNewest SlowSubscriberPolicy = iota
+	// DropOldest evicts the oldest pending element of the full subscription to make room.
+	DropOldest
+	// Block makes Publish wait until the full subscription has room, or its context is canceled.
+	Block
+)
+
+// SubscriptionOptions configures a Subscription.
+type SubscriptionOptions struct {
+	// Capacity is the maximum number of pending elements in the subscription.
+	// Zero means unbounded, in which case Policy is ignored.
+	Capacity int
+	// LowWatermark and HighWatermark are used like in NewWaitableQueue,
+	// with Capacity as the initial capacity.
+	// Unbounded subscriptions never report QueueIsNearSaturation.
+	LowWatermark, HighWatermark int
+	// Policy applies when the subscription is full.
+	Policy SlowSubscriberPolicy
+	// FromOldest starts the subscription with the elements retained by the Broadcast.
+	FromOldest bool
+}
+
+// Broadcast delivers every published element to all its subscriptions,
+// unlike WaitableQueue, in which each element is only dequeued once.
+//
+// It is concurrency-safe.
+type Broadcast[E any] struct {
+	closed    bool
+	mu        sync.Mutex // Protects all fields except pubMu
+	pubMu     sync.Mutex // Serializes Publish, to deliver elements in the same order to all subscriptions
+	retained  []E        // Ring buffer of the latest published elements
+	start     int        // Index of the oldest retained element
+	retention int
+	subs      map[*Subscription[E]]unit
+}
+
+// NewBroadcast creates a Broadcast retaining the given number of latest published
+// elements, for use by subscriptions created with SubscriptionOptions.FromOldest.
+func NewBroadcast[E any](retention int) (*Broadcast[E], error) {
+	if retention < 0 {
+		return nil, fmt.Errorf("%w: got %d", ErrRetentionIsNegative, retention)
+	}
+	return &Broadcast[E]{
+		retained:  make([]E, 0, retention),
+		retention: retention,
+		subs:      make(map[*Subscription[E]]unit),
+	}, nil
+}
+
+// Subscribe creates a new Subscription receiving all elements published from now on,
+// and the retained elements if opts.FromOldest is true.
+func (b *Broadcast[E]) Subscribe(opts SubscriptionOptions) (*Subscription[E], error) {
+	wm, err := types.NewWatermarks(opts.Capacity, opts.LowWatermark, opts.HighWatermark)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Policy < DropNewest || opts.Policy > Block {
+		return nil, fmt.Errorf("%w: got %d", ErrUnknownSlowSubscriber, opts.Policy)
+	}
+	if opts.Capacity == 0 {
+		wm = wm.Unbounded()
+	}
+	sub := &Subscription[E]{
+		Watermarks: wm,
+		b:          b,
+		capacity:   opts.Capacity,
+		gone:       make(chan unit),
+		items:      make([]E, 0, opts.Capacity),
+		policy:     opts.Policy,
+		room:       make(chan unit, 1),
+		signal:     make(chan unit, 1),
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, ErrBroadcastIsClosed
+	}
+	if opts.FromOldest {
+		for i := range b.retained {
+			sub.items = append(sub.items, b.retained[(b.start+i)%len(b.retained)])
+		}
+		if sub.capacity > 0 && len(sub.items) > sub.capacity {
+			sub.items = sub.items[len(sub.items)-sub.capacity:]
+		}
+		if len(sub.items) > 0 {
+			sub.signal <- unit{}
+		}
+	}
+	b.subs[sub] = unit{}
+	return sub, nil
+}
+
+// Publish delivers e to all subscriptions, applying their policy to the full ones.
+//
+// It only returns an error if the Broadcast is closed, or if ctx is canceled
+// while waiting on a full subscription using the Block policy. In the latter case,
+// e may already have been delivered to some subscriptions.
+func (b *Broadcast[E]) Publish(ctx context.Context, e E) error {
+	b.pubMu.Lock()
+	defer b.pubMu.Unlock()
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrBroadcastIsClosed
+	}
+	if b.retention > 0 {
+		if len(b.retained) < b.retention {
+			b.retained = append(b.retained, e)
+		} else {
+			b.retained[b.start] = e
+			b.start = (b.start + 1) % b.retention
+		}
+	}
+	subs := make([]*Subscription[E], 0, len(b.subs))
+	for sub := range b.subs {
+		subs = append(subs, sub)
+	}
+	b.mu.Unlock()
+
+	// Deliver without holding the lock, so that blocked deliveries do not prevent
+	// slow subscriptions from being unsubscribed, or the Broadcast from being closed.
+	for _, sub := range subs {
+		if err := sub.offer(ctx, e); err != nil && !errors.Is(err, ErrSubscriptionIsClosed) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Len returns the number of active subscriptions.
+func (b *Broadcast[E]) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
+
+// Close prevents any further publishing, and closes all subscriptions.
+//
+// Subscribers may still dequeue their pending elements.
+func (b *Broadcast[E]) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	b.closed = true
+	for sub := range b.subs {
+		sub.close()
+	}
+	clear(b.subs)
+}
+
+// Subscription is the subscriber side of a Broadcast.
+//
+// Like a WaitableQueue, it is consumed with Dequeue and WaitChan, and each
+// subscription has its own watermarks.
+type Subscription[E any] struct {
+	types.Watermarks
+	b        *Broadcast[E]
+	capacity int
+	closed   bool
+	dropped  int
+	gone     chan unit // Closed on closure, to unblock publishers
+	items    []E
+	mu       sync.Mutex
+	policy   SlowSubscriberPolicy
+	room     chan unit // Signals blocked publishers that an element was dequeued
+	signal   chan unit // Used to signal availability or closure
+}
+
+// offer delivers e to the subscription, applying its policy if it is full.
+func (s *Subscription[E]) offer(ctx context.Context, e E) error {
+	for {
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			return ErrSubscriptionIsClosed
+		}
+		if s.capacity == 0 || len(s.items) < s.capacity {
+			break
+		}
+		switch s.policy {
+		case DropNewest:
+			s.dropped++
+			s.mu.Unlock()
+			return nil
+		case DropOldest:
+			s.dropped++
+			s.items[0] = *new(E) // Prevent memory leak if E is a pointer type
+			s.items = s.items[1:]
+		}
+		if len(s.items) < s.capacity {
+			break
+		}
+		// Block: wait for room.
+		s.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.gone:
+			return ErrSubscriptionIsClosed
+		case <-s.room:
+		}
+	}
+	defer s.mu.Unlock()
+	s.items = append(s.items, e)
+	// Non-blocking send, as in waitable.Enqueue.
+	select {
+	case s.signal <- unit{}:
+	default:
+	}
+	return nil
+}
+
+// Dequeue removes and returns the oldest pending element if available.
+func (s *Subscription[E]) Dequeue() (E, bool, container.WaitableQueueState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.items) == 0 {
+		var zero E
+		return zero, false, container.QueueIsBelowLowWatermark
+	}
+	item := s.items[0]
+	s.items[0] = *new(E) // Prevent memory leak if E is a pointer type
+	s.items = s.items[1:]
+
+	select {
+	case s.room <- unit{}:
+	default:
+	}
+	return item, true, s.State(len(s.items))
+}
+
+// Dropped returns the number of elements dropped because the subscription was full.
+func (s *Subscription[E]) Dropped() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dropped
+}
+
+// Len returns the number of pending elements.
+func (s *Subscription[E]) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.items)
+}
+
+// WaitChan returns a channel signaling when an element might be available to dequeue,
+// or when the subscription is closed.
+func (s *Subscription[E]) WaitChan() <-chan container.Unit {
+	return s.signal
+}
+
+// Unsubscribe stops delivery to the subscription and closes it.
+//
+// Pending elements may still be dequeued.
+func (s *Subscription[E]) Unsubscribe() {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	delete(s.b.subs, s)
+	s.close()
+}
+
+// close marks the subscription as closed and closes its channels.
+func (s *Subscription[E]) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.gone)
+		close(s.signal)
+	}
+}
@@ -0,0 +1,254 @@
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fgm/container"
+	"github.com/fgm/container/queue"
+)
+
+// drainSubscription dequeues all pending elements from s.
+func drainSubscription[E any](s *queue.Subscription[E]) []E {
+	var items []E
+	for {
+		e, ok, _ := s.Dequeue()
+		if !ok {
+			return items
+		}
+		items = append(items, e)
+	}
+}
+
+func TestNewBroadcast(t *testing.T) {
+	if _, err := queue.NewBroadcast[int](-1); !errors.Is(err, queue.ErrRetentionIsNegative) {
+		t.Errorf("got %v but expected %v", err, queue.ErrRetentionIsNegative)
+	}
+	b, err := queue.NewBroadcast[int](0)
+	if err != nil {
+		t.Fatalf("failed creating broadcast: %v", err)
+	}
+	if _, err := b.Subscribe(queue.SubscriptionOptions{LowWatermark: 2, HighWatermark: 1}); !errors.Is(err, queue.ErrHighWatermarkIsLessThanLowWatermark) {
+		t.Errorf("got %v but expected %v", err, queue.ErrHighWatermarkIsLessThanLowWatermark)
+	}
+	if _, err := b.Subscribe(queue.SubscriptionOptions{Policy: queue.Block + 1}); !errors.Is(err, queue.ErrUnknownSlowSubscriber) {
+		t.Errorf("got %v but expected %v", err, queue.ErrUnknownSlowSubscriber)
+	}
+}
+
+func TestBroadcast_FanOut(t *testing.T) {
+	b, _ := queue.NewBroadcast[int](0)
+	s1, _ := b.Subscribe(queue.SubscriptionOptions{})
+	s2, _ := b.Subscribe(queue.SubscriptionOptions{})
+	if b.Len() != 2 {
+		t.Fatalf("got %d subscriptions but expected 2", b.Len())
+	}
+	for i := range 3 {
+		if err := b.Publish(t.Context(), i); err != nil {
+			t.Fatalf("failed publishing: %v", err)
+		}
+	}
+	expected := []int{0, 1, 2}
+	for _, s := range []*queue.Subscription[int]{s1, s2} {
+		<-s.WaitChan()
+		if actual := drainSubscription(s); !slices.Equal(actual, expected) {
+			t.Errorf("got %v but expected %v", actual, expected)
+		}
+	}
+}
+
+func TestBroadcast_Watermarks(t *testing.T) {
+	b, _ := queue.NewBroadcast[int](0)
+	low, _ := b.Subscribe(queue.SubscriptionOptions{Capacity: 10, LowWatermark: 0, HighWatermark: 1})
+	high, _ := b.Subscribe(queue.SubscriptionOptions{Capacity: 10, LowWatermark: 5, HighWatermark: 8})
+	for i := range 3 {
+		_ = b.Publish(t.Context(), i)
+	}
+	if _, _, wqs := low.Dequeue(); wqs != container.QueueIsAboveHighWatermark {
+		t.Errorf("got %s but expected %s", wqs, container.QueueIsAboveHighWatermark)
+	}
+	if _, _, wqs := high.Dequeue(); wqs != container.QueueIsBelowLowWatermark {
+		t.Errorf("got %s but expected %s", wqs, container.QueueIsBelowLowWatermark)
+	}
+}
+
+func TestBroadcast_UnboundedWatermarks(t *testing.T) {
+	b, _ := queue.NewBroadcast[int](0)
+	s, _ := b.Subscribe(queue.SubscriptionOptions{LowWatermark: 2, HighWatermark: 8})
+	for i := range 20 {
+		_ = b.Publish(t.Context(), i)
+	}
+	// Unbounded subscriptions have no saturation, even far above their high watermark.
+	expected := map[int]container.WaitableQueueState{
+		19: container.QueueIsAboveHighWatermark,
+		8:  container.QueueIsAboveHighWatermark,
+		7:  container.QueueIsNominal,
+		2:  container.QueueIsBelowLowWatermark,
+	}
+	for remaining := 19; remaining >= 0; remaining-- {
+		_, _, wqs := s.Dequeue()
+		if e, ok := expected[remaining]; ok && wqs != e {
+			t.Errorf("got %s with %d pending but expected %s", wqs, remaining, e)
+		}
+	}
+}
+
+func TestBroadcast_SlowSubscribers(t *testing.T) {
+	tests := [...]struct {
+		name     string
+		policy   queue.SlowSubscriberPolicy
+		expected []int
+	}{
+		{"drop newest", queue.DropNewest, []int{0, 1}},
+		{"drop oldest", queue.DropOldest, []int{3, 4}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b, _ := queue.NewBroadcast[int](0)
+			s, _ := b.Subscribe(queue.SubscriptionOptions{Capacity: 2, Policy: test.policy})
+			for i := range 5 {
+				if err := b.Publish(t.Context(), i); err != nil {
+					t.Fatalf("failed publishing: %v", err)
+				}
+			}
+			if s.Dropped() != 3 {
+				t.Errorf("got %d dropped but expected 3", s.Dropped())
+			}
+			if actual := drainSubscription(s); !slices.Equal(actual, test.expected) {
+				t.Errorf("got %v but expected %v", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestBroadcast_Block(t *testing.T) {
+	b, _ := queue.NewBroadcast[int](0)
+	s, _ := b.Subscribe(queue.SubscriptionOptions{Capacity: 1, Policy: queue.Block})
+	_ = b.Publish(t.Context(), 0)
+
+	// A full subscription blocks until its context is canceled...
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+	if err := b.Publish(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v but expected %v", err, context.DeadlineExceeded)
+	}
+
+	// ...or until it has room.
+	done := make(chan error)
+	go func() { done <- b.Publish(t.Context(), 2) }()
+	if e, ok, _ := s.Dequeue(); !ok || e != 0 {
+		t.Errorf("got %d, %t but expected 0, true", e, ok)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("failed publishing: %v", err)
+	}
+
+	// ...or until it is unsubscribed.
+	go func() { done <- b.Publish(t.Context(), 3) }()
+	time.Sleep(10 * time.Millisecond) // Let Publish block on the full subscription.
+	s.Unsubscribe()
+	if err := <-done; err != nil {
+		t.Errorf("failed publishing: %v", err)
+	}
+	if b.Len() != 0 {
+		t.Errorf("got %d subscriptions after unsubscribing", b.Len())
+	}
+	if actual, expected := drainSubscription(s), []int{2}; !slices.Equal(actual, expected) {
+		t.Errorf("got %v but expected %v", actual, expected)
+	}
+	if s.Dropped() != 0 {
+		t.Errorf("got %d dropped with Block policy", s.Dropped())
+	}
+}
+
+func TestBroadcast_FromOldest(t *testing.T) {
+	b, _ := queue.NewBroadcast[int](3)
+	early, _ := b.Subscribe(queue.SubscriptionOptions{FromOldest: true})
+	for i := range 5 {
+		_ = b.Publish(t.Context(), i)
+	}
+	late, _ := b.Subscribe(queue.SubscriptionOptions{FromOldest: true})
+	lateSmall, _ := b.Subscribe(queue.SubscriptionOptions{Capacity: 2, Policy: queue.DropOldest, FromOldest: true})
+	latest, _ := b.Subscribe(queue.SubscriptionOptions{})
+	_ = b.Publish(t.Context(), 5)
+
+	tests := [...]struct {
+		name     string
+		sub      *queue.Subscription[int]
+		expected []int
+	}{
+		{"early", early, []int{0, 1, 2, 3, 4, 5}},
+		{"late", late, []int{2, 3, 4, 5}},
+		{"late with small capacity", lateSmall, []int{4, 5}},
+		{"latest", latest, []int{5}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := drainSubscription(test.sub); !slices.Equal(actual, test.expected) {
+				t.Errorf("got %v but expected %v", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestBroadcast_Close(t *testing.T) {
+	b, _ := queue.NewBroadcast[int](1)
+	s, _ := b.Subscribe(queue.SubscriptionOptions{})
+	_ = b.Publish(t.Context(), 1)
+	b.Close()
+	b.Close() // Idempotent
+	s.Unsubscribe()
+	if err := b.Publish(t.Context(), 2); !errors.Is(err, queue.ErrBroadcastIsClosed) {
+		t.Errorf("got %v but expected %v", err, queue.ErrBroadcastIsClosed)
+	}
+	if _, err := b.Subscribe(queue.SubscriptionOptions{}); !errors.Is(err, queue.ErrBroadcastIsClosed) {
+		t.Errorf("got %v but expected %v", err, queue.ErrBroadcastIsClosed)
+	}
+	<-s.WaitChan() // Pending signal from Publish
+	if _, ok := <-s.WaitChan(); ok {
+		t.Errorf("WaitChan should be closed")
+	}
+	if actual, expected := drainSubscription(s), []int{1}; !slices.Equal(actual, expected) {
+		t.Errorf("got %v but expected %v", actual, expected)
+	}
+}
+
+func TestBroadcast_Concurrent(t *testing.T) {
+	const (
+		subscribers = 4
+		items       = 200
+	)
+	b, _ := queue.NewBroadcast[int](0)
+	var wg sync.WaitGroup
+	wg.Add(subscribers)
+	results := make([][]int, subscribers)
+	for i := range subscribers {
+		s, _ := b.Subscribe(queue.SubscriptionOptions{Capacity: 8, Policy: queue.Block})
+		go func() {
+			defer wg.Done()
+			for {
+				results[i] = append(results[i], drainSubscription(s)...)
+				if _, ok := <-s.WaitChan(); !ok {
+					results[i] = append(results[i], drainSubscription(s)...)
+					return
+				}
+			}
+		}()
+	}
+	for i := range items {
+		if err := b.Publish(t.Context(), i); err != nil {
+			t.Fatalf("failed publishing: %v", err)
+		}
+	}
+	b.Close()
+	wg.Wait()
+	for i, actual := range results {
+		if len(actual) != items || !slices.IsSorted(actual) {
+			t.Errorf("subscriber %d got %d items, sorted: %t", i, len(actual), slices.IsSorted(actual))
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package queue
+
+import "time"
+
+// Clock abstracts the passing of time, allowing deterministic tests.
+//
+// It is used by RateLimitedQueue and ExpiringWaitableQueue.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// systemClock is the Clock based on the time package.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
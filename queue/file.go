@@ -0,0 +1,529 @@
+package queue
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrCodecIsNil          = errors.New("container: codec cannot be nil")
+	ErrCorruptSegment      = errors.New("container: corrupt segment")
+	ErrSegmentSizeTooSmall = errors.New("container: segment size is too small")
+	ErrSyncEveryIsNegative = errors.New("container: sync frequency cannot be negative")
+
+	errChecksumMismatch = errors.New("checksum mismatch")
+	errRecordTooLarge   = errors.New("record too large")
+)
+
+const (
+	// DefaultSegmentSize is the maximum size of a FileQueue segment unless configured otherwise.
+	DefaultSegmentSize = 64 << 20
+
+	offsetFile    = "offset"
+	recordHeader  = 8 // Payload length and CRC32, as big-endian uint32 each
+	segmentSuffix = ".seg"
+)
+
+// Codec converts elements to and from their persistent representation.
+type Codec[E any] interface {
+	Encode(E) ([]byte, error)
+	Decode([]byte) (E, error)
+}
+
+// JSONCodec is a Codec using encoding/json.
+type JSONCodec[E any] struct{}
+
+func (JSONCodec[E]) Encode(e E) ([]byte, error) { return json.Marshal(e) }
+
+func (JSONCodec[E]) Decode(b []byte) (E, error) {
+	var e E
+	err := json.Unmarshal(b, &e)
+	return e, err
+}
+
+// FileQueueOptions configures a FileQueue.
+type FileQueueOptions struct {
+	// SegmentSize is the size in bytes above which a new segment file is started.
+	// Zero means DefaultSegmentSize.
+	SegmentSize int64
+	// SyncEvery is the number of operations, Enqueue or Dequeue, after which the
+	// last segment and the consumer offset are synced to disk.
+	// Zero means they are only synced by Sync and Close: use 1 for maximum durability.
+	SyncEvery int
+}
+
+// FileQueue is a container.Queue persisting its elements in a directory,
+// to survive process restarts.
+//
+// Elements are appended to a write-ahead log split into segment files,
+// and the offset of the next element to dequeue is stored alongside them.
+// Segments are deleted once all their elements have been dequeued.
+//
+// Delivery is at-least-once: after a crash, elements dequeued since the last
+// sync are dequeued again.
+//
+// Since container.Queue methods do not return errors, they are reported by Err.
+// The first I/O error, including a record failing to decode, is kept, and the
+// queue then behaves as empty: Len returns 0, Dequeue returns no element, and
+// Enqueue drops its element.
+// An element failing to encode is only dropped, leaving the queue usable:
+// Err reports it until the next Enqueue.
+// Check Err after Enqueue when losing elements matters.
+//
+// Like the other Queue implementations, it is not concurrency-safe:
+// use NewWaitableFromQueue to obtain a concurrency-safe waitable variant.
+// Keep the FileQueue to shut it down: once the waitable variant is closed and
+// no longer used, call Close on the FileQueue, then check Err.
+type FileQueue[E any] struct {
+	codec    Codec[E]
+	dir      string
+	encErr   error // Encoding error of the last Enqueue, if any
+	err      error // First I/O error, after which the queue behaves as empty
+	ops      int   // Operations since the last sync
+	reader   *bufio.Reader
+	readFile *os.File
+	readSeg  int      // Index in segments of the segment being read
+	readSeq  uint64   // Sequence number of the next element to dequeue
+	segments []uint64 // Sequence number of the first element in each segment, ascending
+	segSize  int64
+	syncN    int
+	write    *os.File // The last segment
+	written  int64    // Size of the last segment
+	writeSeq uint64   // Sequence number of the next element to enqueue
+}
+
+// NewFileQueue opens the FileQueue stored in dir, creating it if needed.
+//
+// Existing elements are recovered by replaying the log, and an incomplete record
+// at the end of the last segment, typically left by a crash, is discarded.
+func NewFileQueue[E any](dir string, codec Codec[E], opts FileQueueOptions) (*FileQueue[E], error) {
+	if codec == nil {
+		return nil, ErrCodecIsNil
+	}
+	if opts.SegmentSize == 0 {
+		opts.SegmentSize = DefaultSegmentSize
+	}
+	if opts.SegmentSize <= recordHeader {
+		return nil, fmt.Errorf("%w: got %d", ErrSegmentSizeTooSmall, opts.SegmentSize)
+	}
+	if opts.SyncEvery < 0 {
+		return nil, fmt.Errorf("%w: got %d", ErrSyncEveryIsNegative, opts.SyncEvery)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	fq := &FileQueue[E]{
+		codec:   codec,
+		dir:     dir,
+		segSize: opts.SegmentSize,
+		syncN:   opts.SyncEvery,
+	}
+	if err := fq.recover(); err != nil {
+		fq.closeFiles()
+		return nil, err
+	}
+	return fq, nil
+}
+
+// segmentPath returns the path of the segment starting at the given sequence number.
+func (fq *FileQueue[E]) segmentPath(start uint64) string {
+	return filepath.Join(fq.dir, fmt.Sprintf("%020d%s", start, segmentSuffix))
+}
+
+// recover rebuilds the queue state from the directory contents.
+func (fq *FileQueue[E]) recover() error {
+	entries, err := os.ReadDir(fq.dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), segmentSuffix)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		start, err := strconv.ParseUint(name, 10, 64)
+		if err != nil {
+			continue
+		}
+		fq.segments = append(fq.segments, start)
+	}
+	slices.Sort(fq.segments)
+
+	offset, err := fq.readOffset()
+	if err != nil {
+		return err
+	}
+	if len(fq.segments) == 0 {
+		fq.segments = []uint64{offset}
+	}
+
+	// Check segments are contiguous, and find the end of the log.
+	fq.writeSeq = fq.segments[0]
+	for i, start := range fq.segments {
+		if start != fq.writeSeq {
+			return fmt.Errorf("%w: %s starts at %d, expected %d", ErrCorruptSegment, fq.segmentPath(start), start, fq.writeSeq)
+		}
+		count, size, err := fq.scan(start, i == len(fq.segments)-1)
+		if err != nil {
+			return err
+		}
+		fq.writeSeq += count
+		fq.written = size
+	}
+
+	// Offsets outside the log may come from segments compacted before the offset was synced.
+	fq.readSeq = min(max(offset, fq.segments[0]), fq.writeSeq)
+
+	if fq.write, err = os.OpenFile(fq.segmentPath(fq.segments[len(fq.segments)-1]), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644); err != nil {
+		return err
+	}
+	if err := fq.syncDir(); err != nil {
+		return err
+	}
+	if err := fq.truncate(fq.written); err != nil {
+		return err
+	}
+
+	// Compact segments consumed before the crash, then position the reader.
+	for len(fq.segments) > 1 && fq.segments[1] <= fq.readSeq {
+		if err := os.Remove(fq.segmentPath(fq.segments[0])); err != nil {
+			return err
+		}
+		fq.segments = fq.segments[1:]
+	}
+	if err := fq.openReader(0); err != nil {
+		return err
+	}
+	for range fq.readSeq - fq.segments[0] {
+		if _, err := fq.readRecord(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// truncate discards the end of the last segment after the given size, if any.
+func (fq *FileQueue[E]) truncate(size int64) error {
+	fi, err := fq.write.Stat()
+	if err != nil {
+		return err
+	}
+	if fi.Size() == size {
+		return nil
+	}
+	if err := fq.write.Truncate(size); err != nil {
+		return err
+	}
+	return fq.write.Sync()
+}
+
+// scan counts the valid records in a segment, and returns their total size.
+//
+// An incomplete or corrupt record is only accepted at the end of the last segment.
+func (fq *FileQueue[E]) scan(start uint64, last bool) (count uint64, size int64, err error) {
+	f, err := os.Open(fq.segmentPath(start))
+	if errors.Is(err, os.ErrNotExist) && last {
+		return 0, 0, nil // Segment to be created
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return 0, 0, err
+	}
+	r := bufio.NewReader(f)
+	for {
+		payload, err := readRecord(r, fi.Size()-size-recordHeader)
+		if err == io.EOF {
+			return count, size, nil
+		}
+		if err != nil {
+			if last {
+				return count, size, nil
+			}
+			return 0, 0, fmt.Errorf("%w: %s: %w", ErrCorruptSegment, fq.segmentPath(start), err)
+		}
+		count++
+		size += recordHeader + int64(len(payload))
+	}
+}
+
+// readRecord reads a record no larger than maxSize, checking its CRC.
+//
+// It returns io.EOF at the end of the input, and io.ErrUnexpectedEOF on an incomplete record.
+func readRecord(r io.Reader, maxSize int64) ([]byte, error) {
+	var header [recordHeader]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(header[:4])
+	if int64(n) > maxSize {
+		// Do not allocate a garbage size.
+		return nil, fmt.Errorf("%w: record size %d", errRecordTooLarge, n)
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:]) {
+		return nil, errChecksumMismatch
+	}
+	return payload, nil
+}
+
+// readRecord reads the next record from the segment being read.
+func (fq *FileQueue[E]) readRecord() ([]byte, error) {
+	payload, err := readRecord(fq.reader, math.MaxUint32) // Records were checked by scan or written by Enqueue
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrCorruptSegment, fq.readFile.Name(), err)
+	}
+	return payload, nil
+}
+
+// openReader starts reading the segment at index i in segments.
+func (fq *FileQueue[E]) openReader(i int) error {
+	if fq.readFile != nil {
+		if err := fq.readFile.Close(); err != nil {
+			return err
+		}
+	}
+	f, err := os.Open(fq.segmentPath(fq.segments[i]))
+	if err != nil {
+		return err
+	}
+	fq.readFile, fq.reader, fq.readSeg = f, bufio.NewReader(f), i
+	return nil
+}
+
+// readOffset returns the synced consumer offset, or 0 if none was synced.
+func (fq *FileQueue[E]) readOffset() (uint64, error) {
+	b, err := os.ReadFile(filepath.Join(fq.dir, offsetFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if len(b) != 8 {
+		return 0, fmt.Errorf("%w: invalid offset file", ErrCorruptSegment)
+	}
+	return binary.BigEndian.Uint64(b), nil
+}
+
+// writeOffset atomically replaces the offset file with the current consumer offset.
+func (fq *FileQueue[E]) writeOffset() error {
+	path := filepath.Join(fq.dir, offsetFile)
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := binary.Write(f, binary.BigEndian, fq.readSeq); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	return fq.syncDir()
+}
+
+// syncDir flushes the directory to disk, so that the files created or renamed in it survive a crash.
+func (fq *FileQueue[E]) syncDir() error {
+	d, err := os.Open(fq.dir)
+	if err != nil {
+		return err
+	}
+	if err := d.Sync(); err != nil {
+		d.Close()
+		return err
+	}
+	return d.Close()
+}
+
+// Enqueue appends e to the log, starting a new segment if the last one is full.
+//
+// If e fails to encode, it is dropped and Err reports why until the next Enqueue.
+func (fq *FileQueue[E]) Enqueue(e E) {
+	if fq.err != nil {
+		return
+	}
+	if fq.write == nil {
+		fq.err = ErrQueueIsClosed
+		return
+	}
+	payload, err := fq.codec.Encode(e)
+	if fq.encErr = err; err != nil {
+		return
+	}
+	record := make([]byte, recordHeader+len(payload))
+	binary.BigEndian.PutUint32(record[:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(payload))
+	copy(record[recordHeader:], payload)
+
+	if fq.written > 0 && fq.written+int64(len(record)) > fq.segSize {
+		if fq.err = fq.roll(); fq.err != nil {
+			return
+		}
+	}
+	if _, fq.err = fq.write.Write(record); fq.err != nil {
+		return
+	}
+	fq.written += int64(len(record))
+	fq.writeSeq++
+	fq.tick()
+}
+
+// roll syncs and closes the last segment, and starts a new one.
+func (fq *FileQueue[E]) roll() error {
+	if err := fq.write.Sync(); err != nil {
+		return err
+	}
+	if err := fq.write.Close(); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(fq.segmentPath(fq.writeSeq), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if err := fq.syncDir(); err != nil {
+		f.Close()
+		return err
+	}
+	fq.segments = append(fq.segments, fq.writeSeq)
+	fq.write, fq.written = f, 0
+	return nil
+}
+
+// Dequeue removes the first element from the queue, deleting its segment
+// once all the elements in it have been dequeued.
+func (fq *FileQueue[E]) Dequeue() (E, bool) {
+	var zero E
+	if fq.err != nil || fq.readFile == nil || fq.readSeq == fq.writeSeq {
+		return zero, false
+	}
+	if next := fq.readSeg + 1; next < len(fq.segments) && fq.readSeq == fq.segments[next] {
+		if fq.err = fq.compact(); fq.err != nil {
+			return zero, false
+		}
+	}
+	payload, err := fq.readRecord()
+	if err != nil {
+		fq.err = err
+		return zero, false
+	}
+	e, err := fq.codec.Decode(payload)
+	if err != nil {
+		fq.err = err
+		return zero, false
+	}
+	fq.readSeq++
+	fq.tick()
+	return e, true
+}
+
+// compact moves the reader to the next segment, and deletes the consumed one.
+//
+// The offset is synced first, so that a crash cannot leave it in a deleted segment.
+func (fq *FileQueue[E]) compact() error {
+	if err := fq.openReader(fq.readSeg + 1); err != nil {
+		return err
+	}
+	if err := fq.writeOffset(); err != nil {
+		return err
+	}
+	if err := os.Remove(fq.segmentPath(fq.segments[0])); err != nil {
+		return err
+	}
+	fq.segments = fq.segments[1:]
+	fq.readSeg--
+	return nil
+}
+
+// tick counts an operation, and syncs if the policy requires it.
+func (fq *FileQueue[E]) tick() {
+	if fq.syncN == 0 {
+		return
+	}
+	if fq.ops++; fq.ops >= fq.syncN {
+		fq.err = fq.Sync()
+	}
+}
+
+// Len returns the number of elements in the queue, or 0 after an error.
+func (fq *FileQueue[E]) Len() int {
+	if fq.err != nil {
+		return 0
+	}
+	return int(fq.writeSeq - fq.readSeq)
+}
+
+// Err returns the first I/O error met by the queue if any, or else the error
+// encoding the element of the last Enqueue, if any.
+func (fq *FileQueue[E]) Err() error {
+	if fq.err != nil {
+		return fq.err
+	}
+	return fq.encErr
+}
+
+// Sync flushes the last segment and the consumer offset to disk.
+func (fq *FileQueue[E]) Sync() error {
+	if fq.write == nil {
+		return ErrQueueIsClosed
+	}
+	fq.ops = 0
+	if err := fq.write.Sync(); err != nil {
+		return err
+	}
+	return fq.writeOffset()
+}
+
+// Close syncs the queue and closes its files. The queue MUST NOT be used afterwards.
+func (fq *FileQueue[E]) Close() error {
+	if fq.write == nil {
+		return nil
+	}
+	err := fq.Sync()
+	if cerr := fq.closeFiles(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// closeFiles closes the open files, returning the first error met.
+func (fq *FileQueue[E]) closeFiles() error {
+	var errs []error
+	if fq.readFile != nil {
+		errs = append(errs, fq.readFile.Close())
+		fq.readFile, fq.reader = nil, nil
+	}
+	if fq.write != nil {
+		errs = append(errs, fq.write.Close())
+		fq.write = nil
+	}
+	return errors.Join(errs...)
+}
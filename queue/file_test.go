@@ -0,0 +1,280 @@
+package queue_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/fgm/container"
+	"github.com/fgm/container/queue"
+)
+
+// mustFileQueue opens a FileQueue of ints in dir, closing it at the end of the test.
+func mustFileQueue(t *testing.T, dir string, opts queue.FileQueueOptions) *queue.FileQueue[int] {
+	t.Helper()
+	fq, err := queue.NewFileQueue[int](dir, queue.JSONCodec[int]{}, opts)
+	if err != nil {
+		t.Fatalf("Failed to open queue: %v", err)
+	}
+	t.Cleanup(func() { _ = fq.Close() })
+	return fq
+}
+
+// drainFileQueue dequeues all elements from fq.
+func drainFileQueue(t *testing.T, fq *queue.FileQueue[int]) []int {
+	t.Helper()
+	var actual []int
+	for e, ok := fq.Dequeue(); ok; e, ok = fq.Dequeue() {
+		actual = append(actual, e)
+	}
+	if err := fq.Err(); err != nil {
+		t.Fatalf("Failed to dequeue: %v", err)
+	}
+	return actual
+}
+
+// segments returns the names of the segment files in dir.
+func segments(t *testing.T, dir string) []string {
+	t.Helper()
+	names, err := filepath.Glob(filepath.Join(dir, "*.seg"))
+	if err != nil {
+		t.Fatalf("Failed to list segments: %v", err)
+	}
+	return names
+}
+
+func TestNewFileQueue(t *testing.T) {
+	dir := t.TempDir()
+	tests := [...]struct {
+		name     string
+		codec    queue.Codec[int]
+		opts     queue.FileQueueOptions
+		expected error
+	}{
+		{"nil codec", nil, queue.FileQueueOptions{}, queue.ErrCodecIsNil},
+		{"small segment", queue.JSONCodec[int]{}, queue.FileQueueOptions{SegmentSize: 8}, queue.ErrSegmentSizeTooSmall},
+		{"negative sync", queue.JSONCodec[int]{}, queue.FileQueueOptions{SyncEvery: -1}, queue.ErrSyncEveryIsNegative},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := queue.NewFileQueue(dir, test.codec, test.opts); !errors.Is(err, test.expected) {
+				t.Errorf("got %v but expected %v", err, test.expected)
+			}
+		})
+	}
+}
+
+func TestFileQueuePop(t *testing.T) {
+	testDequeue(t, mustFileQueue(t, t.TempDir(), queue.FileQueueOptions{}), true)
+}
+
+func TestFileQueueFIFO(t *testing.T) {
+	testFIFO(t, mustFileQueue(t, t.TempDir(), queue.FileQueueOptions{SegmentSize: 32}))
+}
+
+func TestFileQueue_Restart(t *testing.T) {
+	dir := t.TempDir()
+	fq := mustFileQueue(t, dir, queue.FileQueueOptions{})
+	for i := range 5 {
+		fq.Enqueue(i)
+	}
+	fq.Dequeue()
+	fq.Dequeue()
+	if err := fq.Close(); err != nil {
+		t.Fatalf("Failed to close queue: %v", err)
+	}
+	if _, ok := fq.Dequeue(); ok {
+		t.Errorf("dequeued from closed queue")
+	}
+	fq.Enqueue(5)
+	if err := fq.Err(); !errors.Is(err, queue.ErrQueueIsClosed) {
+		t.Errorf("got %v but expected %v", err, queue.ErrQueueIsClosed)
+	}
+
+	fq = mustFileQueue(t, dir, queue.FileQueueOptions{})
+	if fq.Len() != 3 {
+		t.Errorf("got length %d but expected 3", fq.Len())
+	}
+	fq.Enqueue(5)
+	if actual, expected := drainFileQueue(t, fq), []int{2, 3, 4, 5}; !slices.Equal(actual, expected) {
+		t.Errorf("got %v but expected %v", actual, expected)
+	}
+}
+
+func TestFileQueue_Crash(t *testing.T) {
+	tests := [...]struct {
+		name      string
+		syncEvery int
+		expected  []int
+	}{
+		{"sync on close only", 0, []int{0, 1, 2}}, // At-least-once: redelivery of elements dequeued before the crash
+		{"sync on each operation", 1, []int{1, 2}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			// Not closed, as in a crash.
+			crashed := mustFileQueue(t, dir, queue.FileQueueOptions{SyncEvery: test.syncEvery})
+			for i := range 3 {
+				crashed.Enqueue(i)
+			}
+			crashed.Dequeue()
+
+			fq := mustFileQueue(t, dir, queue.FileQueueOptions{})
+			if actual := drainFileQueue(t, fq); !slices.Equal(actual, test.expected) {
+				t.Errorf("got %v but expected %v", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestFileQueue_TornWrite(t *testing.T) {
+	dir := t.TempDir()
+	fq := mustFileQueue(t, dir, queue.FileQueueOptions{})
+	fq.Enqueue(1)
+	fq.Enqueue(2)
+	_ = fq.Close()
+
+	// Simulate a crash in the middle of appending a record.
+	last := segments(t, dir)[0]
+	f, err := os.OpenFile(last, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatalf("Failed to open segment: %v", err)
+	}
+	_, _ = f.Write([]byte{0, 0, 0, 42, 1, 2})
+	_ = f.Close()
+
+	fq = mustFileQueue(t, dir, queue.FileQueueOptions{})
+	if fq.Len() != 2 {
+		t.Errorf("got length %d but expected 2", fq.Len())
+	}
+	fq.Enqueue(3)
+	if actual, expected := drainFileQueue(t, fq), []int{1, 2, 3}; !slices.Equal(actual, expected) {
+		t.Errorf("got %v but expected %v", actual, expected)
+	}
+}
+
+func TestFileQueue_Segments(t *testing.T) {
+	dir := t.TempDir()
+	// Each record is 8 bytes of header and 1 byte of JSON payload: 2 records per segment.
+	fq := mustFileQueue(t, dir, queue.FileQueueOptions{SegmentSize: 20})
+	for i := range 6 {
+		fq.Enqueue(i)
+	}
+	if n := len(segments(t, dir)); n != 3 {
+		t.Errorf("got %d segments but expected 3", n)
+	}
+	for range 3 {
+		fq.Dequeue()
+	}
+	// The first segment is fully consumed, the second is being read.
+	if n := len(segments(t, dir)); n != 2 {
+		t.Errorf("got %d segments after dequeuing but expected 2", n)
+	}
+	_ = fq.Close()
+
+	// Corrupting a segment other than the last one is detected on restart.
+	if err := os.WriteFile(segments(t, dir)[0], []byte("garbage!!"), 0o644); err != nil {
+		t.Fatalf("Failed to corrupt segment: %v", err)
+	}
+	if _, err := queue.NewFileQueue[int](dir, queue.JSONCodec[int]{}, queue.FileQueueOptions{}); !errors.Is(err, queue.ErrCorruptSegment) {
+		t.Errorf("got %v but expected %v", err, queue.ErrCorruptSegment)
+	}
+}
+
+// failingCodec fails to encode negative numbers.
+type failingCodec struct {
+	queue.JSONCodec[int]
+}
+
+var errNegative = errors.New("negative")
+
+func (fc failingCodec) Encode(e int) ([]byte, error) {
+	if e < 0 {
+		return nil, errNegative
+	}
+	return fc.JSONCodec.Encode(e)
+}
+
+func TestFileQueue_CodecError(t *testing.T) {
+	fq, err := queue.NewFileQueue[int](t.TempDir(), failingCodec{}, queue.FileQueueOptions{})
+	if err != nil {
+		t.Fatalf("Failed to open queue: %v", err)
+	}
+	defer fq.Close()
+	fq.Enqueue(1)
+	fq.Enqueue(-1) // Dropped
+	if err := fq.Err(); !errors.Is(err, errNegative) {
+		t.Errorf("got %v but expected %v", err, errNegative)
+	}
+	// The queue remains usable, and the error is only reported until the next Enqueue.
+	fq.Enqueue(2)
+	if err := fq.Err(); err != nil {
+		t.Errorf("got %v but expected no error", err)
+	}
+	if actual, expected := drainFileQueue(t, fq), []int{1, 2}; !slices.Equal(actual, expected) {
+		t.Errorf("got %v but expected %v", actual, expected)
+	}
+}
+
+func TestFileQueue_WaitableCodecError(t *testing.T) {
+	fq, err := queue.NewFileQueue[int](t.TempDir(), failingCodec{}, queue.FileQueueOptions{})
+	if err != nil {
+		t.Fatalf("Failed to open queue: %v", err)
+	}
+	defer fq.Close()
+	wq, err := queue.NewWaitableFromQueue[int](fq, queue.WQCap, queue.WQLow, queue.WQHigh)
+	if err != nil {
+		t.Fatalf("Failed to create waitable queue: %v", err)
+	}
+	// Elements dropped by the failing codec are not counted.
+	for _, e := range []int{1, -1, 2, -2} {
+		wq.Enqueue(e)
+	}
+	if c := wq.(container.Countable); c.Len() != 2 {
+		t.Errorf("got length %d but expected 2", c.Len())
+	}
+	wq.Close()
+	if actual, expected := slices.Collect(queue.All(t.Context(), wq)), []int{1, 2}; !slices.Equal(actual, expected) {
+		t.Errorf("got %v but expected %v", actual, expected)
+	}
+}
+
+func TestFileQueue_Waitable(t *testing.T) {
+	dir := t.TempDir()
+	fq := mustFileQueue(t, dir, queue.FileQueueOptions{})
+	fq.Enqueue(1)
+	_ = fq.Close()
+
+	fq = mustFileQueue(t, dir, queue.FileQueueOptions{})
+	wq, err := queue.NewWaitableFromQueue[int](fq, queue.WQCap, queue.WQLow, queue.WQHigh)
+	if err != nil {
+		t.Fatalf("Failed to create waitable queue: %v", err)
+	}
+	// Elements recovered from disk are available.
+	if c := wq.(container.Countable); c.Len() != 1 {
+		t.Errorf("got length %d but expected 1", c.Len())
+	}
+	wq.Enqueue(2)
+	wq.Enqueue(3)
+	if e, ok, _ := wq.Dequeue(); !ok || e != 1 {
+		t.Errorf("got %d, %t but expected 1, true", e, ok)
+	}
+
+	// Shutdown: close the waitable queue, then the FileQueue, which syncs it.
+	wq.Close()
+	if err := fq.Close(); err != nil {
+		t.Fatalf("Failed to close queue: %v", err)
+	}
+	if err := fq.Err(); err != nil {
+		t.Errorf("got %v but expected no error", err)
+	}
+
+	// The remaining elements survive the restart.
+	fq = mustFileQueue(t, dir, queue.FileQueueOptions{})
+	if actual, expected := drainFileQueue(t, fq), []int{2, 3}; !slices.Equal(actual, expected) {
+		t.Errorf("got %v but expected %v", actual, expected)
+	}
+}
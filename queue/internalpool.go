@@ -7,7 +7,7 @@ import (
 
 type internalPoolQueue[E any] struct {
 	maxPool    int
-	pool       []types.ListElement[E]
+	pool       []*types.ListElement[E]
 	head, tail *types.ListElement[E]
 }
 
@@ -15,11 +15,12 @@ func (sq *internalPoolQueue[E]) Enqueue(e E) {
 	var le *types.ListElement[E]
 	if len(sq.pool) != 0 {
 		l := len(sq.pool) - 1
-		le, sq.pool = &sq.pool[l], sq.pool[0:l]
+		le, sq.pool = sq.pool[l], sq.pool[0:l]
 	} else {
 		le = &types.ListElement[E]{}
 	}
 	le.Value = e
+	le.Next = nil
 
 	if sq.head == nil {
 		sq.head = le
@@ -37,17 +38,19 @@ func (sq *internalPoolQueue[E]) Dequeue() (E, bool) {
 	}
 	le := sq.head
 	e := le.Value
-	if len(sq.pool) <= sq.maxPool {
-		sq.pool = append(sq.pool, *le)
-	}
 	// Single element queue : convert to empty.
 	if sq.head.Next == nil {
 		sq.head = nil
 		sq.tail = nil
-		return e, true
+	} else {
+		// Normal queue.
+		sq.head = sq.head.Next
+	}
+	// Recycle the element only once it is no longer linked.
+	if len(sq.pool) <= sq.maxPool {
+		le.Value = *new(E) // Prevent memory leak if E is a pointer type
+		sq.pool = append(sq.pool, le)
 	}
-	// Normal queue.
-	sq.head = sq.head.Next
 	return e, true
 }
 
@@ -55,6 +58,10 @@ func (sq *internalPoolQueue[E]) Dequeue() (E, bool) {
 func NewListInternalPoolQueue[E any](sizeHint int) container.Queue[E] {
 	q := &internalPoolQueue[E]{}
 	q.maxPool = sizeHint
-	q.pool = make([]types.ListElement[E], sizeHint)
+	q.pool = make([]*types.ListElement[E], sizeHint)
+	slab := make([]types.ListElement[E], sizeHint)
+	for i := range slab {
+		q.pool[i] = &slab[i]
+	}
 	return q
 }
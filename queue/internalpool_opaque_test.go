@@ -57,3 +57,7 @@ func BenchmarkListInternalPoolQueue_Enqueue_prealloc(b *testing.B) {
 func TestListInternalPoolQueuePop(t *testing.T) {
 	testDequeue(t, queue.NewListInternalPoolQueue[int](1), false)
 }
+
+func TestListInternalPoolQueueFIFO(t *testing.T) {
+	testFIFO(t, queue.NewListInternalPoolQueue[int](4))
+}
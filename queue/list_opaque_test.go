@@ -72,3 +72,7 @@ func TestListQueue(t *testing.T) {
 func TestListQueuePop(t *testing.T) {
 	testDequeue(t, queue.NewListQueue[int](1), false)
 }
+
+func TestListQueueFIFO(t *testing.T) {
+	testFIFO(t, queue.NewListQueue[int](4))
+}
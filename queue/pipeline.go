@@ -0,0 +1,219 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/fgm/container"
+)
+
+var (
+	ErrConcurrencyIsNotPositive = errors.New("container: concurrency must be positive")
+	ErrPipelineIsRunning        = errors.New("container: pipeline is already running")
+	ErrStageIsNil               = errors.New("container: stage function cannot be nil")
+	ErrUnknownErrorPolicy       = errors.New("container: unknown error policy")
+)
+
+// ErrorPolicy defines what a pipeline stage does when its function returns an error.
+type ErrorPolicy int
+
+const (
+	// StopOnError stops the whole pipeline, and Pipeline.Run returns the error.
+	StopOnError ErrorPolicy = iota
+	// SkipOnError drops the element which caused the error, and goes on.
+	SkipOnError
+)
+
+// StageOptions configures a pipeline stage.
+type StageOptions struct {
+	// Concurrency is the number of goroutines running the stage function.
+	Concurrency int
+	// Capacity, LowWatermark and HighWatermark configure the stage output queue,
+	// like in NewWaitableQueue.
+	Capacity, LowWatermark, HighWatermark int
+	// ErrorPolicy applies when the stage function returns an error.
+	ErrorPolicy ErrorPolicy
+	// OnError is called with each error skipped by SkipOnError. It may be nil.
+	OnError func(error)
+}
+
+// Pipeline runs a chain of stages connected by WaitableQueues.
+//
+// Build it with AddSource, then AddStage for each stage, and start it with Run.
+// Each stage output queue pauses the producing stage once it reaches its high
+// watermark, until the next stage drains it below its low watermark,
+// so that backpressure propagates upstream up to the source.
+//
+// Closing the source queue shuts the pipeline down in order: each stage
+// drains its input, then closes its output.
+type Pipeline struct {
+	mu      sync.Mutex
+	running bool
+	stages  []func(ctx context.Context, fail func(error))
+}
+
+// NewPipeline creates an empty Pipeline.
+func NewPipeline() *Pipeline {
+	return &Pipeline{}
+}
+
+// PipelineStage is a typed handle on the output of a pipeline stage,
+// used to chain the next stage.
+type PipelineStage[E any] struct {
+	index int // In the pipeline, the source being 0
+	out   *gated[E]
+	p     *Pipeline
+}
+
+// Output returns the queue receiving the elements produced by the stage,
+// for consumption by the pipeline user.
+//
+// It is closed once the stage is done, and MUST only be consumed if no other
+// stage is chained after this one.
+func (ps *PipelineStage[E]) Output() container.WaitableQueue[E] {
+	return ps.out
+}
+
+// AddSource defines the queue feeding the pipeline.
+//
+// Its producers are not paused by the pipeline, but may use the
+// WaitableQueueState returned by Enqueue for flow control.
+func AddSource[E any](p *Pipeline, source container.WaitableQueue[E]) *PipelineStage[E] {
+	return &PipelineStage[E]{out: &gated[E]{WaitableQueue: source}, p: p}
+}
+
+// AddStage chains a stage applying fn to each element produced by prev,
+// with opts.Concurrency goroutines.
+func AddStage[In, Out any](prev *PipelineStage[In], fn func(context.Context, In) (Out, error), opts StageOptions) (*PipelineStage[Out], error) {
+	if fn == nil {
+		return nil, ErrStageIsNil
+	}
+	if opts.Concurrency <= 0 {
+		return nil, fmt.Errorf("%w: got %d", ErrConcurrencyIsNotPositive, opts.Concurrency)
+	}
+	if opts.ErrorPolicy < StopOnError || opts.ErrorPolicy > SkipOnError {
+		return nil, fmt.Errorf("%w: got %d", ErrUnknownErrorPolicy, opts.ErrorPolicy)
+	}
+	q, err := NewWaitableQueue[Out](opts.Capacity, opts.LowWatermark, opts.HighWatermark)
+	if err != nil {
+		return nil, err
+	}
+
+	p := prev.p
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.running {
+		return nil, ErrPipelineIsRunning
+	}
+	next := &PipelineStage[Out]{index: len(p.stages) + 1, out: &gated[Out]{WaitableQueue: q}, p: p}
+	in, out := prev.out, next.out
+	p.stages = append(p.stages, func(ctx context.Context, fail func(error)) {
+		defer out.Close()
+		var wg sync.WaitGroup
+		wg.Add(opts.Concurrency)
+		for range opts.Concurrency {
+			go func() {
+				defer wg.Done()
+				for e := range All[In](ctx, in) {
+					res, err := fn(ctx, e)
+					if err != nil {
+						if opts.ErrorPolicy == StopOnError {
+							fail(fmt.Errorf("stage %d: %w", next.index, err))
+							return
+						}
+						if opts.OnError != nil {
+							opts.OnError(err)
+						}
+						continue
+					}
+					out.Enqueue(res)
+					if !out.wait(ctx) {
+						return
+					}
+				}
+			}()
+		}
+		wg.Wait()
+	})
+	return next, nil
+}
+
+// Run starts all the stages, then blocks until they are done: either because
+// the source was closed and all elements went through the pipeline,
+// or because ctx was canceled, or because a stage using StopOnError failed.
+//
+// It returns the error of the failed stage, or the context error.
+// It MUST NOT be called more than once.
+func (p *Pipeline) Run(ctx context.Context) error {
+	p.mu.Lock()
+	if p.running {
+		p.mu.Unlock()
+		return ErrPipelineIsRunning
+	}
+	p.running = true
+	stages := p.stages
+	p.mu.Unlock()
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	var wg sync.WaitGroup
+	wg.Add(len(stages))
+	for _, stage := range stages {
+		go func() {
+			defer wg.Done()
+			stage(ctx, cancel)
+		}()
+	}
+	wg.Wait()
+	return context.Cause(ctx)
+}
+
+// gated is a WaitableQueue between two pipeline stages, which pauses its
+// producers from the time it reaches its high watermark, until it gets back
+// below its low watermark.
+type gated[E any] struct {
+	container.WaitableQueue[E]
+	mu     sync.Mutex // Ensures the pause state matches the latest queue operation
+	resume chan unit  // Only non-nil while paused, closed on resumption
+}
+
+// Enqueue adds an item, pausing producers if the queue is above its high watermark.
+func (g *gated[E]) Enqueue(item E) container.WaitableQueueState {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	wqs := g.WaitableQueue.Enqueue(item)
+	if wqs >= container.QueueIsAboveHighWatermark && g.resume == nil {
+		g.resume = make(chan unit)
+	}
+	return wqs
+}
+
+// Dequeue removes an item, resuming producers if the queue is below its low watermark.
+func (g *gated[E]) Dequeue() (E, bool, container.WaitableQueueState) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	e, ok, wqs := g.WaitableQueue.Dequeue()
+	if wqs == container.QueueIsBelowLowWatermark && g.resume != nil {
+		close(g.resume)
+		g.resume = nil
+	}
+	return e, ok, wqs
+}
+
+// wait blocks while producers are paused, and returns false if ctx was canceled.
+func (g *gated[E]) wait(ctx context.Context) bool {
+	g.mu.Lock()
+	resume := g.resume
+	g.mu.Unlock()
+	if resume == nil {
+		return true
+	}
+	select {
+	case <-resume:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,190 @@
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fgm/container"
+	"github.com/fgm/container/queue"
+)
+
+var errOdd = errors.New("odd")
+
+func double(_ context.Context, i int) (int, error) { return 2 * i, nil }
+
+func failOnOdd(_ context.Context, i int) (int, error) {
+	if i%2 != 0 {
+		return 0, errOdd
+	}
+	return i, nil
+}
+
+// stageOptions returns valid StageOptions with the given concurrency and error policy.
+func stageOptions(concurrency int, policy queue.ErrorPolicy) queue.StageOptions {
+	return queue.StageOptions{
+		Concurrency:   concurrency,
+		Capacity:      queue.WQCap,
+		LowWatermark:  queue.WQLow,
+		HighWatermark: queue.WQHigh,
+		ErrorPolicy:   policy,
+	}
+}
+
+func TestAddStage(t *testing.T) {
+	src := queue.AddSource(queue.NewPipeline(), mustWaitable[int](t))
+	tests := [...]struct {
+		name     string
+		fn       func(context.Context, int) (int, error)
+		opts     queue.StageOptions
+		expected error
+	}{
+		{"nil function", nil, stageOptions(1, queue.StopOnError), queue.ErrStageIsNil},
+		{"zero concurrency", double, stageOptions(0, queue.StopOnError), queue.ErrConcurrencyIsNotPositive},
+		{"unknown policy", double, stageOptions(1, queue.SkipOnError+1), queue.ErrUnknownErrorPolicy},
+		{"bad watermarks", double, queue.StageOptions{Concurrency: 1, LowWatermark: 2, HighWatermark: 1}, queue.ErrHighWatermarkIsLessThanLowWatermark},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := queue.AddStage(src, test.fn, test.opts); !errors.Is(err, test.expected) {
+				t.Errorf("got %v but expected %v", err, test.expected)
+			}
+		})
+	}
+}
+
+func TestPipeline_Run(t *testing.T) {
+	source := mustWaitable[int](t)
+	p := queue.NewPipeline()
+	doubled, err := queue.AddStage(queue.AddSource(p, source), double, stageOptions(3, queue.StopOnError))
+	if err != nil {
+		t.Fatalf("Failed to add stage: %v", err)
+	}
+	format := func(_ context.Context, i int) (string, error) { return strconv.Itoa(i), nil }
+	formatted, err := queue.AddStage(doubled, format, stageOptions(1, queue.StopOnError))
+	if err != nil {
+		t.Fatalf("Failed to add stage: %v", err)
+	}
+
+	done := make(chan error)
+	go func() { done <- p.Run(t.Context()) }()
+	for i := range 100 {
+		source.Enqueue(i)
+	}
+	source.Close()
+
+	var actual []int
+	for s := range queue.All(t.Context(), formatted.Output()) {
+		i, _ := strconv.Atoi(s)
+		actual = append(actual, i)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("got %v but expected nil", err)
+	}
+	slices.Sort(actual)
+	if len(actual) != 100 || actual[0] != 0 || actual[99] != 198 {
+		t.Errorf("got %d elements from %d to %d, expected 100 from 0 to 198", len(actual), actual[0], actual[len(actual)-1])
+	}
+	if err := p.Run(t.Context()); !errors.Is(err, queue.ErrPipelineIsRunning) {
+		t.Errorf("got %v but expected %v", err, queue.ErrPipelineIsRunning)
+	}
+	if _, err := queue.AddStage(formatted, func(context.Context, string) (int, error) { return 0, nil }, stageOptions(1, queue.StopOnError)); !errors.Is(err, queue.ErrPipelineIsRunning) {
+		t.Errorf("got %v but expected %v", err, queue.ErrPipelineIsRunning)
+	}
+}
+
+func TestPipeline_ErrorPolicies(t *testing.T) {
+	t.Run("skip", func(t *testing.T) {
+		var skipped atomic.Int32
+		opts := stageOptions(2, queue.SkipOnError)
+		opts.OnError = func(err error) {
+			if errors.Is(err, errOdd) {
+				skipped.Add(1)
+			}
+		}
+		source := mustWaitable(t, 1, 2, 3, 4, 5)
+		source.Close()
+		p := queue.NewPipeline()
+		evens, _ := queue.AddStage(queue.AddSource(p, source), failOnOdd, opts)
+		if err := p.Run(t.Context()); err != nil {
+			t.Fatalf("got %v but expected nil", err)
+		}
+		actual := slices.Sorted(queue.All(t.Context(), evens.Output()))
+		if !slices.Equal(actual, []int{2, 4}) {
+			t.Errorf("got %v but expected [2 4]", actual)
+		}
+		if skipped.Load() != 3 {
+			t.Errorf("got %d skipped errors but expected 3", skipped.Load())
+		}
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		source := mustWaitable(t, 2, 4, 5, 6)
+		p := queue.NewPipeline()
+		evens, _ := queue.AddStage(queue.AddSource(p, source), failOnOdd, stageOptions(1, queue.StopOnError))
+		// The source is not closed: the pipeline stops on the error.
+		if err := p.Run(t.Context()); !errors.Is(err, errOdd) {
+			t.Errorf("got %v but expected %v", err, errOdd)
+		}
+		if actual := slices.Collect(queue.All(t.Context(), evens.Output())); !slices.Equal(actual, []int{2, 4}) {
+			t.Errorf("got %v but expected [2 4]", actual)
+		}
+	})
+}
+
+func TestPipeline_Cancel(t *testing.T) {
+	p := queue.NewPipeline()
+	out, _ := queue.AddStage(queue.AddSource(p, mustWaitable[int](t)), double, stageOptions(2, queue.StopOnError))
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+	if err := p.Run(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v but expected %v", err, context.DeadlineExceeded)
+	}
+	// Outputs are closed on cancellation.
+	if _, ok := <-out.Output().WaitChan(); ok {
+		t.Errorf("output WaitChan should be closed")
+	}
+}
+
+// waitForLen blocks until the queue holds n elements.
+func waitForLen(t *testing.T, q container.WaitableQueue[int], n int) {
+	t.Helper()
+	c := q.(container.Countable)
+	for deadline := time.Now().Add(time.Second); c.Len() != n; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("got length %d but expected %d", c.Len(), n)
+		}
+	}
+}
+
+func TestPipeline_Backpressure(t *testing.T) {
+	const total = 20
+	source := mustWaitable[int](t)
+	for i := range total {
+		source.Enqueue(i)
+	}
+	p := queue.NewPipeline()
+	stage, _ := queue.AddStage(queue.AddSource(p, source), double, stageOptions(1, queue.StopOnError))
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	go func() { _ = p.Run(ctx) }()
+
+	// The stage pauses once its output reaches the high watermark...
+	waitForLen(t, source, total-queue.WQHigh)
+	time.Sleep(10 * time.Millisecond)
+	waitForLen(t, source, total-queue.WQHigh)
+
+	// ...and resumes once it is drained below the low watermark.
+	out := stage.Output()
+	for range queue.WQHigh - queue.WQLow - 1 {
+		out.Dequeue()
+	}
+	time.Sleep(10 * time.Millisecond)
+	waitForLen(t, source, total-queue.WQHigh)
+	out.Dequeue()
+	waitForLen(t, source, total-queue.WQHigh-(queue.WQHigh-queue.WQLow))
+}
@@ -29,3 +29,28 @@ func testDequeue(t *testing.T, s container.Queue[int], expectCountable bool) {
 	}
 
 }
+
+// testFIFO checks ordering with interleaved operations, which exercises element recycling in pooled implementations.
+func testFIFO(t *testing.T, q container.Queue[int]) {
+	next := 0
+	for round := range 3 {
+		for i := range 5 {
+			q.Enqueue(round*5 + i)
+		}
+		for range 3 {
+			actual, ok := q.Dequeue()
+			if !ok || actual != next {
+				t.Fatalf("got %d, %t but expected %d, true", actual, ok, next)
+			}
+			next++
+		}
+	}
+	for ; next < 15; next++ {
+		if actual, ok := q.Dequeue(); !ok || actual != next {
+			t.Fatalf("got %d, %t but expected %d, true", actual, ok, next)
+		}
+	}
+	if _, ok := q.Dequeue(); ok {
+		t.Fatalf("successfully dequeued from empty queue")
+	}
+}
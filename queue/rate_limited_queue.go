@@ -0,0 +1,149 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/fgm/container"
+)
+
+var (
+	ErrBurstIsNegative       = errors.New("container: burst cannot be negative")
+	ErrIntervalIsNotPositive = errors.New("container: interval must be positive")
+	ErrLimitIsNotPositive    = errors.New("container: limit must be positive")
+	ErrQueueIsClosed         = errors.New("container: queue is closed")
+)
+
+// RateLimitOptions configures a RateLimitedQueue.
+type RateLimitOptions struct {
+	// Limit is the number of elements which may be dequeued per Interval.
+	Limit    int
+	Interval time.Duration
+	// Burst is the maximum number of elements which may be dequeued at once after
+	// an idle period. Zero means 1, the strictest value.
+	Burst int
+	// Clock defaults to the system clock.
+	Clock Clock
+}
+
+// RateLimitedQueue limits the rate at which elements are dequeued from a WaitableQueue,
+// using a token bucket refilled with Limit tokens per Interval, holding up to Burst tokens.
+//
+// It is concurrency-safe. Producers keep enqueueing to the underlying queue,
+// which SHOULD NOT be consumed directly, since that would bypass the limit.
+type RateLimitedQueue[E any] struct {
+	burst  float64
+	clock  Clock
+	last   time.Time // Last refill
+	mu     sync.Mutex
+	q      container.WaitableQueue[E]
+	rate   float64 // Tokens per nanosecond
+	tokens float64
+}
+
+// NewRateLimitedQueue creates a RateLimitedQueue consuming from q.
+func NewRateLimitedQueue[E any](q container.WaitableQueue[E], opts RateLimitOptions) (*RateLimitedQueue[E], error) {
+	if q == nil {
+		return nil, ErrBackingStoreIsNil
+	}
+	if opts.Limit <= 0 {
+		return nil, fmt.Errorf("%w: got %d", ErrLimitIsNotPositive, opts.Limit)
+	}
+	if opts.Interval <= 0 {
+		return nil, fmt.Errorf("%w: got %v", ErrIntervalIsNotPositive, opts.Interval)
+	}
+	if opts.Burst < 0 {
+		return nil, fmt.Errorf("%w: got %d", ErrBurstIsNegative, opts.Burst)
+	}
+	if opts.Burst == 0 {
+		opts.Burst = 1
+	}
+	if opts.Clock == nil {
+		opts.Clock = systemClock{}
+	}
+	return &RateLimitedQueue[E]{
+		burst:  float64(opts.Burst),
+		clock:  opts.Clock,
+		last:   opts.Clock.Now(),
+		q:      q,
+		rate:   float64(opts.Limit) / float64(opts.Interval),
+		tokens: float64(opts.Burst),
+	}, nil
+}
+
+// DequeueWait blocks until the rate limit allows dequeuing an element and one
+// is available, then removes and returns it with the state of the queue.
+//
+// It returns the context error if ctx is done first, and ErrQueueIsClosed
+// once the queue is closed and drained.
+//
+// A token is only kept when an element is dequeued: consumers waiting for
+// elements hold no token, so they cannot exceed the burst once elements arrive.
+func (rq *RateLimitedQueue[E]) DequeueWait(ctx context.Context) (E, container.WaitableQueueState, error) {
+	var zero E
+	closed := false
+	for {
+		if err := rq.take(ctx); err != nil {
+			return zero, container.QueueIsBelowLowWatermark, err
+		}
+		if e, ok, wqs := rq.q.Dequeue(); ok {
+			return e, wqs, nil
+		}
+		rq.refund()
+		if closed {
+			return zero, container.QueueIsBelowLowWatermark, ErrQueueIsClosed
+		}
+		select {
+		case <-ctx.Done():
+			return zero, container.QueueIsBelowLowWatermark, ctx.Err()
+		case _, ok := <-rq.q.WaitChan():
+			// On closure, try once more to drain the queue.
+			closed = !ok
+		}
+	}
+}
+
+// refill adds the tokens accumulated since the last refill.
+//
+// It MUST only be called while holding the mutex.
+func (rq *RateLimitedQueue[E]) refill() {
+	now := rq.clock.Now()
+	if elapsed := now.Sub(rq.last); elapsed > 0 {
+		rq.tokens = min(rq.burst, rq.tokens+float64(elapsed)*rq.rate)
+	}
+	rq.last = now
+}
+
+// take blocks until a token is available and removes it from the bucket,
+// or until ctx is done.
+func (rq *RateLimitedQueue[E]) take(ctx context.Context) error {
+	for {
+		rq.mu.Lock()
+		rq.refill()
+		if rq.tokens >= 1 {
+			rq.tokens--
+			rq.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration(math.Ceil((1 - rq.tokens) / rq.rate))
+		rq.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-rq.clock.After(wait):
+		}
+	}
+}
+
+// refund returns an unused token to the bucket.
+func (rq *RateLimitedQueue[E]) refund() {
+	rq.mu.Lock()
+	defer rq.mu.Unlock()
+	rq.refill()
+	rq.tokens = min(rq.burst, rq.tokens+1)
+}
@@ -0,0 +1,226 @@
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/fgm/container/queue"
+)
+
+func TestNewRateLimitedQueue(t *testing.T) {
+	q := mustWaitable[int](t)
+	tests := [...]struct {
+		name     string
+		q        bool
+		opts     queue.RateLimitOptions
+		expected error
+	}{
+		{"nil queue", false, queue.RateLimitOptions{Limit: 1, Interval: time.Second}, queue.ErrBackingStoreIsNil},
+		{"zero limit", true, queue.RateLimitOptions{Interval: time.Second}, queue.ErrLimitIsNotPositive},
+		{"zero interval", true, queue.RateLimitOptions{Limit: 1}, queue.ErrIntervalIsNotPositive},
+		{"negative burst", true, queue.RateLimitOptions{Limit: 1, Interval: time.Second, Burst: -1}, queue.ErrBurstIsNegative},
+		{"valid", true, queue.RateLimitOptions{Limit: 1, Interval: time.Second}, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var err error
+			if test.q {
+				_, err = queue.NewRateLimitedQueue(q, test.opts)
+			} else {
+				_, err = queue.NewRateLimitedQueue[int](nil, test.opts)
+			}
+			if !errors.Is(err, test.expected) {
+				t.Errorf("got %v but expected %v", err, test.expected)
+			}
+		})
+	}
+}
+
+// dequeueAsync runs DequeueWait in a goroutine, returning a channel receiving its element.
+func dequeueAsync(ctx context.Context, rq *queue.RateLimitedQueue[int]) <-chan int {
+	ch := make(chan int, 1)
+	go func() {
+		defer close(ch)
+		if e, _, err := rq.DequeueWait(ctx); err == nil {
+			ch <- e
+		}
+	}()
+	return ch
+}
+
+// waitForTimer blocks until DequeueWait waits on the clock.
+func waitForTimer(t *testing.T, clock *fakeClock) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); clock.Waiters() == 0; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("DequeueWait did not wait on the clock")
+		}
+	}
+}
+
+func TestRateLimitedQueue_DequeueWait(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	q := mustWaitable(t, 1, 2, 3, 4)
+	// 2 elements per second, and up to 2 at once.
+	rq, err := queue.NewRateLimitedQueue(q, queue.RateLimitOptions{Limit: 2, Interval: time.Second, Burst: 2, Clock: clock})
+	if err != nil {
+		t.Fatalf("Failed to create queue: %v", err)
+	}
+
+	// The burst is available immediately.
+	for _, expected := range []int{1, 2} {
+		if e, _, err := rq.DequeueWait(t.Context()); err != nil || e != expected {
+			t.Errorf("got %d, %v but expected %d, nil", e, err, expected)
+		}
+	}
+
+	// Then a token is added every 500ms.
+	ch := dequeueAsync(t.Context(), rq)
+	waitForTimer(t, clock)
+	clock.Advance(499 * time.Millisecond)
+	select {
+	case e := <-ch:
+		t.Fatalf("got %d before a token was available", e)
+	case <-time.After(10 * time.Millisecond):
+	}
+	clock.Advance(time.Millisecond)
+	if e := <-ch; e != 3 {
+		t.Errorf("got %d but expected 3", e)
+	}
+
+	// Tokens accumulate while idle, up to the burst.
+	clock.Advance(time.Minute)
+	q.Enqueue(5)
+	q.Enqueue(6)
+	for _, expected := range []int{4, 5} {
+		if e, _, err := rq.DequeueWait(t.Context()); err != nil || e != expected {
+			t.Errorf("got %d, %v but expected %d, nil", e, err, expected)
+		}
+	}
+	if clock.Waiters() != 0 {
+		t.Errorf("got %d waiters but expected none", clock.Waiters())
+	}
+}
+
+func TestRateLimitedQueue_Refund(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	q := mustWaitable[int](t)
+	rq, _ := queue.NewRateLimitedQueue(q, queue.RateLimitOptions{Limit: 1, Interval: time.Hour, Clock: clock})
+
+	// The only token is not kept while waiting for an element.
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+	if _, _, err := rq.DequeueWait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v but expected %v", err, context.DeadlineExceeded)
+	}
+
+	q.Enqueue(1)
+	q.Close()
+	if e, _, err := rq.DequeueWait(t.Context()); err != nil || e != 1 {
+		t.Errorf("got %d, %v but expected 1, nil", e, err)
+	}
+	clock.Advance(time.Hour)
+	if _, _, err := rq.DequeueWait(t.Context()); !errors.Is(err, queue.ErrQueueIsClosed) {
+		t.Errorf("got %v but expected %v", err, queue.ErrQueueIsClosed)
+	}
+}
+
+func TestRateLimitedQueue_IdleWaiters(t *testing.T) {
+	const burst, waiters = 3, 6
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	q := mustWaitable[int](t)
+	rq, _ := queue.NewRateLimitedQueue(q, queue.RateLimitOptions{Limit: 1, Interval: time.Hour, Burst: burst, Clock: clock})
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	// Waiters idle on an empty queue while the bucket is full, so they must not hold tokens.
+	results := make([]<-chan int, waiters)
+	for i := range results {
+		results[i] = dequeueAsync(ctx, rq)
+	}
+	time.Sleep(10 * time.Millisecond)
+	clock.Advance(10 * time.Hour)
+	for i := range 2 * waiters {
+		q.Enqueue(i)
+	}
+
+	// received counts the elements released within a short delay.
+	received := func() int {
+		n := 0
+		for deadline := time.After(20 * time.Millisecond); ; {
+			select {
+			case <-deadline:
+				return n
+			default:
+			}
+			for i, ch := range results {
+				select {
+				case _, ok := <-ch:
+					if ok {
+						n++
+					}
+					results[i] = nil
+				default:
+				}
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	if n := received(); n != burst {
+		t.Errorf("got %d elements released at once but expected %d", n, burst)
+	}
+	waitForTimer(t, clock)
+	clock.Advance(time.Hour)
+	if n := received(); n != 1 {
+		t.Errorf("got %d elements released after an interval but expected 1", n)
+	}
+}
+
+func TestRateLimitedQueue_All(t *testing.T) {
+	q := mustWaitable(t, 1, 2, 3)
+	q.Close()
+	// With the system clock, at a rate high enough not to slow down the test.
+	rq, _ := queue.NewRateLimitedQueue(q, queue.RateLimitOptions{Limit: 1000, Interval: time.Millisecond})
+	if actual := slices.Collect(queue.AllRateLimited(t.Context(), rq)); !slices.Equal(actual, []int{1, 2, 3}) {
+		t.Errorf("got %v but expected [1 2 3]", actual)
+	}
+}
+
+func TestRateLimitedQueue_Expiring(t *testing.T) {
+	// The same clock drives the rate limit and the expiry of elements.
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	q, err := queue.NewExpiringWaitableQueue[int](queue.WQCap, queue.WQLow, queue.WQHigh, queue.ExpiryOptions[int]{
+		TTL:   time.Second,
+		Clock: clock,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create queue: %v", err)
+	}
+	rq, err := queue.NewRateLimitedQueue[int](q, queue.RateLimitOptions{Limit: 1, Interval: 2 * time.Second, Clock: clock})
+	if err != nil {
+		t.Fatalf("Failed to create queue: %v", err)
+	}
+	q.Enqueue(1)
+	q.Enqueue(2)
+	if e, _, err := rq.DequeueWait(t.Context()); err != nil || e != 1 {
+		t.Errorf("got %d, %v but expected 1, nil", e, err)
+	}
+
+	// Element 2 expires while waiting for the next token.
+	ch := dequeueAsync(t.Context(), rq)
+	waitForTimer(t, clock)
+	q.Enqueue(3) // Expires at 1s, like element 2
+	clock.Advance(time.Second)
+	q.EnqueueWithDeadline(4, clock.Now().Add(time.Minute))
+	waitForTimer(t, clock)
+	clock.Advance(time.Second)
+	if e := <-ch; e != 4 {
+		t.Errorf("got %d but expected 4", e)
+	}
+	if expired := q.Stats().Expired; expired != 2 {
+		t.Errorf("got %d expired elements but expected 2", expired)
+	}
+}
@@ -0,0 +1,268 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+
+	"github.com/fgm/container"
+)
+
+var (
+	ErrQueueIsAlreadyScheduled = errors.New("container: queue is already scheduled")
+	ErrSchedulerIsClosed       = errors.New("container: scheduler is closed")
+	ErrWeightIsNotPositive     = errors.New("container: weight must be positive")
+)
+
+// SchedulingPolicy selects how a Scheduler shares dequeues between its queues.
+type SchedulingPolicy int
+
+const (
+	// WeightedRoundRobin serves up to weight elements from each queue in turn.
+	WeightedRoundRobin SchedulingPolicy = iota
+	// DeficitRoundRobin credits each queue with its weight on each turn, and
+	// charges it the cost of each element it yields. Credit left unused by an
+	// empty queue is lost, while overdrafts are carried over to the next turn.
+	DeficitRoundRobin
+)
+
+// scheduled is the Scheduler bookkeeping for one of its queues.
+type scheduled[E any] struct {
+	q      container.WaitableQueue[E]
+	weight int
+	credit int       // Remaining credit in the current turn
+	inTurn bool      // Whether credit has been granted for the current turn
+	closed bool      // The queue WaitChan was closed: remove it once drained
+	done   chan unit // Closed to stop forwarding
+}
+
+// Scheduler aggregates multiple WaitableQueues, typically one per tenant,
+// so that a single pool of consumers can fairly dequeue from all of them.
+//
+// It is concurrency-safe. Queues may be added and removed at any time.
+// Closed queues are removed automatically once drained.
+//
+// Once added to a Scheduler, a queue SHOULD NOT be consumed directly,
+// because the Scheduler consumes its WaitChan signals.
+type Scheduler[E any] struct {
+	closed       bool
+	cost         func(E) int
+	current      int // Index in queues of the queue being served
+	invalidCosts int // Number of elements with a cost which was not positive
+	mu           sync.Mutex
+	policy       SchedulingPolicy
+	queues       []*scheduled[E]
+	signal       chan unit
+}
+
+// NewScheduler creates an empty Scheduler using the given policy.
+//
+// The cost function is only used by DeficitRoundRobin, and may be nil,
+// in which case all elements cost 1, making it equivalent to WeightedRoundRobin.
+// Costs SHOULD be positive, since an element with a cost of zero or less would
+// grant credit to its queue instead of charging it: such elements are still
+// returned by Dequeue, but charged a cost of 1, and counted by InvalidCosts.
+func NewScheduler[E any](policy SchedulingPolicy, cost func(E) int) *Scheduler[E] {
+	if cost == nil || policy == WeightedRoundRobin {
+		cost = func(E) int { return 1 }
+	}
+	return &Scheduler[E]{
+		cost:   cost,
+		policy: policy,
+		signal: make(chan unit, 1),
+	}
+}
+
+// Add starts scheduling dequeues from q, with the given weight.
+func (s *Scheduler[E]) Add(q container.WaitableQueue[E], weight int) error {
+	if weight <= 0 {
+		return fmt.Errorf("%w: got %d", ErrWeightIsNotPositive, weight)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrSchedulerIsClosed
+	}
+	if s.indexOf(q) >= 0 {
+		return ErrQueueIsAlreadyScheduled
+	}
+	sq := &scheduled[E]{q: q, weight: weight, done: make(chan unit)}
+	s.queues = append(s.queues, sq)
+	go s.forward(sq)
+	return nil
+}
+
+// Remove stops scheduling dequeues from q, and returns true if it was scheduled.
+//
+// Elements remaining in q are not dequeued, and q is not closed.
+func (s *Scheduler[E]) Remove(q container.WaitableQueue[E]) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.indexOf(q)
+	if i < 0 {
+		return false
+	}
+	s.remove(i)
+	return true
+}
+
+// Len returns the number of queues currently scheduled.
+func (s *Scheduler[E]) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.queues)
+}
+
+// Dequeue removes the next element from the queue selected by the policy,
+// and returns it along with the queue it came from.
+//
+// Like WaitableQueue.Dequeue, it does not block: if all queues are empty,
+// it returns the zero value of the element type, a nil queue, and ok is false.
+func (s *Scheduler[E]) Dequeue() (e E, source container.WaitableQueue[E], ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for {
+		// Each pass visits every queue once, unless one yields an element.
+		var starved []*scheduled[E] // Queues which could not be served for lack of credit
+		for visited := 0; visited < len(s.queues); {
+			sq := s.queues[s.current]
+			if !sq.inTurn {
+				sq.inTurn = true
+				if s.policy == DeficitRoundRobin {
+					sq.credit += sq.weight
+				} else {
+					sq.credit = sq.weight
+				}
+			}
+			if sq.credit <= 0 {
+				starved = append(starved, sq)
+				s.endTurn(sq)
+				visited++
+				continue
+			}
+			if e, ok, _ = sq.q.Dequeue(); ok {
+				cost := s.cost(e)
+				if cost <= 0 {
+					s.invalidCosts++
+					cost = 1
+				}
+				if sq.credit -= cost; sq.credit <= 0 {
+					s.endTurn(sq)
+				}
+				return e, sq.q, true
+			}
+			if sq.closed {
+				s.remove(s.current)
+				continue
+			}
+			sq.credit = 0
+			s.endTurn(sq)
+			visited++
+		}
+		if len(starved) == 0 {
+			return e, nil, false
+		}
+		// Grant at once the credit of the passes in which every starved queue would
+		// still lack credit, instead of looping over them while holding the mutex.
+		// The next pass then serves at least one of them.
+		passes := math.MaxInt
+		for _, sq := range starved {
+			passes = min(passes, (sq.weight-sq.credit)/sq.weight)
+		}
+		for _, sq := range starved {
+			sq.credit += (passes - 1) * sq.weight
+		}
+	}
+}
+
+// InvalidCosts returns the number of dequeued elements for which the cost
+// function did not return a positive cost, and which were charged 1 instead.
+func (s *Scheduler[E]) InvalidCosts() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.invalidCosts
+}
+
+// WaitChan returns a channel signaling when an element might be available in
+// any of the scheduled queues, or when the Scheduler is closed.
+func (s *Scheduler[E]) WaitChan() <-chan container.Unit {
+	return s.signal
+}
+
+// Close stops the Scheduler, closing its WaitChan to unblock waiting consumers.
+//
+// It does not close the scheduled queues, which may still be drained with Dequeue.
+func (s *Scheduler[E]) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	for _, sq := range s.queues {
+		close(sq.done)
+	}
+	close(s.signal)
+}
+
+// endTurn moves to the next queue.
+//
+// It MUST only be called while holding the mutex.
+func (s *Scheduler[E]) endTurn(sq *scheduled[E]) {
+	sq.inTurn = false
+	s.current = (s.current + 1) % len(s.queues)
+}
+
+// indexOf returns the index of q in the scheduled queues, or -1 if it is not scheduled.
+//
+// It MUST only be called while holding the mutex.
+func (s *Scheduler[E]) indexOf(q container.WaitableQueue[E]) int {
+	return slices.IndexFunc(s.queues, func(sq *scheduled[E]) bool { return sq.q == q })
+}
+
+// remove stops forwarding signals from the queue at index i, and forgets it.
+//
+// It MUST only be called while holding the mutex.
+func (s *Scheduler[E]) remove(i int) {
+	if !s.closed {
+		close(s.queues[i].done)
+	}
+	s.queues = slices.Delete(s.queues, i, i+1)
+	switch {
+	case len(s.queues) == 0:
+		s.current = 0
+	case i < s.current:
+		s.current--
+	case s.current >= len(s.queues):
+		s.current = 0
+	}
+}
+
+// forward relays the signals of a scheduled queue to the Scheduler WaitChan.
+func (s *Scheduler[E]) forward(sq *scheduled[E]) {
+	for {
+		select {
+		case <-sq.done:
+			return
+		case _, ok := <-sq.q.WaitChan():
+			s.mu.Lock()
+			if !ok {
+				sq.closed = true
+			}
+			if !s.closed {
+				// Non-blocking send, as in waitable.Enqueue.
+				select {
+				case s.signal <- unit{}:
+				default:
+				}
+			}
+			s.mu.Unlock()
+			if !ok {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package queue_test
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fgm/container"
+	"github.com/fgm/container/queue"
+)
+
+// mustWaitable creates a WaitableQueue preloaded with the given items.
+func mustWaitable[E any](tb testing.TB, items ...E) container.WaitableQueue[E] {
+	tb.Helper()
+	q, err := queue.NewWaitableQueue[E](queue.WQCap, queue.WQLow, queue.WQHigh)
+	if err != nil {
+		tb.Fatalf("Failed to create queue: %v", err)
+	}
+	for _, item := range items {
+		q.Enqueue(item)
+	}
+	return q
+}
+
+// drainScheduler dequeues from s until it reports no available element.
+func drainScheduler(s *queue.Scheduler[string]) string {
+	var b strings.Builder
+	for {
+		e, _, ok := s.Dequeue()
+		if !ok {
+			return b.String()
+		}
+		b.WriteString(e)
+	}
+}
+
+func TestScheduler_Add(t *testing.T) {
+	s := queue.NewScheduler[string](queue.WeightedRoundRobin, nil)
+	q := mustWaitable[string](t)
+	if err := s.Add(q, 0); !errors.Is(err, queue.ErrWeightIsNotPositive) {
+		t.Errorf("got %v but expected %v", err, queue.ErrWeightIsNotPositive)
+	}
+	if err := s.Add(q, 1); err != nil {
+		t.Fatalf("failed adding queue: %v", err)
+	}
+	if err := s.Add(q, 1); !errors.Is(err, queue.ErrQueueIsAlreadyScheduled) {
+		t.Errorf("got %v but expected %v", err, queue.ErrQueueIsAlreadyScheduled)
+	}
+	if s.Len() != 1 {
+		t.Errorf("got %d queues but expected 1", s.Len())
+	}
+	s.Close()
+	s.Close() // Idempotent
+	if err := s.Add(mustWaitable[string](t), 1); !errors.Is(err, queue.ErrSchedulerIsClosed) {
+		t.Errorf("got %v but expected %v", err, queue.ErrSchedulerIsClosed)
+	}
+	if _, ok := <-s.WaitChan(); ok {
+		t.Errorf("WaitChan should be closed")
+	}
+}
+
+func TestScheduler_WeightedRoundRobin(t *testing.T) {
+	s := queue.NewScheduler[string](queue.WeightedRoundRobin, nil)
+	defer s.Close()
+	qa := mustWaitable(t, "a", "a", "a", "a", "a", "a", "a")
+	qb := mustWaitable(t, "b", "b", "b", "b")
+	_ = s.Add(qa, 3)
+	_ = s.Add(qb, 1)
+
+	if actual, expected := drainScheduler(s), "aaabaaababb"; actual != expected {
+		t.Errorf("got %s but expected %s", actual, expected)
+	}
+}
+
+func TestScheduler_DeficitRoundRobin(t *testing.T) {
+	// Element cost is its length: "bbb" costs 3, so b only gets served every third round.
+	s := queue.NewScheduler(queue.DeficitRoundRobin, func(e string) int { return len(e) })
+	defer s.Close()
+	qa := mustWaitable(t, "a", "a", "a", "a", "a", "a")
+	qb := mustWaitable(t, "bbb", "bbb")
+	_ = s.Add(qa, 1)
+	_ = s.Add(qb, 1)
+
+	if actual, expected := drainScheduler(s), "abbbaaabbbaa"; actual != expected {
+		t.Errorf("got %s but expected %s", actual, expected)
+	}
+}
+
+func TestScheduler_DeficitRoundRobinLargeCost(t *testing.T) {
+	// Serving the second element needs 1<<40 passes: they must not be looped over.
+	s := queue.NewScheduler(queue.DeficitRoundRobin, func(e string) int { return 1 << (len(e) * 10) })
+	defer s.Close()
+	qa := mustWaitable(t, "aaaa", "aaaa")
+	qb := mustWaitable(t, "b")
+	_ = s.Add(qa, 1)
+	_ = s.Add(qb, 3)
+
+	done := make(chan string)
+	go func() { done <- drainScheduler(s) }()
+	select {
+	case actual := <-done:
+		if expected := "aaaabaaaa"; actual != expected {
+			t.Errorf("got %s but expected %s", actual, expected)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Dequeue did not return")
+	}
+}
+
+func TestScheduler_DeficitRoundRobinInvalidCost(t *testing.T) {
+	tests := [...]struct {
+		name string
+		cost int
+	}{
+		{"zero", 0},
+		{"negative", -1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Valid costs are 1, so invalid ones charged 1 keep the alternation.
+			s := queue.NewScheduler(queue.DeficitRoundRobin, func(e string) int {
+				if e == "A" {
+					return test.cost
+				}
+				return 1
+			})
+			defer s.Close()
+			qa, qb := mustWaitable(t, "A", "a"), mustWaitable(t, "b", "b")
+			_ = s.Add(qa, 1)
+			_ = s.Add(qb, 1)
+			if actual, expected := drainScheduler(s), "Abab"; actual != expected {
+				t.Errorf("got %s but expected %s", actual, expected)
+			}
+			if n := s.InvalidCosts(); n != 1 {
+				t.Errorf("got %d invalid costs but expected 1", n)
+			}
+			if c := qa.(container.Countable); c.Len() != 0 {
+				t.Errorf("got length %d but expected 0", c.Len())
+			}
+		})
+	}
+}
+
+func TestScheduler_Source(t *testing.T) {
+	s := queue.NewScheduler[string](queue.WeightedRoundRobin, nil)
+	defer s.Close()
+	qa, qb := mustWaitable(t, "a"), mustWaitable(t, "b")
+	_ = s.Add(qa, 1)
+	_ = s.Add(qb, 1)
+	for _, expected := range []container.WaitableQueue[string]{qa, qb} {
+		_, source, ok := s.Dequeue()
+		if !ok || source != expected {
+			t.Errorf("got source %v, ok %t but expected %v", source, ok, expected)
+		}
+	}
+	if _, source, ok := s.Dequeue(); ok || source != nil {
+		t.Errorf("got source %v, ok %t from empty queues", source, ok)
+	}
+}
+
+func TestScheduler_Remove(t *testing.T) {
+	s := queue.NewScheduler[string](queue.WeightedRoundRobin, nil)
+	defer s.Close()
+	qa, qb, qc := mustWaitable(t, "a", "a"), mustWaitable(t, "b", "b"), mustWaitable(t, "c", "c")
+	_ = s.Add(qa, 1)
+	_ = s.Add(qb, 1)
+	_ = s.Add(qc, 1)
+	if e, _, _ := s.Dequeue(); e != "a" {
+		t.Fatalf("got %s but expected a", e)
+	}
+	if !s.Remove(qa) {
+		t.Errorf("failed removing scheduled queue")
+	}
+	if s.Remove(qa) {
+		t.Errorf("removed unscheduled queue")
+	}
+	if actual, expected := drainScheduler(s), "bcbc"; actual != expected {
+		t.Errorf("got %s but expected %s", actual, expected)
+	}
+	if c := qa.(container.Countable); c.Len() != 1 {
+		t.Errorf("removed queue should keep its elements, got %d", c.Len())
+	}
+}
+
+func TestScheduler_ClosedQueue(t *testing.T) {
+	s := queue.NewScheduler[string](queue.WeightedRoundRobin, nil)
+	defer s.Close()
+	qa, qb := mustWaitable(t, "a"), mustWaitable(t, "b")
+	_ = s.Add(qa, 1)
+	_ = s.Add(qb, 1)
+	qa.Close()
+
+	// Wait for the closure to be relayed.
+	deadline := time.After(time.Second)
+	for s.Len() != 1 {
+		select {
+		case <-s.WaitChan():
+			drainScheduler(s)
+		case <-deadline:
+			t.Fatalf("closed queue not removed, %d queues remaining", s.Len())
+		}
+	}
+}
+
+func TestScheduler_WaitChan(t *testing.T) {
+	s := queue.NewScheduler[string](queue.WeightedRoundRobin, nil)
+	defer s.Close()
+	q := mustWaitable[string](t)
+	_ = s.Add(q, 1)
+	go q.Enqueue("a")
+	select {
+	case <-s.WaitChan():
+	case <-time.After(time.Second):
+		t.Fatalf("no signal relayed from scheduled queue")
+	}
+	if e, _, ok := s.Dequeue(); !ok || e != "a" {
+		t.Errorf("got %s, %t but expected a, true", e, ok)
+	}
+}
+
+func TestScheduler_Concurrent(t *testing.T) {
+	const (
+		tenants   = 4
+		perTenant = 100
+		consumers = 3
+	)
+	s := queue.NewScheduler[int](queue.WeightedRoundRobin, nil)
+	qs := make([]container.WaitableQueue[int], tenants)
+	for i := range qs {
+		q, err := queue.NewWaitableQueue[int](perTenant, 0, perTenant)
+		if err != nil {
+			t.Fatalf("Failed to create queue: %v", err)
+		}
+		qs[i] = q
+		_ = s.Add(q, i+1)
+	}
+
+	var (
+		mu       sync.Mutex
+		received = make(map[int]bool)
+		wg       sync.WaitGroup
+	)
+	wg.Add(consumers)
+	for range consumers {
+		go func() {
+			defer wg.Done()
+			for {
+				for {
+					e, _, ok := s.Dequeue()
+					if !ok {
+						break
+					}
+					mu.Lock()
+					received[e] = true
+					mu.Unlock()
+				}
+				if _, ok := <-s.WaitChan(); !ok {
+					return
+				}
+			}
+		}()
+	}
+	for i, q := range qs {
+		go func() {
+			for j := range perTenant {
+				q.Enqueue(i*perTenant + j)
+			}
+			q.Close()
+		}()
+	}
+	deadline := time.After(5 * time.Second)
+	for s.Len() != 0 {
+		select {
+		case <-deadline:
+			t.Fatalf("queues not drained, %d remaining", s.Len())
+		case <-time.After(time.Millisecond):
+		}
+	}
+	s.Close()
+	wg.Wait()
+	if len(received) != tenants*perTenant {
+		t.Errorf("got %d elements but expected %d", len(received), tenants*perTenant)
+	}
+}
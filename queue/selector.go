@@ -0,0 +1,193 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"sync"
+
+	"github.com/fgm/container"
+)
+
+var (
+	ErrNoQueueToSelect          = errors.New("container: no queue to select from")
+	ErrQueueIsAlreadySelected   = errors.New("container: queue is already selected")
+	ErrSelectorQueueCannotBeNil = errors.New("container: selector queue cannot be nil")
+)
+
+// selected is the Selector bookkeeping for one of its queues.
+type selected[E any] struct {
+	q      container.WaitableQueue[E]
+	closed bool // The queue WaitChan was closed: remove it once drained
+}
+
+// Selector waits on a dynamic set of WaitableQueues, saving consumers from
+// building a reflect.Select over their WaitChan.
+//
+// It is concurrency-safe. Queues may be added and removed at any time,
+// including while a Select call is waiting.
+// Closed queues are removed automatically once drained.
+//
+// Once added to a Selector, a queue SHOULD NOT be consumed directly,
+// because the Selector consumes its WaitChan signals.
+type Selector[E any] struct {
+	mu     sync.Mutex
+	next   int // Index in queues of the first queue to try, for fairness
+	queues []*selected[E]
+	signal chan unit // Wakes up waiting Select calls when the set changes
+}
+
+// NewSelector creates a Selector over the given queues.
+func NewSelector[E any](queues ...container.WaitableQueue[E]) (*Selector[E], error) {
+	s := &Selector[E]{signal: make(chan unit, 1)}
+	for _, q := range queues {
+		if err := s.Add(q); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Add includes q in the set of queues to select from.
+func (s *Selector[E]) Add(q container.WaitableQueue[E]) error {
+	if q == nil {
+		return ErrSelectorQueueCannotBeNil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.indexOf(q) >= 0 {
+		return ErrQueueIsAlreadySelected
+	}
+	s.queues = append(s.queues, &selected[E]{q: q})
+	s.wake()
+	return nil
+}
+
+// Remove excludes q from the set of queues to select from, and returns true if it was included.
+//
+// Elements remaining in q are not dequeued, and q is not closed.
+func (s *Selector[E]) Remove(q container.WaitableQueue[E]) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.indexOf(q)
+	if i < 0 {
+		return false
+	}
+	s.remove(i)
+	s.wake()
+	return true
+}
+
+// Len returns the number of queues currently in the set.
+func (s *Selector[E]) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.queues)
+}
+
+// Select blocks until an element can be dequeued from one of the queues,
+// and returns it along with the queue it came from.
+//
+// Queues are tried in turn, so that a busy queue cannot starve the others.
+//
+// It returns ErrNoQueueToSelect once the set is empty, typically because all
+// its queues were closed and drained, and the context error if ctx is done first.
+func (s *Selector[E]) Select(ctx context.Context) (e E, source container.WaitableQueue[E], err error) {
+	for {
+		e, source, cases, waiting, err := s.try(ctx)
+		if source != nil || err != nil {
+			return e, source, err
+		}
+
+		chosen, _, ok := reflect.Select(cases)
+		switch chosen {
+		case 0:
+			return e, nil, ctx.Err()
+		case 1: // The set changed: try again.
+		default:
+			if !ok {
+				s.mu.Lock()
+				waiting[chosen-2].closed = true
+				s.mu.Unlock()
+			}
+		}
+	}
+}
+
+// try attempts a non-blocking dequeue from each queue in turn, removing those
+// which are closed and drained.
+//
+// If none yields an element, it returns the select cases to wait on:
+// ctx, the Selector signal, then the WaitChan of each open queue, listed in waiting.
+func (s *Selector[E]) try(ctx context.Context) (e E, source container.WaitableQueue[E], cases []reflect.SelectCase, waiting []*selected[E], err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for visited := 0; visited < len(s.queues); {
+		i := (s.next + visited) % len(s.queues)
+		sq := s.queues[i]
+		if e, ok, _ := sq.q.Dequeue(); ok {
+			s.next = (i + 1) % len(s.queues)
+			// Another Select call may be waiting on the signal just consumed for
+			// this queue, while it still holds elements: let it try again.
+			s.wake()
+			return e, sq.q, nil, nil, nil
+		}
+		if sq.closed {
+			s.remove(i)
+			continue
+		}
+		visited++
+	}
+	if len(s.queues) == 0 {
+		return e, nil, nil, nil, ErrNoQueueToSelect
+	}
+
+	cases = make([]reflect.SelectCase, 0, 2+len(s.queues))
+	cases = append(cases,
+		reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+		reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(s.signal)},
+	)
+	waiting = make([]*selected[E], 0, len(s.queues))
+	for _, sq := range s.queues {
+		if !sq.closed {
+			cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(sq.q.WaitChan())})
+			waiting = append(waiting, sq)
+		}
+	}
+	return e, nil, cases, waiting, nil
+}
+
+// indexOf returns the index of q in the set, or -1 if it is not included.
+//
+// It MUST only be called while holding the mutex.
+func (s *Selector[E]) indexOf(q container.WaitableQueue[E]) int {
+	return slices.IndexFunc(s.queues, func(sq *selected[E]) bool { return sq.q == q })
+}
+
+// remove forgets the queue at index i.
+//
+// It MUST only be called while holding the mutex.
+func (s *Selector[E]) remove(i int) {
+	s.queues = slices.Delete(s.queues, i, i+1)
+	switch {
+	case len(s.queues) == 0:
+		s.next = 0
+	case i < s.next:
+		s.next--
+	case s.next >= len(s.queues):
+		s.next = 0
+	}
+}
+
+// wake signals waiting Select calls to try again.
+//
+// It MUST only be called while holding the mutex.
+func (s *Selector[E]) wake() {
+	// Non-blocking send, as in waitable.Enqueue.
+	select {
+	case s.signal <- unit{}:
+	default:
+	}
+}
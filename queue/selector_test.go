@@ -0,0 +1,155 @@
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fgm/container"
+	"github.com/fgm/container/queue"
+)
+
+func TestNewSelector(t *testing.T) {
+	q := mustWaitable[string](t)
+	if _, err := queue.NewSelector(q, nil); !errors.Is(err, queue.ErrSelectorQueueCannotBeNil) {
+		t.Errorf("got %v but expected %v", err, queue.ErrSelectorQueueCannotBeNil)
+	}
+	if _, err := queue.NewSelector(q, q); !errors.Is(err, queue.ErrQueueIsAlreadySelected) {
+		t.Errorf("got %v but expected %v", err, queue.ErrQueueIsAlreadySelected)
+	}
+	s, err := queue.NewSelector(q)
+	if err != nil {
+		t.Fatalf("failed creating selector: %v", err)
+	}
+	if s.Len() != 1 {
+		t.Errorf("got %d queues but expected 1", s.Len())
+	}
+	if !s.Remove(q) {
+		t.Errorf("Remove() of a selected queue should return true")
+	}
+	if s.Remove(q) {
+		t.Errorf("Remove() of a removed queue should return false")
+	}
+	if _, _, err := s.Select(t.Context()); !errors.Is(err, queue.ErrNoQueueToSelect) {
+		t.Errorf("got %v but expected %v", err, queue.ErrNoQueueToSelect)
+	}
+}
+
+func TestSelector_Select(t *testing.T) {
+	qa := mustWaitable(t, "a", "a", "a")
+	qb := mustWaitable(t, "b")
+	s, _ := queue.NewSelector(qa, qb)
+
+	var b strings.Builder
+	for range 4 {
+		e, source, err := s.Select(t.Context())
+		if err != nil {
+			t.Fatalf("failed selecting: %v", err)
+		}
+		if (e == "a") != (source == qa) {
+			t.Errorf("got %s from the wrong queue", e)
+		}
+		b.WriteString(e)
+	}
+	// Queues are tried in turn.
+	if actual, expected := b.String(), "abaa"; actual != expected {
+		t.Errorf("got %s but expected %s", actual, expected)
+	}
+}
+
+func TestSelector_Wait(t *testing.T) {
+	qa, qb := mustWaitable[string](t), mustWaitable[string](t)
+	s, _ := queue.NewSelector(qa)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		_ = s.Add(qb) // Added while Select is waiting.
+		time.Sleep(10 * time.Millisecond)
+		qb.Enqueue("b")
+	}()
+	e, source, err := s.Select(t.Context())
+	if err != nil {
+		t.Fatalf("failed selecting: %v", err)
+	}
+	if e != "b" || source != qb {
+		t.Errorf("got %s but expected b from qb", e)
+	}
+}
+
+func TestSelector_Closed(t *testing.T) {
+	qa, qb := mustWaitable(t, "a"), mustWaitable[string](t)
+	s, _ := queue.NewSelector(qa, qb)
+	qa.Close()
+
+	// A closed queue is drained before being removed.
+	if e, _, err := s.Select(t.Context()); err != nil || e != "a" {
+		t.Errorf("got %s, %v but expected a, nil", e, err)
+	}
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		qb.Close()
+	}()
+	if _, _, err := s.Select(t.Context()); !errors.Is(err, queue.ErrNoQueueToSelect) {
+		t.Errorf("got %v but expected %v", err, queue.ErrNoQueueToSelect)
+	}
+	if s.Len() != 0 {
+		t.Errorf("got %d queues but expected 0", s.Len())
+	}
+}
+
+func TestSelector_ContextCancel(t *testing.T) {
+	s, _ := queue.NewSelector(mustWaitable[string](t))
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+	e, source, err := s.Select(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v but expected %v", err, context.DeadlineExceeded)
+	}
+	if e != "" || source != nil {
+		t.Errorf("got %q from %v but expected zero values", e, source)
+	}
+}
+
+func TestSelector_Concurrent(t *testing.T) {
+	const (
+		consumers = 4
+		perQueue  = 250
+	)
+	queues := []container.WaitableQueue[int]{mustWaitable[int](t), mustWaitable[int](t), mustWaitable[int](t)}
+	s, _ := queue.NewSelector(queues...)
+
+	results := make(chan int)
+	for range consumers {
+		go func() {
+			for {
+				e, _, err := s.Select(t.Context())
+				if err != nil {
+					return
+				}
+				results <- e
+			}
+		}()
+	}
+	for i, q := range queues {
+		go func() {
+			for j := range perQueue {
+				q.Enqueue(i*perQueue + j)
+			}
+			q.Close()
+		}()
+	}
+	seen := make(map[int]bool)
+	for range len(queues) * perQueue {
+		select {
+		case e := <-results:
+			seen[e] = true
+		case <-time.After(time.Second):
+			t.Fatalf("timed out after %d elements", len(seen))
+		}
+	}
+	if len(seen) != len(queues)*perQueue {
+		t.Errorf("got %d distinct elements but expected %d", len(seen), len(queues)*perQueue)
+	}
+}
@@ -40,3 +40,7 @@ func BenchmarkSliceQueue_Enqueue_preallocSad(b *testing.B) {
 func TestSliceQueuePop(t *testing.T) {
 	testDequeue(t, queue.NewSliceQueue[int](1), true)
 }
+
+func TestSliceQueueFIFO(t *testing.T) {
+	testFIFO(t, queue.NewSliceQueue[int](4))
+}
@@ -15,6 +15,7 @@ type listSyncPoolQueue[E any] struct {
 func (sq *listSyncPoolQueue[E]) Enqueue(e E) {
 	le := sq.pool.Get().(*types.ListElement[E])
 	le.Value = e
+	le.Next = nil
 	if sq.head == nil {
 		sq.head = le
 	}
@@ -31,15 +32,17 @@ func (sq *listSyncPoolQueue[E]) Dequeue() (E, bool) {
 	}
 	le := sq.head
 	e := le.Value
-	sq.pool.Put(le)
 	// Single element queue : convert to empty.
 	if sq.head.Next == nil {
 		sq.head = nil
 		sq.tail = nil
-		return e, true
+	} else {
+		// Normal queue.
+		sq.head = sq.head.Next
 	}
-	// Normal queue.
-	sq.head = sq.head.Next
+	// Recycle the element only once it is no longer linked.
+	le.Value = *new(E) // Prevent memory leak if E is a pointer type
+	sq.pool.Put(le)
 	return e, true
 }
 
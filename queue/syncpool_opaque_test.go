@@ -57,3 +57,7 @@ func BenchmarkListSyncPoolQueue_Enqueue_prealloc(b *testing.B) {
 func TestListSyncPoolQueuePop(t *testing.T) {
 	testDequeue(t, queue.NewListSyncPoolQueue[int](1), false)
 }
+
+func TestListSyncPoolQueueFIFO(t *testing.T) {
+	testFIFO(t, queue.NewListSyncPoolQueue[int](4))
+}
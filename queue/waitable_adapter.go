@@ -0,0 +1,190 @@
+package queue
+
+import (
+	"errors"
+	"slices"
+	"sync"
+
+	"github.com/fgm/container"
+	types "github.com/fgm/container/internal"
+)
+
+var (
+	ErrBackingStoreIsNil      = errors.New("container: backing store cannot be nil")
+	ErrBackingStoreIsNotEmpty = errors.New("container: backing store must be empty unless it is Countable")
+)
+
+// store is the backing storage of a waitableAdapter.
+type store[E any] interface {
+	put(E)
+	take() (E, bool)
+	// restore puts back elements in the order they were taken, leaving the store as it was before.
+	restore([]E)
+}
+
+// queueStore provides FIFO storage on top of a container.Queue.
+type queueStore[E any] struct {
+	container.Queue[E]
+}
+
+func (qs queueStore[E]) put(e E)         { qs.Enqueue(e) }
+func (qs queueStore[E]) take() (E, bool) { return qs.Dequeue() }
+
+func (qs queueStore[E]) restore(taken []E) {
+	for _, e := range taken {
+		qs.Enqueue(e)
+	}
+}
+
+// stackStore provides LIFO storage on top of a container.Stack.
+type stackStore[E any] struct {
+	container.Stack[E]
+}
+
+func (ss stackStore[E]) put(e E)         { ss.Push(e) }
+func (ss stackStore[E]) take() (E, bool) { return ss.Pop() }
+
+func (ss stackStore[E]) restore(taken []E) {
+	for _, e := range slices.Backward(taken) {
+		ss.Push(e)
+	}
+}
+
+// waitableAdapter implements WaitableQueue on top of any store.
+//
+// It has the same locking, signaling, watermark and closing logic as waitable.
+type waitableAdapter[E any] struct {
+	types.Watermarks
+	closed    bool
+	countable container.Countable // The backing store, if it is Countable
+	len       int                 // Maintained here, since not all backing stores are Countable
+	mu        sync.Mutex
+	signal    chan unit // Used to signal availability or closure
+	store     store[E]
+}
+
+// NewWaitableFromQueue creates a new WaitableQueue storing its elements in the
+// backing container.Queue, making the storage a pluggable choice.
+//
+// The backing queue MUST NOT be used directly afterwards, except to release
+// its resources once the WaitableQueue is closed and no longer used,
+// like calling FileQueue.Close.
+// If it is Countable, elements already in it are available to Dequeue.
+// Otherwise, it MUST be empty, since the adapter could not count its elements:
+// a non-empty backing queue is left unchanged and returns ErrBackingStoreIsNotEmpty.
+//
+// The initial capacity is only used to compute the saturation threshold,
+// as in NewWaitableQueue: use the backing queue size hint to preallocate storage.
+func NewWaitableFromQueue[E any](backing container.Queue[E], initialCapacity int, lowWatermark, highWatermark int) (container.WaitableQueue[E], error) {
+	if backing == nil {
+		return nil, ErrBackingStoreIsNil
+	}
+	return newWaitableAdapter[E](queueStore[E]{backing}, backing, initialCapacity, lowWatermark, highWatermark)
+}
+
+// NewWaitableFromStack is like NewWaitableFromQueue, but with a backing container.Stack.
+//
+// The resulting WaitableQueue is LIFO: Dequeue returns the most recently enqueued element.
+func NewWaitableFromStack[E any](backing container.Stack[E], initialCapacity int, lowWatermark, highWatermark int) (container.WaitableQueue[E], error) {
+	if backing == nil {
+		return nil, ErrBackingStoreIsNil
+	}
+	return newWaitableAdapter[E](stackStore[E]{backing}, backing, initialCapacity, lowWatermark, highWatermark)
+}
+
+func newWaitableAdapter[E any](s store[E], backing any, initialCapacity int, lowWatermark, highWatermark int) (*waitableAdapter[E], error) {
+	wm, err := types.NewWatermarks(initialCapacity, lowWatermark, highWatermark)
+	if err != nil {
+		return nil, err
+	}
+	wa := &waitableAdapter[E]{
+		Watermarks: wm,
+		signal:     make(chan unit, 1),
+		store:      s,
+	}
+	if c, ok := backing.(container.Countable); ok {
+		wa.countable, wa.len = c, c.Len()
+	} else if e, ok := s.take(); ok {
+		taken := []E{e}
+		for e, ok = s.take(); ok; e, ok = s.take() {
+			taken = append(taken, e)
+		}
+		s.restore(taken)
+		return nil, ErrBackingStoreIsNotEmpty
+	}
+	if wa.len > 0 {
+		wa.signal <- unit{}
+	}
+	return wa, nil
+}
+
+// Enqueue adds an item to the backing store and signals if necessary.
+func (wa *waitableAdapter[E]) Enqueue(item E) container.WaitableQueueState {
+	wa.mu.Lock()
+	defer wa.mu.Unlock()
+
+	if wa.closed {
+		panic("enqueue on closed queue")
+	}
+
+	wa.store.put(item)
+	wa.recount(1)
+
+	// Non-blocking send, as in waitable.Enqueue.
+	select {
+	case wa.signal <- unit{}:
+	default:
+	}
+	return wa.State(wa.len)
+}
+
+// Dequeue removes and returns an item from the backing store if available.
+func (wa *waitableAdapter[E]) Dequeue() (E, bool, container.WaitableQueueState) {
+	wa.mu.Lock()
+	defer wa.mu.Unlock()
+
+	item, ok := wa.store.take()
+	if !ok {
+		return item, false, container.QueueIsBelowLowWatermark
+	}
+	wa.recount(-1)
+	return item, true, wa.State(wa.len)
+}
+
+// recount updates the number of items after a put or take.
+//
+// Countable backing stores are trusted instead, since their operations may fail
+// without reporting it, like FileQueue.Enqueue with an element failing to encode.
+//
+// It MUST only be called while holding the mutex.
+func (wa *waitableAdapter[E]) recount(delta int) {
+	if wa.countable != nil {
+		wa.len = wa.countable.Len()
+		return
+	}
+	wa.len += delta
+}
+
+// Len returns the number of items in the queue.
+//
+// It MUST NOT be called while holding the mutex to avoid deadlocks.
+func (wa *waitableAdapter[E]) Len() int {
+	wa.mu.Lock()
+	defer wa.mu.Unlock()
+	return wa.len
+}
+
+// WaitChan returns the signal channel.
+func (wa *waitableAdapter[E]) WaitChan() <-chan container.Unit {
+	return wa.signal
+}
+
+// Close marks the queue as closed and closes the signal channel.
+func (wa *waitableAdapter[E]) Close() {
+	wa.mu.Lock()
+	defer wa.mu.Unlock()
+	if !wa.closed {
+		wa.closed = true
+		close(wa.signal)
+	}
+}
@@ -0,0 +1,208 @@
+package queue_test
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/fgm/container"
+	"github.com/fgm/container/queue"
+	"github.com/fgm/container/stack"
+)
+
+func TestNewWaitableFrom(t *testing.T) {
+	if _, err := queue.NewWaitableFromQueue[int](nil, queue.WQCap, queue.WQLow, queue.WQHigh); !errors.Is(err, queue.ErrBackingStoreIsNil) {
+		t.Errorf("got %v but expected %v", err, queue.ErrBackingStoreIsNil)
+	}
+	if _, err := queue.NewWaitableFromStack[int](nil, queue.WQCap, queue.WQLow, queue.WQHigh); !errors.Is(err, queue.ErrBackingStoreIsNil) {
+		t.Errorf("got %v but expected %v", err, queue.ErrBackingStoreIsNil)
+	}
+	if _, err := queue.NewWaitableFromQueue(queue.NewSliceQueue[int](0), queue.WQCap, queue.WQHigh, queue.WQLow); !errors.Is(err, queue.ErrHighWatermarkIsLessThanLowWatermark) {
+		t.Errorf("got %v but expected %v", err, queue.ErrHighWatermarkIsLessThanLowWatermark)
+	}
+}
+
+func TestWaitableAdapter_Order(t *testing.T) {
+	// The size hint is smaller than the number of elements, so pooled backings recycle them.
+	const sizeHint = 1
+	fifo, lifo := []int{1, 2, 3, 4, 5}, []int{3, 5, 4, 2, 1}
+	tests := [...]struct {
+		name     string
+		ctor     func() (container.WaitableQueue[int], error)
+		expected []int
+	}{
+		{"slice queue", func() (container.WaitableQueue[int], error) {
+			return queue.NewWaitableFromQueue(queue.NewSliceQueue[int](sizeHint), queue.WQCap, queue.WQLow, queue.WQHigh)
+		}, fifo},
+		{"list queue", func() (container.WaitableQueue[int], error) {
+			return queue.NewWaitableFromQueue(queue.NewListQueue[int](sizeHint), queue.WQCap, queue.WQLow, queue.WQHigh)
+		}, fifo},
+		{"list sync.Pool queue", func() (container.WaitableQueue[int], error) {
+			return queue.NewWaitableFromQueue(queue.NewListSyncPoolQueue[int](sizeHint), queue.WQCap, queue.WQLow, queue.WQHigh)
+		}, fifo},
+		{"list internal pool queue", func() (container.WaitableQueue[int], error) {
+			return queue.NewWaitableFromQueue(queue.NewListInternalPoolQueue[int](sizeHint), queue.WQCap, queue.WQLow, queue.WQHigh)
+		}, fifo},
+		{"slice stack", func() (container.WaitableQueue[int], error) {
+			return queue.NewWaitableFromStack(stack.NewSliceStack[int](sizeHint), queue.WQCap, queue.WQLow, queue.WQHigh)
+		}, lifo},
+		{"list stack", func() (container.WaitableQueue[int], error) {
+			return queue.NewWaitableFromStack(stack.NewListStack[int](sizeHint), queue.WQCap, queue.WQLow, queue.WQHigh)
+		}, lifo},
+		{"list sync.Pool stack", func() (container.WaitableQueue[int], error) {
+			return queue.NewWaitableFromStack(stack.NewListSyncPoolStack[int](sizeHint), queue.WQCap, queue.WQLow, queue.WQHigh)
+		}, lifo},
+		{"list internal pool stack", func() (container.WaitableQueue[int], error) {
+			return queue.NewWaitableFromStack(stack.NewListInternalPoolStack[int](sizeHint), queue.WQCap, queue.WQLow, queue.WQHigh)
+		}, lifo},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := test.ctor()
+			if err != nil {
+				t.Fatalf("Failed to create queue: %v", err)
+			}
+			for _, e := range []int{1, 2, 3} {
+				q.Enqueue(e)
+			}
+			if c := q.(container.Countable); c.Len() != 3 {
+				t.Errorf("got length %d but expected 3", c.Len())
+			}
+			// Dequeue before enqueuing more, so the dequeued element may be recycled.
+			first, _, _ := q.Dequeue()
+			q.Enqueue(4)
+			q.Enqueue(5)
+			q.Close()
+			actual := append([]int{first}, slices.Collect(queue.All(t.Context(), q))...)
+			if !slices.Equal(actual, test.expected) {
+				t.Errorf("got %v but expected %v", actual, test.expected)
+			}
+			if _, ok, wqs := q.Dequeue(); ok || wqs != container.QueueIsBelowLowWatermark {
+				t.Errorf("dequeue from empty queue: got ok %t, state %s", ok, wqs)
+			}
+		})
+	}
+}
+
+func TestWaitableAdapter_Preloaded(t *testing.T) {
+	backing := queue.NewSliceQueue[int](queue.WQCap)
+	backing.Enqueue(1)
+	backing.Enqueue(2)
+	q, err := queue.NewWaitableFromQueue(backing, queue.WQCap, queue.WQLow, queue.WQHigh)
+	if err != nil {
+		t.Fatalf("Failed to create queue: %v", err)
+	}
+	if c := q.(container.Countable); c.Len() != 2 {
+		t.Errorf("got length %d but expected 2", c.Len())
+	}
+	select {
+	case <-q.WaitChan():
+	default:
+		t.Errorf("preloaded queue should signal availability")
+	}
+}
+
+func TestWaitableAdapter_PreloadedNotCountable(t *testing.T) {
+	// List queues and stacks are not Countable.
+	lq, ls := queue.NewListQueue[int](0), stack.NewListStack[int](0)
+	for _, e := range []int{1, 2, 3} {
+		lq.Enqueue(e)
+		ls.Push(e)
+	}
+	if _, err := queue.NewWaitableFromQueue(lq, queue.WQCap, queue.WQLow, queue.WQHigh); !errors.Is(err, queue.ErrBackingStoreIsNotEmpty) {
+		t.Errorf("got %v but expected %v", err, queue.ErrBackingStoreIsNotEmpty)
+	}
+	if _, err := queue.NewWaitableFromStack(ls, queue.WQCap, queue.WQLow, queue.WQHigh); !errors.Is(err, queue.ErrBackingStoreIsNotEmpty) {
+		t.Errorf("got %v but expected %v", err, queue.ErrBackingStoreIsNotEmpty)
+	}
+
+	// Rejected backing stores are left unchanged.
+	var fromQueue, fromStack []int
+	for e, ok := lq.Dequeue(); ok; e, ok = lq.Dequeue() {
+		fromQueue = append(fromQueue, e)
+	}
+	for e, ok := ls.Pop(); ok; e, ok = ls.Pop() {
+		fromStack = append(fromStack, e)
+	}
+	if !slices.Equal(fromQueue, []int{1, 2, 3}) || !slices.Equal(fromStack, []int{3, 2, 1}) {
+		t.Errorf("got %v and %v but expected [1 2 3] and [3 2 1]", fromQueue, fromStack)
+	}
+	q, err := queue.NewWaitableFromQueue(lq, queue.WQCap, queue.WQLow, queue.WQHigh)
+	if err != nil {
+		t.Fatalf("Failed to create queue from empty backing store: %v", err)
+	}
+	if c := q.(container.Countable); c.Len() != 0 {
+		t.Errorf("got length %d but expected 0", c.Len())
+	}
+}
+
+func TestWaitableAdapter_States(t *testing.T) {
+	q, err := queue.NewWaitableFromQueue(queue.NewListQueue[int](0), queue.WQCap, queue.WQLow, queue.WQHigh)
+	if err != nil {
+		t.Fatalf("Failed to create queue: %v", err)
+	}
+	var wqs container.WaitableQueueState
+	for i := range queue.WQCap {
+		wqs = q.Enqueue(i)
+	}
+	if wqs != container.QueueIsNearSaturation {
+		t.Errorf("got %s but expected %s", wqs, container.QueueIsNearSaturation)
+	}
+	for range queue.WQCap - queue.WQLow {
+		_, _, wqs = q.Dequeue()
+	}
+	if wqs != container.QueueIsBelowLowWatermark {
+		t.Errorf("got %s but expected %s", wqs, container.QueueIsBelowLowWatermark)
+	}
+}
+
+func TestWaitableAdapter_Close(t *testing.T) {
+	q, err := queue.NewWaitableFromStack(stack.NewSliceStack[int](0), queue.WQCap, queue.WQLow, queue.WQHigh)
+	if err != nil {
+		t.Fatalf("Failed to create queue: %v", err)
+	}
+	q.Close()
+	q.Close() // Idempotent
+	if _, ok := <-q.WaitChan(); ok {
+		t.Errorf("WaitChan should be closed")
+	}
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Enqueue() expected panic but didn't get one")
+		}
+	}()
+	q.Enqueue(1)
+}
+
+func TestWaitableAdapter_Concurrent(t *testing.T) {
+	const (
+		producers = 4
+		perProd   = 250
+	)
+	q, err := queue.NewWaitableFromQueue(queue.NewListInternalPoolQueue[int](producers*perProd), producers*perProd, 0, producers*perProd)
+	if err != nil {
+		t.Fatalf("Failed to create queue: %v", err)
+	}
+	var wg sync.WaitGroup
+	wg.Add(producers)
+	for p := range producers {
+		go func() {
+			defer wg.Done()
+			for i := range perProd {
+				q.Enqueue(p*perProd + i)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		q.Close()
+	}()
+	seen := make(map[int]bool)
+	for e := range queue.All(t.Context(), q) {
+		seen[e] = true
+	}
+	if len(seen) != producers*perProd {
+		t.Errorf("got %d elements but expected %d", len(seen), producers*perProd)
+	}
+}
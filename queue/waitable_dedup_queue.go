@@ -0,0 +1,147 @@
+package queue
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/fgm/container"
+	types "github.com/fgm/container/internal"
+)
+
+var (
+	ErrKeyIsNil               = errors.New("container: key function cannot be nil")
+	ErrUnknownDuplicatePolicy = errors.New("container: unknown duplicate policy")
+)
+
+// DuplicatePolicy defines what Enqueue does when an element with the same key is already pending.
+type DuplicatePolicy int
+
+const (
+	// KeepOriginal drops the new element, keeping the pending one at its position.
+	KeepOriginal DuplicatePolicy = iota
+	// MoveToBack drops the pending element, and adds the new one at the back of the queue.
+	MoveToBack
+	// ReplaceValue replaces the pending element by the new one, at the same position.
+	ReplaceValue
+)
+
+// keyed wraps an element with its key, to find it in the index when it is dequeued.
+type keyed[E any, K comparable] struct {
+	key   K
+	value E
+}
+
+// waitableDedup implements WaitableQueue, coalescing elements with the same key.
+type waitableDedup[E any, K comparable] struct {
+	types.Watermarks
+	closed bool
+	index  map[K]*list.Element // Pending elements by key
+	items  *list.List          // Of keyed[E, K]
+	key    func(E) K
+	mu     sync.Mutex
+	policy DuplicatePolicy
+	signal chan unit // Used to signal availability or closure
+}
+
+// NewWaitableDedupQueue creates a new WaitableQueue in which at most one element
+// per key is pending at any time, the key of an element being returned by the key function.
+//
+// When an element is enqueued while another one with the same key is pending,
+// the policy defines which one remains, and at which position.
+// Once an element is dequeued, a new one with the same key may be enqueued again.
+//
+// The initial capacity and watermarks are used like in NewWaitableQueue,
+// counting pending elements after deduplication.
+func NewWaitableDedupQueue[E any, K comparable](initialCapacity int, lowWatermark, highWatermark int, key func(E) K, policy DuplicatePolicy) (container.WaitableQueue[E], error) {
+	wm, err := types.NewWatermarks(initialCapacity, lowWatermark, highWatermark)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, ErrKeyIsNil
+	}
+	if policy < KeepOriginal || policy > ReplaceValue {
+		return nil, fmt.Errorf("%w: got %d", ErrUnknownDuplicatePolicy, policy)
+	}
+	return &waitableDedup[E, K]{
+		Watermarks: wm,
+		index:      make(map[K]*list.Element, initialCapacity),
+		items:      list.New(),
+		key:        key,
+		policy:     policy,
+		signal:     make(chan unit, 1),
+	}, nil
+}
+
+// Enqueue adds an item, or coalesces it with a pending one with the same key,
+// and signals if necessary.
+func (wd *waitableDedup[E, K]) Enqueue(item E) container.WaitableQueueState {
+	wd.mu.Lock()
+	defer wd.mu.Unlock()
+
+	if wd.closed {
+		panic("enqueue on closed queue")
+	}
+
+	k := wd.key(item)
+	if le, ok := wd.index[k]; ok {
+		// The pending element was already signaled.
+		switch wd.policy {
+		case MoveToBack:
+			le.Value = keyed[E, K]{key: k, value: item}
+			wd.items.MoveToBack(le)
+		case ReplaceValue:
+			le.Value = keyed[E, K]{key: k, value: item}
+		}
+		return wd.State(wd.items.Len())
+	}
+	wd.index[k] = wd.items.PushBack(keyed[E, K]{key: k, value: item})
+
+	// Non-blocking send, as in waitable.Enqueue.
+	select {
+	case wd.signal <- unit{}:
+	default:
+	}
+	return wd.State(wd.items.Len())
+}
+
+// Dequeue removes and returns the first item if available.
+func (wd *waitableDedup[E, K]) Dequeue() (E, bool, container.WaitableQueueState) {
+	wd.mu.Lock()
+	defer wd.mu.Unlock()
+
+	front := wd.items.Front()
+	if front == nil {
+		var zero E
+		return zero, false, container.QueueIsBelowLowWatermark
+	}
+	kv := wd.items.Remove(front).(keyed[E, K])
+	delete(wd.index, kv.key)
+	return kv.value, true, wd.State(wd.items.Len())
+}
+
+// Len returns the number of pending items in the queue.
+//
+// It MUST NOT be called while holding the mutex to avoid deadlocks.
+func (wd *waitableDedup[E, K]) Len() int {
+	wd.mu.Lock()
+	defer wd.mu.Unlock()
+	return wd.items.Len()
+}
+
+// WaitChan returns the signal channel.
+func (wd *waitableDedup[E, K]) WaitChan() <-chan container.Unit {
+	return wd.signal
+}
+
+// Close marks the queue as closed and closes the signal channel.
+func (wd *waitableDedup[E, K]) Close() {
+	wd.mu.Lock()
+	defer wd.mu.Unlock()
+	if !wd.closed {
+		wd.closed = true
+		close(wd.signal)
+	}
+}
@@ -0,0 +1,94 @@
+package queue_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/fgm/container"
+	"github.com/fgm/container/queue"
+)
+
+// indexJob is an indexing job for a document.
+type indexJob struct {
+	doc     string
+	version int
+}
+
+func indexJobKey(j indexJob) string { return j.doc }
+
+func TestNewWaitableDedupQueue(t *testing.T) {
+	if _, err := queue.NewWaitableDedupQueue[indexJob, string](queue.WQCap, queue.WQLow, queue.WQHigh, nil, queue.KeepOriginal); !errors.Is(err, queue.ErrKeyIsNil) {
+		t.Errorf("got %v but expected %v", err, queue.ErrKeyIsNil)
+	}
+	if _, err := queue.NewWaitableDedupQueue(queue.WQCap, queue.WQLow, queue.WQHigh, indexJobKey, queue.ReplaceValue+1); !errors.Is(err, queue.ErrUnknownDuplicatePolicy) {
+		t.Errorf("got %v but expected %v", err, queue.ErrUnknownDuplicatePolicy)
+	}
+	if _, err := queue.NewWaitableDedupQueue(queue.WQCap, queue.WQHigh, queue.WQLow, indexJobKey, queue.KeepOriginal); !errors.Is(err, queue.ErrHighWatermarkIsLessThanLowWatermark) {
+		t.Errorf("got %v but expected %v", err, queue.ErrHighWatermarkIsLessThanLowWatermark)
+	}
+}
+
+func TestWaitableDedupQueue_Policies(t *testing.T) {
+	tests := [...]struct {
+		name     string
+		policy   queue.DuplicatePolicy
+		expected []indexJob
+	}{
+		{"keep original", queue.KeepOriginal, []indexJob{{"a", 1}, {"b", 1}, {"c", 1}}},
+		{"move to back", queue.MoveToBack, []indexJob{{"b", 1}, {"c", 1}, {"a", 3}}},
+		{"replace value", queue.ReplaceValue, []indexJob{{"a", 3}, {"b", 1}, {"c", 1}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := queue.NewWaitableDedupQueue(queue.WQCap, queue.WQLow, queue.WQHigh, indexJobKey, test.policy)
+			if err != nil {
+				t.Fatalf("Failed to create queue: %v", err)
+			}
+			for _, j := range []indexJob{{"a", 1}, {"b", 1}, {"a", 2}, {"c", 1}, {"a", 3}} {
+				q.Enqueue(j)
+			}
+			if c := q.(container.Countable); c.Len() != 3 {
+				t.Errorf("got length %d but expected 3", c.Len())
+			}
+			q.Close()
+			if actual := slices.Collect(queue.All(t.Context(), q)); !slices.Equal(actual, test.expected) {
+				t.Errorf("got %v but expected %v", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestWaitableDedupQueue_Requeue(t *testing.T) {
+	q, _ := queue.NewWaitableDedupQueue(queue.WQCap, queue.WQLow, queue.WQHigh, indexJobKey, queue.KeepOriginal)
+	q.Enqueue(indexJob{"a", 1})
+	if j, ok, _ := q.Dequeue(); !ok || j != (indexJob{"a", 1}) {
+		t.Errorf("got %v, %t but expected {a 1}, true", j, ok)
+	}
+	// Once dequeued, the key is no longer pending.
+	q.Enqueue(indexJob{"a", 2})
+	if j, ok, _ := q.Dequeue(); !ok || j != (indexJob{"a", 2}) {
+		t.Errorf("got %v, %t but expected {a 2}, true", j, ok)
+	}
+	if _, ok, wqs := q.Dequeue(); ok || wqs != container.QueueIsBelowLowWatermark {
+		t.Errorf("dequeue from empty queue: got ok %t, state %s", ok, wqs)
+	}
+}
+
+func TestWaitableDedupQueue_States(t *testing.T) {
+	q, _ := queue.NewWaitableDedupQueue(queue.WQCap, queue.WQLow, queue.WQHigh, func(i int) int { return i }, queue.MoveToBack)
+	var wqs container.WaitableQueueState
+	for i := range queue.WQCap {
+		wqs = q.Enqueue(i)
+	}
+	if wqs != container.QueueIsNearSaturation {
+		t.Errorf("got %s but expected %s", wqs, container.QueueIsNearSaturation)
+	}
+	// Duplicates do not count.
+	if wqs = q.Enqueue(0); wqs != container.QueueIsNearSaturation {
+		t.Errorf("got %s but expected %s", wqs, container.QueueIsNearSaturation)
+	}
+	if c := q.(container.Countable); c.Len() != queue.WQCap {
+		t.Errorf("got length %d but expected %d", c.Len(), queue.WQCap)
+	}
+}
@@ -0,0 +1,178 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/fgm/container"
+	types "github.com/fgm/container/internal"
+)
+
+var ErrTTLIsNegative = errors.New("container: TTL cannot be negative")
+
+// ExpiryOptions configures an ExpiringWaitableQueue.
+type ExpiryOptions[E any] struct {
+	// TTL is the time to live of elements added with Enqueue.
+	// Zero means they never expire.
+	TTL time.Duration
+	// OnExpire is called for each expired element dropped by Dequeue, outside the queue lock.
+	// It may be nil.
+	OnExpire func(E)
+	// Clock is used to check expiry. It defaults to the system clock.
+	Clock Clock
+}
+
+// ExpiryStats are the counters of an ExpiringWaitableQueue since its creation.
+type ExpiryStats struct {
+	Enqueued uint64 // Elements added to the queue
+	Dequeued uint64 // Elements returned by Dequeue
+	Expired  uint64 // Elements dropped by Dequeue because their deadline had passed
+}
+
+// expiring wraps an element with its deadline.
+type expiring[E any] struct {
+	value    E
+	deadline time.Time // Zero means no deadline
+}
+
+// ExpiringWaitableQueue is a WaitableQueue in which each element may have a deadline,
+// after which Dequeue drops it instead of returning it.
+//
+// Expired elements are only dropped when they reach the head of the queue,
+// so they are still included in Len and watermark states until then.
+type ExpiringWaitableQueue[E any] struct {
+	types.Watermarks
+	closed   bool
+	items    []expiring[E]
+	clock    Clock
+	mu       sync.Mutex
+	onExpire func(E)
+	signal   chan unit // Used to signal availability or closure
+	stats    ExpiryStats
+	ttl      time.Duration
+}
+
+// NewExpiringWaitableQueue creates a new ExpiringWaitableQueue with the given
+// initial capacity and watermarks, used like in NewWaitableQueue.
+func NewExpiringWaitableQueue[E any](initialCapacity int, lowWatermark, highWatermark int, opts ExpiryOptions[E]) (*ExpiringWaitableQueue[E], error) {
+	wm, err := types.NewWatermarks(initialCapacity, lowWatermark, highWatermark)
+	if err != nil {
+		return nil, err
+	}
+	if opts.TTL < 0 {
+		return nil, fmt.Errorf("%w: got %v", ErrTTLIsNegative, opts.TTL)
+	}
+	if opts.Clock == nil {
+		opts.Clock = systemClock{}
+	}
+	return &ExpiringWaitableQueue[E]{
+		Watermarks: wm,
+		items:      make([]expiring[E], 0, initialCapacity),
+		clock:      opts.Clock,
+		onExpire:   opts.OnExpire,
+		signal:     make(chan unit, 1),
+		ttl:        opts.TTL,
+	}, nil
+}
+
+// Enqueue adds an item expiring after the queue TTL, if any, and signals if necessary.
+func (eq *ExpiringWaitableQueue[E]) Enqueue(item E) container.WaitableQueueState {
+	var deadline time.Time
+	if eq.ttl > 0 {
+		deadline = eq.clock.Now().Add(eq.ttl)
+	}
+	return eq.EnqueueWithDeadline(item, deadline)
+}
+
+// EnqueueWithDeadline adds an item expiring at the given deadline, and signals if necessary.
+//
+// A zero deadline means the item never expires, regardless of the queue TTL.
+func (eq *ExpiringWaitableQueue[E]) EnqueueWithDeadline(item E, deadline time.Time) container.WaitableQueueState {
+	eq.mu.Lock()
+	defer eq.mu.Unlock()
+
+	if eq.closed {
+		panic("enqueue on closed queue")
+	}
+
+	eq.items = append(eq.items, expiring[E]{value: item, deadline: deadline})
+	eq.stats.Enqueued++
+
+	// Non-blocking send, as in waitable.Enqueue.
+	select {
+	case eq.signal <- unit{}:
+	default:
+	}
+	return eq.State(len(eq.items))
+}
+
+// Dequeue removes and returns the first unexpired item if available,
+// dropping the expired items before it.
+func (eq *ExpiringWaitableQueue[E]) Dequeue() (E, bool, container.WaitableQueueState) {
+	item, ok, wqs, expired := eq.dequeue()
+	if eq.onExpire != nil {
+		for _, e := range expired {
+			eq.onExpire(e)
+		}
+	}
+	return item, ok, wqs
+}
+
+// dequeue implements Dequeue, also returning the expired items it dropped.
+func (eq *ExpiringWaitableQueue[E]) dequeue() (item E, ok bool, wqs container.WaitableQueueState, expired []E) {
+	eq.mu.Lock()
+	defer eq.mu.Unlock()
+
+	now := eq.clock.Now()
+	for len(eq.items) > 0 {
+		head := eq.items[0]
+		eq.items[0] = expiring[E]{} // Prevent memory leak if E is a pointer type
+		eq.items = eq.items[1:]
+
+		if !head.deadline.IsZero() && !now.Before(head.deadline) {
+			eq.stats.Expired++
+			if eq.onExpire != nil {
+				expired = append(expired, head.value)
+			}
+			continue
+		}
+		eq.stats.Dequeued++
+		return head.value, true, eq.State(len(eq.items)), expired
+	}
+	return item, false, container.QueueIsBelowLowWatermark, expired
+}
+
+// Len returns the number of items in the queue, including expired ones not yet dropped.
+//
+// It MUST NOT be called while holding the mutex to avoid deadlocks.
+func (eq *ExpiringWaitableQueue[E]) Len() int {
+	eq.mu.Lock()
+	defer eq.mu.Unlock()
+	return len(eq.items)
+}
+
+// Stats returns a snapshot of the queue counters.
+//
+// Like Len, it is not atomic vs other operations.
+func (eq *ExpiringWaitableQueue[E]) Stats() ExpiryStats {
+	eq.mu.Lock()
+	defer eq.mu.Unlock()
+	return eq.stats
+}
+
+// WaitChan returns the signal channel.
+func (eq *ExpiringWaitableQueue[E]) WaitChan() <-chan container.Unit {
+	return eq.signal
+}
+
+// Close marks the queue as closed and closes the signal channel.
+func (eq *ExpiringWaitableQueue[E]) Close() {
+	eq.mu.Lock()
+	defer eq.mu.Unlock()
+	if !eq.closed {
+		eq.closed = true
+		close(eq.signal)
+	}
+}
@@ -0,0 +1,104 @@
+package queue_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/fgm/container"
+	"github.com/fgm/container/queue"
+)
+
+func TestNewExpiringWaitableQueue(t *testing.T) {
+	if _, err := queue.NewExpiringWaitableQueue(queue.WQCap, queue.WQLow, queue.WQHigh, queue.ExpiryOptions[int]{TTL: -1}); !errors.Is(err, queue.ErrTTLIsNegative) {
+		t.Errorf("got %v but expected %v", err, queue.ErrTTLIsNegative)
+	}
+	if _, err := queue.NewExpiringWaitableQueue(queue.WQCap, queue.WQHigh, queue.WQLow, queue.ExpiryOptions[int]{}); !errors.Is(err, queue.ErrHighWatermarkIsLessThanLowWatermark) {
+		t.Errorf("got %v but expected %v", err, queue.ErrHighWatermarkIsLessThanLowWatermark)
+	}
+	var q container.WaitableQueue[int]
+	q, err := queue.NewExpiringWaitableQueue(queue.WQCap, queue.WQLow, queue.WQHigh, queue.ExpiryOptions[int]{})
+	if err != nil {
+		t.Fatalf("Failed to create queue: %v", err)
+	}
+	// Without a TTL, elements never expire.
+	q.Enqueue(queue.WQInput)
+	if item, ok, _ := q.Dequeue(); !ok || item != queue.WQInput {
+		t.Errorf("got %d, %t but expected %d, true", item, ok, queue.WQInput)
+	}
+}
+
+func TestExpiringWaitableQueue_Expiry(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	var expired []int
+	q, err := queue.NewExpiringWaitableQueue(queue.WQCap, queue.WQLow, queue.WQHigh, queue.ExpiryOptions[int]{
+		TTL:      time.Second,
+		OnExpire: func(e int) { expired = append(expired, e) },
+		Clock:    clock,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create queue: %v", err)
+	}
+	q.Enqueue(1)                                           // Expires at 1s
+	q.EnqueueWithDeadline(2, clock.Now().Add(time.Minute)) // Expires at 1m
+	q.Enqueue(3)                                           // Expires at 1s
+	q.EnqueueWithDeadline(4, time.Time{})                  // Never expires
+	clock.Advance(time.Second)
+	q.Enqueue(5) // Expires at 2s
+
+	if q.Len() != 5 {
+		t.Errorf("got length %d but expected 5 before dropping expired items", q.Len())
+	}
+	var actual []int
+	for item, ok, _ := q.Dequeue(); ok; item, ok, _ = q.Dequeue() {
+		actual = append(actual, item)
+	}
+	if expected := []int{2, 4, 5}; !slices.Equal(actual, expected) {
+		t.Errorf("got %v but expected %v", actual, expected)
+	}
+	if expected := []int{1, 3}; !slices.Equal(expired, expected) {
+		t.Errorf("got expired %v but expected %v", expired, expected)
+	}
+	if stats, expected := q.Stats(), (queue.ExpiryStats{Enqueued: 5, Dequeued: 3, Expired: 2}); stats != expected {
+		t.Errorf("got %+v but expected %+v", stats, expected)
+	}
+}
+
+func TestExpiringWaitableQueue_AllExpired(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	q, _ := queue.NewExpiringWaitableQueue(queue.WQCap, queue.WQLow, queue.WQHigh, queue.ExpiryOptions[int]{
+		TTL:   time.Millisecond,
+		Clock: clock,
+	})
+	for i := range queue.WQCap {
+		q.Enqueue(i)
+	}
+	clock.Advance(time.Millisecond)
+	if _, ok, wqs := q.Dequeue(); ok || wqs != container.QueueIsBelowLowWatermark {
+		t.Errorf("got ok %t, state %s, but expected false, %s", ok, wqs, container.QueueIsBelowLowWatermark)
+	}
+	if q.Len() != 0 {
+		t.Errorf("got length %d but expected 0", q.Len())
+	}
+	if stats := q.Stats(); stats.Expired != queue.WQCap {
+		t.Errorf("got %d expired but expected %d", stats.Expired, queue.WQCap)
+	}
+}
+
+func TestExpiringWaitableQueue_Close(t *testing.T) {
+	q, _ := queue.NewExpiringWaitableQueue(queue.WQCap, queue.WQLow, queue.WQHigh, queue.ExpiryOptions[int]{})
+	q.Enqueue(1)
+	q.Enqueue(2)
+	q.Close()
+	q.Close() // Idempotent
+	if actual := slices.Collect(queue.All(t.Context(), q)); !slices.Equal(actual, []int{1, 2}) {
+		t.Errorf("got %v but expected [1 2]", actual)
+	}
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Enqueue() expected panic but didn't get one")
+		}
+	}()
+	q.Enqueue(3)
+}
@@ -0,0 +1,45 @@
+package queue
+
+import (
+	"context"
+	"iter"
+
+	"github.com/fgm/container"
+	types "github.com/fgm/container/internal"
+)
+
+// Dequeuer is the consuming side of a container.WaitableQueue.
+//
+// It is implemented by all WaitableQueue implementations and by Subscription.
+type Dequeuer[E any] interface {
+	Dequeue() (E, bool, container.WaitableQueueState)
+	WaitChan() <-chan container.Unit
+}
+
+// All returns an iterator over the elements of q, dequeuing them as it goes.
+// It blocks while q is empty, and ends once q is closed and drained,
+// or when the context is canceled.
+//
+// It works with any container.WaitableQueue implementation,
+// and may be used by several consumers at once.
+func All[E any](ctx context.Context, q Dequeuer[E]) iter.Seq[E] {
+	return types.All(ctx, q.Dequeue, q.WaitChan())
+}
+
+// AllWithState is like All, but also yields the state of q after each element was dequeued.
+func AllWithState[E any](ctx context.Context, q Dequeuer[E]) iter.Seq2[E, container.WaitableQueueState] {
+	return types.AllWithState(ctx, q.Dequeue, q.WaitChan())
+}
+
+// AllRateLimited is like All, but dequeues the elements of rq with DequeueWait,
+// so it also ends when the rate limiter wait is canceled.
+func AllRateLimited[E any](ctx context.Context, rq *RateLimitedQueue[E]) iter.Seq[E] {
+	return func(yield func(E) bool) {
+		for {
+			e, _, err := rq.DequeueWait(ctx)
+			if err != nil || !yield(e) {
+				return
+			}
+		}
+	}
+}
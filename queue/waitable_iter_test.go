@@ -0,0 +1,136 @@
+package queue_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/fgm/container"
+	"github.com/fgm/container/queue"
+)
+
+func TestWaitable_All(t *testing.T) {
+	q := mustWaitable(t, 1, 2)
+	go func() {
+		time.Sleep(10 * time.Millisecond) // Let All block on the empty queue.
+		q.Enqueue(3)
+		q.Close()
+	}()
+	actual := slices.Collect(queue.All(t.Context(), q))
+	if expected := []int{1, 2, 3}; !slices.Equal(actual, expected) {
+		t.Errorf("got %v but expected %v", actual, expected)
+	}
+}
+
+func TestWaitable_AllWithState(t *testing.T) {
+	q := mustWaitable(t, 1, 2, 3, 4)
+	q.Close()
+	var states []container.WaitableQueueState
+	for _, wqs := range queue.AllWithState(t.Context(), q) {
+		states = append(states, wqs)
+	}
+	expected := []container.WaitableQueueState{
+		container.QueueIsNominal,
+		container.QueueIsBelowLowWatermark,
+		container.QueueIsBelowLowWatermark,
+		container.QueueIsBelowLowWatermark,
+	}
+	if !slices.Equal(states, expected) {
+		t.Errorf("got %v but expected %v", states, expected)
+	}
+}
+
+func TestWaitable_AllCancel(t *testing.T) {
+	q := mustWaitable(t, 1)
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	count := 0
+	for range queue.All(ctx, q) {
+		count++
+		cancel() // The queue is open and empty, so only cancellation ends the loop.
+	}
+	if count != 1 {
+		t.Errorf("got %d elements but expected 1", count)
+	}
+}
+
+func TestWaitable_AllBreak(t *testing.T) {
+	q := mustWaitable(t, 1, 2, 3)
+	for e := range queue.All(t.Context(), q) {
+		if e == 2 {
+			break
+		}
+	}
+	if e, ok, _ := q.Dequeue(); !ok || e != 3 {
+		t.Errorf("got %d, %t but expected 3, true", e, ok)
+	}
+}
+
+// chanQueue is a minimal container.WaitableQueue, like those implemented outside this module.
+type chanQueue struct {
+	items  chan int
+	signal chan container.Unit
+}
+
+func (cq chanQueue) Close() { close(cq.signal) }
+
+func (cq chanQueue) Dequeue() (int, bool, container.WaitableQueueState) {
+	select {
+	case e := <-cq.items:
+		return e, true, container.QueueIsNominal
+	default:
+		return 0, false, container.QueueIsBelowLowWatermark
+	}
+}
+
+func (cq chanQueue) Enqueue(e int) container.WaitableQueueState {
+	cq.items <- e
+	select {
+	case cq.signal <- container.Unit{}:
+	default:
+	}
+	return container.QueueIsNominal
+}
+
+func (cq chanQueue) WaitChan() <-chan container.Unit { return cq.signal }
+
+func TestAll_OtherImplementation(t *testing.T) {
+	var q container.WaitableQueue[int] = chanQueue{items: make(chan int, 3), signal: make(chan container.Unit, 1)}
+	for e := range 3 {
+		q.Enqueue(e)
+	}
+	q.Close()
+	actual := slices.Collect(queue.All(t.Context(), q))
+	if expected := []int{0, 1, 2}; !slices.Equal(actual, expected) {
+		t.Errorf("got %v but expected %v", actual, expected)
+	}
+}
+
+func TestWaitablePriority_All(t *testing.T) {
+	q, err := queue.NewWaitablePriorityQueue[int](queue.WQCap, queue.WQLow, queue.WQHigh, func(a, b int) bool { return a > b }, nil)
+	if err != nil {
+		t.Fatalf("Failed to create queue: %v", err)
+	}
+	for _, e := range []int{2, 3, 1} {
+		q.Enqueue(e)
+	}
+	q.Close()
+	actual := slices.Collect(queue.All(t.Context(), q))
+	if expected := []int{3, 2, 1}; !slices.Equal(actual, expected) {
+		t.Errorf("got %v but expected %v", actual, expected)
+	}
+}
+
+func TestSubscription_All(t *testing.T) {
+	b, _ := queue.NewBroadcast[int](0)
+	s, _ := b.Subscribe(queue.SubscriptionOptions{})
+	for i := range 3 {
+		_ = b.Publish(t.Context(), i)
+	}
+	b.Close()
+	actual := slices.Collect(queue.All(t.Context(), s))
+	if expected := []int{0, 1, 2}; !slices.Equal(actual, expected) {
+		t.Errorf("got %v but expected %v", actual, expected)
+	}
+}
@@ -0,0 +1,196 @@
+package queue
+
+import (
+	"errors"
+	"maps"
+	"sync"
+
+	"github.com/fgm/container"
+	types "github.com/fgm/container/internal"
+)
+
+var ErrLessIsNil = errors.New("container: priority comparator cannot be nil")
+
+// prioritized wraps an element with its insertion sequence,
+// to keep FIFO order between elements of equal priority.
+type prioritized[E any] struct {
+	value E
+	seq   uint64
+}
+
+// waitablePriority implements WaitableQueue with priority ordering.
+type waitablePriority[E any] struct {
+	types.Watermarks
+	band   func(E) int // Optional: maps an element to its priority band
+	bands  map[int]int // Depth per band, only maintained if band is not nil
+	closed bool
+	items  []prioritized[E]  // Binary heap
+	less   func(a, b E) bool // Returns true if a must be dequeued before b
+	mu     sync.Mutex
+	seq    uint64    // Next insertion sequence
+	signal chan unit // Used to signal availability or closure
+}
+
+// NewWaitablePriorityQueue creates a new WaitableQueue in which elements are
+// dequeued by priority instead of insertion order.
+//
+// The less function returns true if a has a higher priority than b, meaning
+// it must be dequeued first. Elements of equal priority are dequeued in FIFO order.
+//
+// The optional band function maps elements to a priority band. When it is not nil,
+// the returned queue also implements container.BandCountable.
+//
+// Watermark states are computed on the total number of elements, regardless of priority.
+func NewWaitablePriorityQueue[E any](initialCapacity int, lowWatermark, highWatermark int, less func(a, b E) bool, band func(E) int) (container.WaitableQueue[E], error) {
+	wm, err := types.NewWatermarks(initialCapacity, lowWatermark, highWatermark)
+	if err != nil {
+		return nil, err
+	}
+	if less == nil {
+		return nil, ErrLessIsNil
+	}
+	wp := &waitablePriority[E]{
+		Watermarks: wm,
+		items:      make([]prioritized[E], 0, initialCapacity),
+		less:       less,
+		signal:     make(chan unit, 1),
+	}
+	if band == nil {
+		return wp, nil
+	}
+	wp.band = band
+	wp.bands = make(map[int]int)
+	return &bandedWaitablePriority[E]{wp}, nil
+}
+
+// before is the heap ordering: priority first, then insertion order.
+func (wp *waitablePriority[E]) before(i, j int) bool {
+	a, b := wp.items[i], wp.items[j]
+	if wp.less(a.value, b.value) {
+		return true
+	}
+	if wp.less(b.value, a.value) {
+		return false
+	}
+	return a.seq < b.seq
+}
+
+func (wp *waitablePriority[E]) up(j int) {
+	for j > 0 {
+		i := (j - 1) / 2 // parent
+		if !wp.before(j, i) {
+			break
+		}
+		wp.items[i], wp.items[j] = wp.items[j], wp.items[i]
+		j = i
+	}
+}
+
+func (wp *waitablePriority[E]) down(i int) {
+	n := len(wp.items)
+	for {
+		j := 2*i + 1 // left child
+		if j >= n {
+			break
+		}
+		if r := j + 1; r < n && wp.before(r, j) {
+			j = r
+		}
+		if !wp.before(j, i) {
+			break
+		}
+		wp.items[i], wp.items[j] = wp.items[j], wp.items[i]
+		i = j
+	}
+}
+
+// Enqueue adds an item at the position matching its priority and signals if necessary.
+func (wp *waitablePriority[E]) Enqueue(item E) container.WaitableQueueState {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	if wp.closed {
+		panic("enqueue on closed queue")
+	}
+
+	wp.items = append(wp.items, prioritized[E]{value: item, seq: wp.seq})
+	wp.seq++
+	wp.up(len(wp.items) - 1)
+	if wp.band != nil {
+		wp.bands[wp.band(item)]++
+	}
+
+	// Non-blocking send, as in waitable.Enqueue.
+	select {
+	case wp.signal <- unit{}:
+	default:
+	}
+	return wp.State(len(wp.items))
+}
+
+// Dequeue removes and returns the highest priority item if available.
+func (wp *waitablePriority[E]) Dequeue() (E, bool, container.WaitableQueueState) {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	n := len(wp.items) - 1
+	if n < 0 {
+		var zero E
+		return zero, false, container.QueueIsBelowLowWatermark
+	}
+
+	item := wp.items[0].value
+	wp.items[0] = wp.items[n]
+	wp.items[n] = prioritized[E]{} // Prevent memory leak if E is a pointer type
+	wp.items = wp.items[:n]
+	wp.down(0)
+
+	if wp.band != nil {
+		b := wp.band(item)
+		if wp.bands[b]--; wp.bands[b] == 0 {
+			delete(wp.bands, b)
+		}
+	}
+	return item, true, wp.State(len(wp.items))
+}
+
+// Len returns the total number of items in the queue, regardless of their priority.
+//
+// It MUST NOT be called while holding the mutex to avoid deadlocks.
+func (wp *waitablePriority[E]) Len() int {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+	return len(wp.items)
+}
+
+// WaitChan returns the signal channel.
+func (wp *waitablePriority[E]) WaitChan() <-chan container.Unit {
+	return wp.signal
+}
+
+// Close marks the queue as closed and closes the signal channel.
+func (wp *waitablePriority[E]) Close() {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+	if !wp.closed {
+		wp.closed = true
+		close(wp.signal)
+	}
+}
+
+// bandedWaitablePriority is a waitablePriority also implementing container.BandCountable.
+//
+// It is a separate type so that callers can rely on a type assertion to
+// know whether band depths are available.
+type bandedWaitablePriority[E any] struct {
+	*waitablePriority[E]
+}
+
+// BandLens returns a snapshot of the number of items in each non-empty priority band.
+//
+// It MUST NOT be called while holding the mutex to avoid deadlocks.
+func (bwp *bandedWaitablePriority[E]) BandLens() map[int]int {
+	bwp.mu.Lock()
+	defer bwp.mu.Unlock()
+	return maps.Clone(bwp.bands)
+}
@@ -0,0 +1,187 @@
+package queue_test
+
+import (
+	"errors"
+	"maps"
+	"sync"
+	"testing"
+
+	"github.com/fgm/container"
+	"github.com/fgm/container/queue"
+)
+
+type job struct {
+	name     string
+	priority int
+}
+
+func jobLess(a, b job) bool { return a.priority > b.priority }
+func jobBand(j job) int     { return j.priority }
+
+func TestNewWaitablePriorityQueue(t *testing.T) {
+	tests := [...]struct {
+		name             string
+		capacity, lo, hi int
+		less             func(a, b job) bool
+		expectErr        error
+	}{
+		{"capacity below 0", -1, 0, 0, jobLess, queue.ErrCapacityIsNegative},
+		{"high watermark below low watermark", 10, 8, 2, jobLess, queue.ErrHighWatermarkIsLessThanLowWatermark},
+		{"nil comparator", 10, 2, 8, nil, queue.ErrLessIsNil},
+		{"happy path", 10, 2, 8, jobLess, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := queue.NewWaitablePriorityQueue[job](test.capacity, test.lo, test.hi, test.less, nil)
+			if !errors.Is(err, test.expectErr) {
+				t.Fatalf("Got %v but expected error %v", err, test.expectErr)
+			}
+			if (actual == nil) != (test.expectErr != nil) {
+				t.Fatalf("Got queue %#v with error %v", actual, err)
+			}
+		})
+	}
+}
+
+func TestWaitablePriority_Order(t *testing.T) {
+	q, err := queue.NewWaitablePriorityQueue[job](queue.WQCap, queue.WQLow, queue.WQHigh, jobLess, nil)
+	if err != nil {
+		t.Fatalf("Failed to create queue: %v", err)
+	}
+	if _, ok := q.(container.BandCountable); ok {
+		t.Errorf("queue without band function should not be BandCountable")
+	}
+	input := []job{{"l1", 1}, {"h1", 9}, {"m1", 5}, {"h2", 9}, {"l2", 1}, {"m2", 5}}
+	for _, j := range input {
+		q.Enqueue(j)
+	}
+	expected := []string{"h1", "h2", "m1", "m2", "l1", "l2"}
+	for i, name := range expected {
+		actual, ok, _ := q.Dequeue()
+		if !ok {
+			t.Fatalf("failed dequeueing item %d", i)
+		}
+		if actual.name != name {
+			t.Errorf("item %d: got %s but expected %s", i, actual.name, name)
+		}
+	}
+	if _, ok, wqs := q.Dequeue(); ok || wqs != container.QueueIsBelowLowWatermark {
+		t.Errorf("dequeue from empty queue: got ok %t, state %s", ok, wqs)
+	}
+}
+
+func TestWaitablePriority_States(t *testing.T) {
+	q, err := queue.NewWaitablePriorityQueue[job](queue.WQCap, queue.WQLow, queue.WQHigh, jobLess, nil)
+	if err != nil {
+		t.Fatalf("Failed to create queue: %v", err)
+	}
+	expected := []container.WaitableQueueState{
+		container.QueueIsBelowLowWatermark,  // 1
+		container.QueueIsBelowLowWatermark,  // 2
+		container.QueueIsNominal,            // 3
+		container.QueueIsNominal,            // 4
+		container.QueueIsNominal,            // 5
+		container.QueueIsNominal,            // 6
+		container.QueueIsNominal,            // 7
+		container.QueueIsAboveHighWatermark, // 8
+		container.QueueIsNearSaturation,     // 9
+		container.QueueIsNearSaturation,     // 10
+	}
+	for i, state := range expected {
+		// Alternate priorities: states only depend on total size.
+		if actual := q.Enqueue(job{priority: i % 3}); actual != state {
+			t.Errorf("enqueue %d: got %s but expected %s", i+1, actual, state)
+		}
+	}
+	q.Dequeue()
+	if _, _, actual := q.Dequeue(); actual != container.QueueIsAboveHighWatermark {
+		t.Errorf("dequeue: got %s but expected %s", actual, container.QueueIsAboveHighWatermark)
+	}
+}
+
+func TestWaitablePriority_BandLens(t *testing.T) {
+	q, err := queue.NewWaitablePriorityQueue[job](queue.WQCap, queue.WQLow, queue.WQHigh, jobLess, jobBand)
+	if err != nil {
+		t.Fatalf("Failed to create queue: %v", err)
+	}
+	bc, ok := q.(container.BandCountable)
+	if !ok {
+		t.Fatalf("queue with band function should be BandCountable")
+	}
+	for _, j := range []job{{"a", 1}, {"b", 2}, {"c", 1}, {"d", 3}} {
+		q.Enqueue(j)
+	}
+	if actual, expected := bc.BandLens(), map[int]int{1: 2, 2: 1, 3: 1}; !maps.Equal(actual, expected) {
+		t.Errorf("got %v but expected %v", actual, expected)
+	}
+	q.Dequeue() // d: band 3 becomes empty
+	q.Dequeue() // b
+	actual := bc.BandLens()
+	if expected := map[int]int{1: 2}; !maps.Equal(actual, expected) {
+		t.Errorf("got %v but expected %v", actual, expected)
+	}
+	// The result is a snapshot, not a view on the queue.
+	actual[1] = 42
+	if bc.BandLens()[1] != 2 {
+		t.Errorf("BandLens result should not alias queue state")
+	}
+	if c, ok := q.(container.Countable); !ok || c.Len() != 2 {
+		t.Errorf("expected a Countable queue with 2 items")
+	}
+}
+
+func TestWaitablePriority_Close(t *testing.T) {
+	q, err := queue.NewWaitablePriorityQueue[job](queue.WQCap, queue.WQLow, queue.WQHigh, jobLess, nil)
+	if err != nil {
+		t.Fatalf("Failed to create queue: %v", err)
+	}
+	q.Enqueue(job{"a", 1})
+	q.Close()
+	q.Close()      // Idempotent
+	<-q.WaitChan() // Pending signal from Enqueue
+	if _, ok := <-q.WaitChan(); ok {
+		t.Errorf("WaitChan should be closed")
+	}
+	if actual, ok, _ := q.Dequeue(); !ok || actual.name != "a" {
+		t.Errorf("closed queue should still be drainable")
+	}
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Enqueue() expected panic but didn't get one")
+		}
+	}()
+	q.Enqueue(job{"b", 1})
+}
+
+func TestWaitablePriority_Concurrent(t *testing.T) {
+	const (
+		producers = 4
+		perProd   = 250
+	)
+	q, err := queue.NewWaitablePriorityQueue[int](producers*perProd, 0, producers*perProd, func(a, b int) bool { return a < b }, nil)
+	if err != nil {
+		t.Fatalf("Failed to create queue: %v", err)
+	}
+	var wg sync.WaitGroup
+	wg.Add(producers)
+	for p := range producers {
+		go func() {
+			defer wg.Done()
+			for i := range perProd {
+				q.Enqueue(p*perProd + i)
+			}
+		}()
+	}
+	wg.Wait()
+	prev := -1
+	for range producers * perProd {
+		actual, ok, _ := q.Dequeue()
+		if !ok {
+			t.Fatalf("queue drained too early")
+		}
+		if actual <= prev {
+			t.Fatalf("got %d after %d", actual, prev)
+		}
+		prev = actual
+	}
+}
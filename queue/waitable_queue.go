@@ -1,29 +1,28 @@
 package queue
 
 import (
-	"errors"
-	"fmt"
 	"sync"
 
 	"github.com/fgm/container"
+	types "github.com/fgm/container/internal"
 )
 
 var (
-	ErrCapacityIsNegative                  = errors.New("container: initial capacity cannot be negative")
-	ErrLowWatermarkIsNegative              = errors.New("container: low watermark cannot be negative")
-	ErrHighWatermarkIsNegative             = errors.New("container: high watermark cannot be negative")
-	ErrHighWatermarkIsLessThanLowWatermark = errors.New("container: high watermark cannot be less than low watermark")
+	ErrCapacityIsNegative                  = types.ErrCapacityIsNegative
+	ErrLowWatermarkIsNegative              = types.ErrLowWatermarkIsNegative
+	ErrHighWatermarkIsNegative             = types.ErrHighWatermarkIsNegative
+	ErrHighWatermarkIsLessThanLowWatermark = types.ErrHighWatermarkIsLessThanLowWatermark
 )
 
 type unit = container.Unit
 
 // waitable implements WaitableQueue
 type waitable[E any] struct {
-	closed      bool
-	items       []E
-	hi, lo, sat int // Low and high watermarks, possible saturation
-	mu          sync.Mutex
-	signal      chan unit // Used to signal availability or closure
+	types.Watermarks
+	closed bool
+	items  []E
+	mu     sync.Mutex
+	signal chan unit // Used to signal availability or closure
 }
 
 // NewWaitableQueue creates a new WaitableQueue with the given initial capacity and watermarks.
@@ -31,17 +30,9 @@ type waitable[E any] struct {
 // The three arguments are in number of elements, not in bytes.
 // Implementations MAY use the initial capacity to preallocate storage.
 func NewWaitableQueue[E any](initialCapacity int, lowWatermark, highWatermark int) (container.WaitableQueue[E], error) {
-	if initialCapacity < 0 {
-		return nil, fmt.Errorf("%w: got %d", ErrCapacityIsNegative, initialCapacity)
-	}
-	if lowWatermark < 0 {
-		return nil, fmt.Errorf("%w: got %d", ErrLowWatermarkIsNegative, lowWatermark)
-	}
-	if highWatermark < 0 {
-		return nil, fmt.Errorf("%w: got %d", ErrHighWatermarkIsNegative, highWatermark)
-	}
-	if lowWatermark > highWatermark {
-		return nil, fmt.Errorf("%w: low is %d high is %d", ErrHighWatermarkIsLessThanLowWatermark, lowWatermark, highWatermark)
+	wm, err := types.NewWatermarks(initialCapacity, lowWatermark, highWatermark)
+	if err != nil {
+		return nil, err
 	}
 
 	// Use a buffered channel of size 1. This prevents Enqueue
@@ -49,12 +40,10 @@ func NewWaitableQueue[E any](initialCapacity int, lowWatermark, highWatermark in
 	// It acts like a latch: if signal is sent and no one is waiting,
 	// the next wait will immediately succeed.
 	return &waitable[E]{
-		closed: false,
-		items:  make([]E, 0, initialCapacity),
-		hi:     highWatermark,
-		lo:     lowWatermark,
-		sat:    (highWatermark + 3*initialCapacity) / 4,
-		signal: make(chan unit, 1),
+		Watermarks: wm,
+		closed:     false,
+		items:      make([]E, 0, initialCapacity),
+		signal:     make(chan unit, 1),
 	}, nil
 }
 
@@ -62,17 +51,7 @@ func NewWaitableQueue[E any](initialCapacity int, lowWatermark, highWatermark in
 //
 // It MUST only be called while holding the mutex to avoid race conditions.
 func (bq *waitable[E]) getState() container.WaitableQueueState {
-	l := len(bq.items) // Do not use bq.Len() here, it would deadlock.
-	switch {
-	case l <= bq.lo:
-		return container.QueueIsBelowLowWatermark
-	case l >= bq.hi && l < bq.sat:
-		return container.QueueIsAboveHighWatermark
-	case l >= bq.sat:
-		return container.QueueIsNearSaturation
-	default:
-		return container.QueueIsNominal
-	}
+	return bq.State(len(bq.items)) // Do not use bq.Len() here, it would deadlock.
 }
 
 // Enqueue adds an item and signals *if* necessary.
@@ -0,0 +1,158 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/fgm/container"
+)
+
+var (
+	ErrIdleTimeoutIsNotPositive       = errors.New("container: idle timeout must be positive")
+	ErrMaxWorkersIsLessThanMinWorkers = errors.New("container: maximum workers cannot be less than minimum workers")
+	ErrMinWorkersIsNotPositive        = errors.New("container: minimum workers must be positive")
+)
+
+// WorkerPool consumes a WaitableQueue with a number of workers adjusted to the
+// queue watermark states.
+//
+// It starts with a minimum number of workers, adds one each time a worker dequeues
+// an element while the queue is above its high watermark, up to a maximum,
+// and retires workers remaining idle below the low watermark for a given time,
+// down to the minimum.
+type WorkerPool[E any] struct {
+	handler  func(context.Context, E)
+	idle     time.Duration
+	min, max int
+	mu       sync.Mutex
+	q        container.WaitableQueue[E]
+	wg       sync.WaitGroup
+	workers  int
+}
+
+// NewWorkerPool creates a WorkerPool calling handler on each element dequeued from q.
+//
+// Workers never run handler concurrently on the same element, but the handler
+// MUST be concurrency-safe, as multiple workers call it in parallel.
+func NewWorkerPool[E any](q container.WaitableQueue[E], handler func(context.Context, E), minWorkers, maxWorkers int, idleTimeout time.Duration) (*WorkerPool[E], error) {
+	if minWorkers <= 0 {
+		return nil, fmt.Errorf("%w: got %d", ErrMinWorkersIsNotPositive, minWorkers)
+	}
+	if maxWorkers < minWorkers {
+		return nil, fmt.Errorf("%w: min is %d max is %d", ErrMaxWorkersIsLessThanMinWorkers, minWorkers, maxWorkers)
+	}
+	if idleTimeout <= 0 {
+		return nil, fmt.Errorf("%w: got %v", ErrIdleTimeoutIsNotPositive, idleTimeout)
+	}
+	return &WorkerPool[E]{
+		handler: handler,
+		idle:    idleTimeout,
+		min:     minWorkers,
+		max:     maxWorkers,
+		q:       q,
+	}, nil
+}
+
+// Run starts the minimum number of workers, then blocks until the queue is
+// closed and drained, or the context is canceled.
+//
+// It MUST NOT be called more than once.
+func (p *WorkerPool[E]) Run(ctx context.Context) {
+	p.mu.Lock()
+	p.workers = p.min
+	p.wg.Add(p.min)
+	for range p.min {
+		go p.work(ctx)
+	}
+	p.mu.Unlock()
+	p.wg.Wait()
+}
+
+// Workers returns the current number of workers.
+//
+// Like Countable.Len, it MUST NOT be used to take decisions,
+// but only as an observability/debugging tool.
+func (p *WorkerPool[E]) Workers() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.workers
+}
+
+// grow adds a worker unless the pool is already at its maximum size.
+func (p *WorkerPool[E]) grow(ctx context.Context) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.workers >= p.max {
+		return
+	}
+	p.workers++
+	p.wg.Add(1)
+	go p.work(ctx)
+}
+
+// shrink accounts for a retiring worker unless the pool is already at its minimum size,
+// and returns true if the worker may retire.
+func (p *WorkerPool[E]) shrink() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.workers <= p.min {
+		return false
+	}
+	p.workers--
+	return true
+}
+
+// exit accounts for a worker stopping on closure or cancellation.
+func (p *WorkerPool[E]) exit() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.workers--
+}
+
+// work is the worker loop.
+func (p *WorkerPool[E]) work(ctx context.Context) {
+	defer p.wg.Done()
+	idle := time.NewTimer(p.idle)
+	defer idle.Stop()
+
+	closed := false
+	for {
+		// Dequeue until the queue is empty.
+		for {
+			if ctx.Err() != nil {
+				p.exit()
+				return
+			}
+			e, ok, wqs := p.q.Dequeue()
+			if !ok {
+				break
+			}
+			if wqs >= container.QueueIsAboveHighWatermark {
+				p.grow(ctx)
+			}
+			p.handler(ctx, e)
+		}
+		if closed {
+			p.exit()
+			return
+		}
+
+		idle.Reset(p.idle)
+		select {
+		case <-ctx.Done():
+			p.exit()
+			return
+		case _, ok := <-p.q.WaitChan():
+			// On closure, loop once more to drain the queue, then exit.
+			closed = !ok
+		case <-idle.C:
+			// The queue was found empty, hence below its low watermark, and stayed so.
+			if p.shrink() {
+				return
+			}
+		}
+	}
+}
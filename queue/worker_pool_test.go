@@ -0,0 +1,124 @@
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fgm/container/queue"
+)
+
+func TestNewWorkerPool(t *testing.T) {
+	q := mustWaitable[int](t)
+	handler := func(context.Context, int) {}
+	tests := [...]struct {
+		name      string
+		min, max  int
+		idle      time.Duration
+		expectErr error
+	}{
+		{"min workers below 1", 0, 1, time.Second, queue.ErrMinWorkersIsNotPositive},
+		{"max workers below min", 2, 1, time.Second, queue.ErrMaxWorkersIsLessThanMinWorkers},
+		{"idle timeout not positive", 1, 1, 0, queue.ErrIdleTimeoutIsNotPositive},
+		{"happy path", 1, 2, time.Second, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p, err := queue.NewWorkerPool(q, handler, test.min, test.max, test.idle)
+			if !errors.Is(err, test.expectErr) {
+				t.Fatalf("Got %v but expected error %v", err, test.expectErr)
+			}
+			if (p == nil) != (test.expectErr != nil) {
+				t.Fatalf("Got pool %#v with error %v", p, err)
+			}
+		})
+	}
+}
+
+func TestWorkerPool_Scaling(t *testing.T) {
+	const (
+		minWorkers = 1
+		maxWorkers = 4
+		items      = 40
+	)
+	q, err := queue.NewWaitableQueue[int](items, 2, 5)
+	if err != nil {
+		t.Fatalf("Failed to create queue: %v", err)
+	}
+	var (
+		handled atomic.Int32
+		release = make(chan struct{})
+	)
+	p, err := queue.NewWorkerPool(q, func(context.Context, int) {
+		<-release
+		handled.Add(1)
+	}, minWorkers, maxWorkers, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Failed to create pool: %v", err)
+	}
+	for i := range items {
+		q.Enqueue(i)
+	}
+	done := make(chan struct{})
+	go func() {
+		p.Run(t.Context())
+		close(done)
+	}()
+
+	// With the handler blocked, each dequeue above the high watermark adds a worker.
+	waitFor(t, "scale up", func() bool { return p.Workers() == maxWorkers })
+	close(release)
+
+	// Once the queue is drained, idle workers are retired.
+	waitFor(t, "drain", func() bool { return handled.Load() == items })
+	waitFor(t, "scale down", func() bool { return p.Workers() == minWorkers })
+
+	q.Enqueue(items)
+	q.Close()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Run did not return after queue closure")
+	}
+	if actual := handled.Load(); actual != items+1 {
+		t.Errorf("handled %d items but expected %d", actual, items+1)
+	}
+	if p.Workers() != 0 {
+		t.Errorf("got %d workers after closure", p.Workers())
+	}
+}
+
+func TestWorkerPool_Cancel(t *testing.T) {
+	q := mustWaitable[int](t)
+	p, err := queue.NewWorkerPool(q, func(context.Context, int) {}, 2, 2, time.Second)
+	if err != nil {
+		t.Fatalf("Failed to create pool: %v", err)
+	}
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		p.Run(ctx)
+		close(done)
+	}()
+	waitFor(t, "start", func() bool { return p.Workers() == 2 })
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Run did not return after cancellation")
+	}
+}
+
+// waitFor polls cond until it is true, failing the test after a timeout.
+func waitFor(tb testing.TB, what string, cond func() bool) {
+	tb.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			tb.Fatalf("timeout waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
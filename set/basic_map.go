@@ -202,6 +202,182 @@ func (s *BasicMap[E]) Difference(other container.Set[E]) container.Set[E] {
 	return result
 }
 
+// SymmetricDifference returns a new set containing elements present in either set but not in both.
+//
+// Note that it may return one of its arguments without creating a clone.
+func (s *BasicMap[E]) SymmetricDifference(other container.Set[E]) container.Set[E] {
+	// Shortcut degenerate cases.
+	if s == nil && other == nil {
+		return NewBasicMap[E](0)
+	}
+	if s == nil || s.Len() == 0 {
+		if other == nil {
+			return s
+		}
+		return other
+	}
+	if isEmpty(other) {
+		return s
+	}
+
+	// Non-degenerate case.
+	result := &BasicMap[E]{items: make(map[E]unit, s.Len())}
+
+	// Add items from other set which are not in this set
+	for item := range other.Items() {
+		if !s.Contains(item) {
+			result.Add(item)
+		}
+	}
+
+	// Add items from this set which are not in other set
+	for item := range s.items {
+		if !other.Contains(item) {
+			result.Add(item)
+		}
+	}
+
+	return result
+}
+
+// IsSubsetOf returns true if all elements in this set are present in the other.
+//
+// The empty set is a subset of any set, and nil sets are considered empty.
+func (s *BasicMap[E]) IsSubsetOf(other container.Set[E]) bool {
+	return isSubset(s, other)
+}
+
+// IsSupersetOf returns true if all elements in the other set are present in this one.
+//
+// Any set is a superset of the empty set, and nil sets are considered empty.
+func (s *BasicMap[E]) IsSupersetOf(other container.Set[E]) bool {
+	return isSuperset(s, other)
+}
+
+// IsProperSubsetOf returns true if this set is a subset of the other, and the other has more elements.
+func (s *BasicMap[E]) IsProperSubsetOf(other container.Set[E]) bool {
+	return isProperSubset(s, other)
+}
+
+// Equal returns true if both sets contain the same elements.
+//
+// Nil sets are considered empty, hence equal to empty sets.
+func (s *BasicMap[E]) Equal(other container.Set[E]) bool {
+	return equal(s, other)
+}
+
+// IsDisjoint returns true if the sets have no element in common.
+func (s *BasicMap[E]) IsDisjoint(other container.Set[E]) bool {
+	return isDisjoint(s, other)
+}
+
+// UnionWith adds the elements of the other set to this one, and returns the number of elements added.
+//
+// Unlike Union, it reuses the receiver storage instead of allocating a new set.
+func (s *BasicMap[E]) UnionWith(other container.Set[E]) (added int) {
+	// Shortcut degenerate cases.
+	if s == nil || other == nil || s.is(other) {
+		return 0
+	}
+
+	for item := range other.Items() {
+		if !s.Add(item) {
+			added++
+		}
+	}
+	return added
+}
+
+// IntersectWith removes the elements of this set absent from the other, and returns the number of elements removed.
+//
+// Unlike Intersection, it reuses the receiver storage instead of allocating a new set.
+func (s *BasicMap[E]) IntersectWith(other container.Set[E]) (removed int) {
+	// Shortcut degenerate cases.
+	if s.Len() == 0 || s.is(other) {
+		return 0
+	}
+	if isEmpty(other) {
+		return s.clear()
+	}
+
+	for item := range s.items {
+		if !other.Contains(item) {
+			delete(s.items, item)
+			removed++
+		}
+	}
+	return removed
+}
+
+// SubtractWith removes the elements of the other set from this one, and returns the number of elements removed.
+//
+// Unlike Difference, it reuses the receiver storage instead of allocating a new set.
+func (s *BasicMap[E]) SubtractWith(other container.Set[E]) (removed int) {
+	// Shortcut degenerate cases.
+	if s.Len() == 0 || other == nil {
+		return 0
+	}
+	if s.is(other) {
+		return s.clear()
+	}
+
+	// Non-degenerate case: iterate on the smaller set if it is known.
+	if countable, ok := other.(container.Countable); ok && countable.Len() < s.Len() {
+		for item := range other.Items() {
+			if s.Remove(item) {
+				removed++
+			}
+		}
+		return removed
+	}
+	for item := range s.items {
+		if other.Contains(item) {
+			delete(s.items, item)
+			removed++
+		}
+	}
+	return removed
+}
+
+// SymmetricDifferenceWith adds the elements of the other set absent from this one,
+// removes those present in both, and returns the number of elements added and removed.
+//
+// Unlike SymmetricDifference, it reuses the receiver storage instead of allocating a new set.
+func (s *BasicMap[E]) SymmetricDifferenceWith(other container.Set[E]) (added, removed int) {
+	// Shortcut degenerate cases.
+	if s == nil || other == nil {
+		return 0, 0
+	}
+	if s.is(other) {
+		return 0, s.clear()
+	}
+
+	for item := range other.Items() {
+		if s.Remove(item) {
+			removed++
+		} else {
+			s.items[item] = unit{}
+			added++
+		}
+	}
+	return added, removed
+}
+
+// clear removes all items from the set, keeping its storage unlike Clear,
+// and returns the number of items removed.
+func (s *BasicMap[E]) clear() (count int) {
+	count = len(s.items)
+	clear(s.items)
+	return count
+}
+
+// is returns true if the other set is the receiver itself, which in-place
+// operations must not iterate while modifying it.
+func (s *BasicMap[E]) is(other container.Set[E]) bool {
+	o, ok := other.(*BasicMap[E])
+	return ok && o == s
+}
+
 // NewBasicMap returns a ready-for-use container.Set implemented by the BasicMap type.
 func NewBasicMap[E comparable](sizeHint int) container.Set[E] {
 	return &BasicMap[E]{make(map[E]unit, sizeHint)}
@@ -159,6 +159,8 @@ func TestBasicMap_SetOperations(t *testing.T) {
 		{"union", testUnion},
 		{"intersection", testIntersection},
 		{"difference", testDifference},
+		{"symmetric difference", testSymmetricDifference},
+		{"predicates", testPredicates},
 	}
 
 	for _, tt := range tests {
@@ -272,6 +274,193 @@ func testDifference(t *testing.T) {
 	}
 }
 
+func testSymmetricDifference(t *testing.T) {
+	cases := []struct {
+		name     string
+		s1       container.Set[int]
+		s2       container.Set[int]
+		expected int
+	}{
+		// nil without a type is not a valid receiver type.
+		{"nilSet ^ nil", nilSet(), nil, 0},
+		{"nilSet ^ nilSet", nilSet(), nilSet(), 0},
+		{"nilSet ^ empty", nilSet(), set.NewBasicMap[int](0), 0},
+		{"empty ^ nil", set.NewBasicMap[int](0), nil, 0},
+		{"empty ^ nilSet", set.NewBasicMap[int](0), nilSet(), 0},
+		{"empty ^ empty", set.NewBasicMap[int](0), set.NewBasicMap[int](0), 0},
+		{"nilSet ^ nonempty", nilSet(), createSet(t, 1, 2), 2},
+		{"nonempty ^ nil", createSet(t, 1, 2), nil, 2},
+		{"nonempty ^ nilSet", createSet(t, 1, 2), nilSet(), 2},
+		{"disjoint", createSet(t, 1, 2), createSet(t, 3, 4), 4},
+		{"overlapping", createSet(t, 1, 2), createSet(t, 2, 3), 2},
+		{"equal", createSet(t, 1, 2), createSet(t, 2, 1), 0},
+		{"non-countable", createSet(t, 1, 2), &mockNonCountableSet{elements: map[int]bool{2: true, 3: true}}, 2},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := tc.s1.SymmetricDifference(tc.s2)
+			c, ok := result.(container.Countable)
+			if !ok {
+				t.Fatalf("expected a countable implementation")
+			}
+			if c.Len() != tc.expected {
+				t.Errorf("expected length %d, got %d", tc.expected, c.Len())
+			}
+		})
+	}
+}
+
+func testPredicates(t *testing.T) {
+	type predicates struct {
+		subset, superset, proper, equal, disjoint bool
+	}
+	cases := []struct {
+		name     string
+		s1       container.Set[int]
+		s2       container.Set[int]
+		expected predicates
+	}{
+		// nil without a type is not a valid receiver type.
+		{"nilSet, nil", nilSet(), nil, predicates{true, true, false, true, true}},
+		{"nilSet, nilSet", nilSet(), nilSet(), predicates{true, true, false, true, true}},
+		{"nilSet, empty", nilSet(), set.NewBasicMap[int](0), predicates{true, true, false, true, true}},
+		{"empty, nil", set.NewBasicMap[int](0), nil, predicates{true, true, false, true, true}},
+		{"nilSet, nonempty", nilSet(), createSet(t, 1), predicates{true, false, true, false, true}},
+		{"nonempty, nil", createSet(t, 1), nil, predicates{false, true, false, false, true}},
+		{"nonempty, nilSet", createSet(t, 1), nilSet(), predicates{false, true, false, false, true}},
+		{"equal", createSet(t, 1, 2), createSet(t, 2, 1), predicates{true, true, false, true, false}},
+		{"proper subset", createSet(t, 1), createSet(t, 1, 2), predicates{true, false, true, false, false}},
+		{"proper superset", createSet(t, 1, 2), createSet(t, 2), predicates{false, true, false, false, false}},
+		{"overlapping", createSet(t, 1, 2), createSet(t, 2, 3), predicates{false, false, false, false, false}},
+		{"disjoint", createSet(t, 1, 2), createSet(t, 3), predicates{false, false, false, false, true}},
+		{"non-countable subset", createSet(t, 1), &mockNonCountableSet{elements: map[int]bool{1: true, 2: true}}, predicates{true, false, true, false, false}},
+		{"non-countable equal", createSet(t, 1, 2), &mockNonCountableSet{elements: map[int]bool{1: true, 2: true}}, predicates{true, true, false, true, false}},
+		{"non-countable disjoint", createSet(t, 1), &mockNonCountableSet{elements: map[int]bool{2: true}}, predicates{false, false, false, false, true}},
+		{"non-countable empty", createSet(t, 1), &mockNonCountableSet{elements: map[int]bool{}}, predicates{false, true, false, false, true}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := predicates{
+				subset:   tc.s1.IsSubsetOf(tc.s2),
+				superset: tc.s1.IsSupersetOf(tc.s2),
+				proper:   tc.s1.IsProperSubsetOf(tc.s2),
+				equal:    tc.s1.Equal(tc.s2),
+				disjoint: tc.s1.IsDisjoint(tc.s2),
+			}
+			if actual != tc.expected {
+				t.Errorf("expected %+v, got %+v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestBasicMap_InPlaceOperations(t *testing.T) {
+	cases := []struct {
+		name     string
+		op       func(s *set.BasicMap[int], other container.Set[int]) counts
+		s1, s2   []int
+		expected []int
+		counts   counts
+	}{
+		{"union", unionWith, []int{1, 2}, []int{2, 3}, []int{1, 2, 3}, counts{1, 0}},
+		{"union empty", unionWith, []int{1, 2}, []int{}, []int{1, 2}, counts{0, 0}},
+		{"intersect", intersectWith, []int{1, 2}, []int{2, 3}, []int{2}, counts{0, 1}},
+		{"intersect empty", intersectWith, []int{1, 2}, []int{}, []int{}, counts{0, 2}},
+		{"subtract", subtractWith, []int{1, 2, 3}, []int{2, 4}, []int{1, 3}, counts{0, 1}},
+		{"subtract larger", subtractWith, []int{1, 2}, []int{2, 3, 4}, []int{1}, counts{0, 1}},
+		{"symmetric difference", symmetricDifferenceWith, []int{1, 2}, []int{2, 3}, []int{1, 3}, counts{1, 1}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := createSet(t, tc.s1...)
+			if actual := tc.op(s, createSet(t, tc.s2...)); actual != tc.counts {
+				t.Errorf("expected counts %+v, got %+v", tc.counts, actual)
+			}
+			if expected := createSet(t, tc.expected...); !s.Equal(expected) {
+				t.Errorf("expected %v, got %v", expected, s)
+			}
+		})
+	}
+}
+
+func TestBasicMap_InPlaceDegenerate(t *testing.T) {
+	ops := []struct {
+		name     string
+		op       func(s *set.BasicMap[int], other container.Set[int]) counts
+		self     int // Expected length after applying the operation to the set itself
+		nilOther int // Expected length after applying the operation to a nil set
+	}{
+		{"union", unionWith, 2, 2},
+		{"intersect", intersectWith, 2, 0},
+		{"subtract", subtractWith, 0, 2},
+		{"symmetric difference", symmetricDifferenceWith, 0, 2},
+	}
+
+	for _, tc := range ops {
+		t.Run(tc.name, func(t *testing.T) {
+			var ns *set.BasicMap[int]
+			if actual := tc.op(ns, createSet(t, 1)); actual != (counts{}) {
+				t.Errorf("expected no change on nil receiver, got %+v", actual)
+			}
+			s := createSet(t, 1, 2)
+			tc.op(s, s)
+			if s.Len() != tc.self {
+				t.Errorf("expected length %d with itself, got %d", tc.self, s.Len())
+			}
+			s = createSet(t, 1, 2)
+			tc.op(s, nil)
+			if s.Len() != tc.nilOther {
+				t.Errorf("expected length %d with nil, got %d", tc.nilOther, s.Len())
+			}
+		})
+	}
+}
+
+// counts are the results of in-place operations.
+type counts struct{ added, removed int }
+
+func unionWith(s *set.BasicMap[int], other container.Set[int]) counts {
+	return counts{added: s.UnionWith(other)}
+}
+
+func intersectWith(s *set.BasicMap[int], other container.Set[int]) counts {
+	return counts{removed: s.IntersectWith(other)}
+}
+
+func subtractWith(s *set.BasicMap[int], other container.Set[int]) counts {
+	return counts{removed: s.SubtractWith(other)}
+}
+
+func symmetricDifferenceWith(s *set.BasicMap[int], other container.Set[int]) counts {
+	added, removed := s.SymmetricDifferenceWith(other)
+	return counts{added, removed}
+}
+
+// BenchmarkBasicMap_Union merges small sets into an accumulator of up to 1000 elements.
+func BenchmarkBasicMap_Union(b *testing.B) {
+	const accSize = 1000
+	small := createSet(b, -1, -2, -3, -4)
+	b.Run("Union", func(b *testing.B) {
+		var acc container.Set[int] = createSet(b)
+		for i := range b.N {
+			small.Add(i % accSize)
+			acc = acc.Union(small)
+			small.Remove(i % accSize)
+		}
+	})
+	b.Run("UnionWith", func(b *testing.B) {
+		acc := createSet(b)
+		for i := range b.N {
+			small.Add(i % accSize)
+			acc.UnionWith(small)
+			small.Remove(i % accSize)
+		}
+	})
+}
+
 // Helper function to create a set with given elements
 func createSet(tb testing.TB, elements ...int) *set.BasicMap[int] {
 	tb.Helper()
@@ -442,7 +631,13 @@ func (m *mockNonCountableSet) Clear() int {
 }
 
 func (m *mockNonCountableSet) Items() iter.Seq[int] {
-	return nil // not needed for this test
+	return func(yield func(int) bool) {
+		for k := range m.elements {
+			if !yield(k) {
+				return
+			}
+		}
+	}
 }
 
 func (m *mockNonCountableSet) String() string {
@@ -467,6 +662,30 @@ func (m *mockNonCountableSet) Difference(other container.Set[int]) container.Set
 	return nil // not needed for this test
 }
 
+func (m *mockNonCountableSet) SymmetricDifference(other container.Set[int]) container.Set[int] {
+	return nil // not needed for this test
+}
+
+func (m *mockNonCountableSet) IsSubsetOf(other container.Set[int]) bool {
+	return false // not needed for this test
+}
+
+func (m *mockNonCountableSet) IsSupersetOf(other container.Set[int]) bool {
+	return false // not needed for this test
+}
+
+func (m *mockNonCountableSet) IsProperSubsetOf(other container.Set[int]) bool {
+	return false // not needed for this test
+}
+
+func (m *mockNonCountableSet) Equal(other container.Set[int]) bool {
+	return false // not needed for this test
+}
+
+func (m *mockNonCountableSet) IsDisjoint(other container.Set[int]) bool {
+	return false // not needed for this test
+}
+
 func FuzzBasicMapAdd(f *testing.F) {
 	// Add some seed corpus
 	f.Add(1, 2, 3)                    // Distinct values
@@ -553,6 +772,125 @@ func FuzzBasicMapUnion(f *testing.F) {
 	})
 }
 
+func FuzzBasicMapSymmetricDifference(f *testing.F) {
+	// Add some seed corpus
+	f.Add(1, 2, 3, 4)     // All distinct
+	f.Add(-1, -1, -1, -1) // All duplicates
+	f.Add(0, 1, 0, 2)     // Intermixed duplicates
+
+	f.Fuzz(func(t *testing.T, a, b, c, d int) {
+		// Create two sets with potentially overlapping elements
+		s1 := set.NewBasicMap[int](2)
+		s1.Add(a)
+		s1.Add(b)
+
+		s2 := set.NewBasicMap[int](2)
+		s2.Add(c)
+		s2.Add(d)
+
+		// Perform symmetric difference operation
+		result := s1.SymmetricDifference(s2)
+
+		// Verify each element is in the result if and only if it is in exactly one set
+		for _, item := range []int{a, b, c, d} {
+			expected := s1.Contains(item) != s2.Contains(item)
+			if result.Contains(item) != expected {
+				t.Errorf("Symmetric difference containing %d should be %t", item, expected)
+			}
+		}
+
+		// Verify it matches the difference of the union and the intersection
+		expected := s1.Union(s2).Difference(s1.Intersection(s2))
+		if !result.Equal(expected) {
+			t.Errorf("Expected symmetric difference %v, got %v", expected, result)
+		}
+	})
+}
+
+func FuzzBasicMapPredicates(f *testing.F) {
+	// Add some seed corpus
+	f.Add(1, 2, 3, 4) // All distinct
+	f.Add(1, 2, 2, 1) // Equal sets
+	f.Add(1, 1, 1, 2) // Proper subset
+	f.Add(0, 1, 0, 2) // Intermixed duplicates
+
+	f.Fuzz(func(t *testing.T, a, b, c, d int) {
+		// Create two sets with potentially overlapping elements
+		s1 := set.NewBasicMap[int](2)
+		s1.Add(a)
+		s1.Add(b)
+
+		s2 := set.NewBasicMap[int](2)
+		s2.Add(c)
+		s2.Add(d)
+
+		// Compare the predicates with their definitions
+		subset := s1.Difference(s2).(container.Countable).Len() == 0
+		superset := s2.Difference(s1).(container.Countable).Len() == 0
+		if actual := s1.IsSubsetOf(s2); actual != subset {
+			t.Errorf("IsSubsetOf should be %t", subset)
+		}
+		if actual := s1.IsSupersetOf(s2); actual != superset {
+			t.Errorf("IsSupersetOf should be %t", superset)
+		}
+		if actual := s1.Equal(s2); actual != (subset && superset) {
+			t.Errorf("Equal should be %t", subset && superset)
+		}
+		if actual := s1.IsProperSubsetOf(s2); actual != (subset && !superset) {
+			t.Errorf("IsProperSubsetOf should be %t", subset && !superset)
+		}
+		disjoint := s1.Intersection(s2).(container.Countable).Len() == 0
+		if actual := s1.IsDisjoint(s2); actual != disjoint {
+			t.Errorf("IsDisjoint should be %t", disjoint)
+		}
+		if s1.IsDisjoint(s2) != s2.IsDisjoint(s1) {
+			t.Errorf("IsDisjoint should be symmetric")
+		}
+	})
+}
+
+func FuzzBasicMapInPlace(f *testing.F) {
+	// Add some seed corpus
+	f.Add(1, 2, 3, 4)     // All distinct
+	f.Add(-1, -1, -1, -1) // All duplicates
+	f.Add(0, 1, 0, 2)     // Intermixed duplicates
+
+	f.Fuzz(func(t *testing.T, a, b, c, d int) {
+		s2 := createSet(t, c, d)
+		ops := []struct {
+			name     string
+			inPlace  func(s *set.BasicMap[int]) int
+			expected func(s *set.BasicMap[int]) container.Set[int]
+		}{
+			{"UnionWith", func(s *set.BasicMap[int]) int { return s.UnionWith(s2) }, func(s *set.BasicMap[int]) container.Set[int] { return s.Union(s2) }},
+			{"IntersectWith", func(s *set.BasicMap[int]) int { return s.IntersectWith(s2) }, func(s *set.BasicMap[int]) container.Set[int] { return s.Intersection(s2) }},
+			{"SubtractWith", func(s *set.BasicMap[int]) int { return s.SubtractWith(s2) }, func(s *set.BasicMap[int]) container.Set[int] { return s.Difference(s2) }},
+			{"SymmetricDifferenceWith", func(s *set.BasicMap[int]) int {
+				added, removed := s.SymmetricDifferenceWith(s2)
+				return added - removed
+			}, func(s *set.BasicMap[int]) container.Set[int] { return s.SymmetricDifference(s2) }},
+		}
+		for _, op := range ops {
+			// Verify the in-place operation matches the allocating one
+			s1 := createSet(t, a, b)
+			expected := op.expected(s1)
+			delta := op.inPlace(s1)
+			if !s1.Equal(expected) {
+				t.Errorf("%s: expected %v, got %v", op.name, expected, s1)
+			}
+
+			// Verify the returned count matches the length change
+			before := len(map[int]unit{a: {}, b: {}})
+			if op.name != "UnionWith" && op.name != "SymmetricDifferenceWith" {
+				delta = -delta
+			}
+			if s1.Len() != before+delta {
+				t.Errorf("%s: expected length %d, got %d", op.name, before+delta, s1.Len())
+			}
+		}
+	})
+}
+
 func FuzzBasicMapItems(f *testing.F) {
 	// Add some seed corpus.
 	f.Add(1, 2, 3)                    // Distinct values.
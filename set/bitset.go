@@ -0,0 +1,364 @@
+package set
+
+import (
+	"fmt"
+	"iter"
+	"math/bits"
+	"slices"
+	"strings"
+
+	"github.com/fgm/container"
+)
+
+// Integer is the constraint for the elements of a Bitset.
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+const (
+	// MaxBitsetElement is the largest element a Bitset can hold, using 512 MiB.
+	MaxBitsetElement = 1<<32 - 1
+
+	wordBits = 64
+)
+
+// Bitset is a dense set of non-negative integers, storing one bit per value
+// from 0 to the largest element: a set holding n uses n/8 bytes, whatever its length.
+//
+// It is much smaller and faster than BasicMap for elements in a small range,
+// and iterates its elements in increasing order. Set operations between two
+// Bitset instances work a word of 64 elements at a time.
+//
+// Since it cannot store negative elements, Add panics when passed one,
+// and to bound its memory use, it also panics when passed one above MaxBitsetElement.
+//
+// It is not concurrency-safe.
+// Unlike BasicMap, its union/intersection/difference operations always return a new Bitset.
+type Bitset[E Integer] struct {
+	words []uint64
+}
+
+// String returns the set items in increasing order.
+func (s *Bitset[E]) String() string {
+	var b strings.Builder
+	b.WriteByte('{')
+	i := 0
+	for item := range s.Items() {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		_, _ = fmt.Fprintf(&b, "%v", item)
+		i++
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// Len returns the number of items in the Set, using a population count on each word.
+func (s *Bitset[E]) Len() int {
+	if s == nil {
+		return 0
+	}
+	n := 0
+	for _, w := range s.words {
+		n += bits.OnesCount64(w)
+	}
+	return n
+}
+
+// Add adds an item to the set. Returns true if the item was already present.
+//
+// It panics if the item is negative or above MaxBitsetElement.
+func (s *Bitset[E]) Add(item E) (found bool) {
+	if s == nil {
+		return false
+	}
+	if item < 0 {
+		panic(fmt.Errorf("negative bitset element %v", item))
+	}
+	if uint64(item) > MaxBitsetElement {
+		panic(fmt.Errorf("bitset element %v above MaxBitsetElement", item))
+	}
+	i, mask := position(item)
+	if i >= len(s.words) {
+		s.words = append(s.words, make([]uint64, i+1-len(s.words))...)
+	}
+	found = s.words[i]&mask != 0
+	s.words[i] |= mask
+	return found
+}
+
+// Remove removes an item from the set.
+// It does not fail if the item was not present, and returns true if it was.
+func (s *Bitset[E]) Remove(item E) (found bool) {
+	if !s.Contains(item) {
+		return false
+	}
+	i, mask := position(item)
+	s.words[i] &^= mask
+	return true
+}
+
+// Contains returns true if the item is present in the set.
+func (s *Bitset[E]) Contains(item E) bool {
+	if s == nil || item < 0 {
+		return false
+	}
+	i, mask := position(item)
+	return i < len(s.words) && s.words[i]&mask != 0
+}
+
+// Clear removes all items from the set and returns the number of items removed.
+//
+// It keeps the storage allocated for the current largest element.
+func (s *Bitset[E]) Clear() (count int) {
+	if s == nil {
+		return 0
+	}
+	count = s.Len()
+	clear(s.words)
+	return count
+}
+
+// Items returns an iterator over the set elements, in increasing order.
+func (s *Bitset[E]) Items() iter.Seq[E] {
+	return func(yield func(E) bool) {
+		if s == nil {
+			return
+		}
+		for i, w := range s.words {
+			for w != 0 {
+				if !yield(E(i*wordBits + bits.TrailingZeros64(w))) {
+					return
+				}
+				w &= w - 1 // Clear the lowest set bit.
+			}
+		}
+	}
+}
+
+// Min returns the smallest element in the set, or false if the set is empty.
+func (s *Bitset[E]) Min() (E, bool) {
+	return s.NextSet(0)
+}
+
+// Max returns the largest element in the set, or false if the set is empty.
+func (s *Bitset[E]) Max() (E, bool) {
+	if s == nil {
+		return 0, false
+	}
+	for i := len(s.words) - 1; i >= 0; i-- {
+		if w := s.words[i]; w != 0 {
+			return E(i*wordBits + wordBits - 1 - bits.LeadingZeros64(w)), true
+		}
+	}
+	return 0, false
+}
+
+// NextSet returns the smallest element in the set greater than or equal to from,
+// or false if there is none.
+func (s *Bitset[E]) NextSet(from E) (E, bool) {
+	if s == nil {
+		return 0, false
+	}
+	if from < 0 {
+		from = 0
+	}
+	i, mask := position(from)
+	if i >= len(s.words) {
+		return 0, false
+	}
+	// Ignore the bits below from in its word.
+	w := s.words[i] &^ (mask - 1)
+	for {
+		if w != 0 {
+			return E(i*wordBits + bits.TrailingZeros64(w)), true
+		}
+		i++
+		if i >= len(s.words) {
+			return 0, false
+		}
+		w = s.words[i]
+	}
+}
+
+// Union returns a new set containing elements present in either set.
+//
+// It panics if the other set contains negative elements.
+func (s *Bitset[E]) Union(other container.Set[E]) container.Set[E] {
+	res := s.clone()
+	if o, ok := other.(*Bitset[E]); ok {
+		if len(o.data()) > len(res.words) {
+			res.words = append(res.words, make([]uint64, len(o.data())-len(res.words))...)
+		}
+		for i, w := range o.data() {
+			res.words[i] |= w
+		}
+		return res
+	}
+	if other != nil {
+		for item := range other.Items() {
+			res.Add(item)
+		}
+	}
+	return res
+}
+
+// Intersection returns a new set containing elements present in both sets.
+func (s *Bitset[E]) Intersection(other container.Set[E]) container.Set[E] {
+	if o, ok := other.(*Bitset[E]); ok {
+		res := s.clone()
+		res.words = res.words[:min(len(res.words), len(o.data()))]
+		for i, w := range o.data() {
+			if i < len(res.words) {
+				res.words[i] &= w
+			}
+		}
+		return res
+	}
+	res := &Bitset[E]{}
+	if other != nil {
+		for item := range s.Items() {
+			if other.Contains(item) {
+				res.Add(item)
+			}
+		}
+	}
+	return res
+}
+
+// Difference returns a new set containing elements present in this set but not in the other.
+func (s *Bitset[E]) Difference(other container.Set[E]) container.Set[E] {
+	res := s.clone()
+	if o, ok := other.(*Bitset[E]); ok {
+		for i, w := range o.data() {
+			if i < len(res.words) {
+				res.words[i] &^= w
+			}
+		}
+		return res
+	}
+	if other != nil {
+		for item := range s.Items() {
+			if other.Contains(item) {
+				res.Remove(item)
+			}
+		}
+	}
+	return res
+}
+
+// SymmetricDifference returns a new set containing elements present in either set but not in both.
+//
+// It panics if the other set contains negative elements.
+func (s *Bitset[E]) SymmetricDifference(other container.Set[E]) container.Set[E] {
+	res := s.clone()
+	if o, ok := other.(*Bitset[E]); ok {
+		if len(o.data()) > len(res.words) {
+			res.words = append(res.words, make([]uint64, len(o.data())-len(res.words))...)
+		}
+		for i, w := range o.data() {
+			res.words[i] ^= w
+		}
+		return res
+	}
+	if other != nil {
+		for item := range other.Items() {
+			if !res.Remove(item) {
+				res.Add(item)
+			}
+		}
+	}
+	return res
+}
+
+// IsSubsetOf returns true if all elements in this set are present in the other.
+//
+// The empty set is a subset of any set, and nil sets are considered empty.
+func (s *Bitset[E]) IsSubsetOf(other container.Set[E]) bool {
+	if o, ok := other.(*Bitset[E]); ok {
+		return s.compare(o, func(mine, theirs uint64) bool { return mine&^theirs == 0 })
+	}
+	return isSubset(s, other)
+}
+
+// IsSupersetOf returns true if all elements in the other set are present in this one.
+func (s *Bitset[E]) IsSupersetOf(other container.Set[E]) bool {
+	if o, ok := other.(*Bitset[E]); ok {
+		return s.compare(o, func(mine, theirs uint64) bool { return theirs&^mine == 0 })
+	}
+	return isSuperset(s, other)
+}
+
+// IsProperSubsetOf returns true if this set is a subset of the other, and the
+// other contains at least one element not in this set.
+func (s *Bitset[E]) IsProperSubsetOf(other container.Set[E]) bool {
+	if o, ok := other.(*Bitset[E]); ok {
+		return s.IsSubsetOf(o) && !s.IsSupersetOf(o)
+	}
+	return isProperSubset(s, other)
+}
+
+// Equal returns true if both sets contain the same elements.
+func (s *Bitset[E]) Equal(other container.Set[E]) bool {
+	if o, ok := other.(*Bitset[E]); ok {
+		return s.compare(o, func(mine, theirs uint64) bool { return mine == theirs })
+	}
+	return equal(s, other)
+}
+
+// IsDisjoint returns true if the sets have no element in common.
+func (s *Bitset[E]) IsDisjoint(other container.Set[E]) bool {
+	if o, ok := other.(*Bitset[E]); ok {
+		return s.compare(o, func(mine, theirs uint64) bool { return mine&theirs == 0 })
+	}
+	return isDisjoint(s, other)
+}
+
+// data returns the words of the set, which may be nil.
+func (s *Bitset[E]) data() []uint64 {
+	if s == nil {
+		return nil
+	}
+	return s.words
+}
+
+// clone returns a copy of the set. A nil receiver returns an empty set.
+func (s *Bitset[E]) clone() *Bitset[E] {
+	return &Bitset[E]{words: slices.Clone(s.data())}
+}
+
+// compare returns true if the predicate holds for each pair of words of the sets,
+// missing words being 0.
+func (s *Bitset[E]) compare(other *Bitset[E], pred func(mine, theirs uint64) bool) bool {
+	mine, theirs := s.data(), other.data()
+	for i := range max(len(mine), len(theirs)) {
+		var m, t uint64
+		if i < len(mine) {
+			m = mine[i]
+		}
+		if i < len(theirs) {
+			t = theirs[i]
+		}
+		if !pred(m, t) {
+			return false
+		}
+	}
+	return true
+}
+
+// position returns the index of the word holding the item bit, and the mask of that bit.
+func position[E Integer](item E) (int, uint64) {
+	u := uint64(item)
+	return int(u / wordBits), 1 << (u % wordBits)
+}
+
+// NewBitset returns a ready-for-use container.Set implemented by the Bitset type,
+// with storage allocated for elements up to sizeHint.
+func NewBitset[E Integer](sizeHint E) container.Set[E] {
+	if sizeHint < 0 {
+		sizeHint = 0
+	}
+	return &Bitset[E]{words: make([]uint64, 0, int(min(uint64(sizeHint), MaxBitsetElement)/wordBits)+1)}
+}
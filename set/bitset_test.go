@@ -0,0 +1,265 @@
+package set_test
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/fgm/container"
+	"github.com/fgm/container/set"
+)
+
+// createBitset creates a Bitset holding the elements.
+func createBitset(tb testing.TB, elements ...uint16) *set.Bitset[uint16] {
+	tb.Helper()
+	s, ok := set.NewBitset[uint16](0).(*set.Bitset[uint16])
+	if !ok {
+		tb.Fatalf("expected a *set.Bitset")
+	}
+	for _, e := range elements {
+		s.Add(e)
+	}
+	return s
+}
+
+func TestBitset(t *testing.T) {
+	var ns *set.Bitset[int]
+	if ns.Len() != 0 || ns.Contains(1) || ns.Add(1) || ns.Remove(1) || ns.Clear() != 0 || ns.String() != "{}" {
+		t.Errorf("nil set should be empty and ignore changes")
+	}
+	if _, ok := ns.Min(); ok {
+		t.Errorf("nil set should have no minimum")
+	}
+
+	s := set.NewBitset(100).(*set.Bitset[int])
+	for _, e := range []int{130, 3, 64, 63, 3} {
+		s.Add(e)
+	}
+	if actual := slices.Collect(s.Items()); !slices.Equal(actual, []int{3, 63, 64, 130}) {
+		t.Errorf("got %v but expected ordered [3 63 64 130]", actual)
+	}
+	if s.String() != "{3, 63, 64, 130}" || s.Len() != 4 {
+		t.Errorf("got %v but expected {3, 63, 64, 130}", s)
+	}
+	if s.Contains(-1) || s.Contains(1000) || s.Remove(-1) {
+		t.Errorf("out of range elements should not be found")
+	}
+	if !s.Remove(130) || s.Remove(130) {
+		t.Errorf("Remove should only return true for existing elements")
+	}
+	if s.Clear() != 3 || s.Len() != 0 {
+		t.Errorf("Clear should remove all elements")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("adding a negative element should panic")
+		}
+	}()
+	s.Add(-1)
+}
+
+func TestBitset_MaxElement(t *testing.T) {
+	s := set.NewBitset[uint64](0).(*set.Bitset[uint64])
+	for _, item := range []uint64{set.MaxBitsetElement + 1, math.MaxUint64} {
+		if s.Contains(item) || s.Remove(item) {
+			t.Errorf("elements above MaxBitsetElement should never be found")
+		}
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("adding %d should panic", item)
+				}
+			}()
+			s.Add(item)
+		}()
+	}
+	if s.Len() != 0 {
+		t.Errorf("got %d elements but expected 0", s.Len())
+	}
+}
+
+func TestBitset_Navigation(t *testing.T) {
+	s := set.NewBitset[int8](0).(*set.Bitset[int8])
+	if _, ok := s.Max(); ok {
+		t.Errorf("empty set should have no maximum")
+	}
+	for _, e := range []int8{5, 64, 127} {
+		s.Add(e)
+	}
+	if m, ok := s.Min(); !ok || m != 5 {
+		t.Errorf("got minimum %d but expected 5", m)
+	}
+	if m, ok := s.Max(); !ok || m != 127 {
+		t.Errorf("got maximum %d but expected 127", m)
+	}
+	tests := [...]struct {
+		from     int8
+		expected int8
+		ok       bool
+	}{
+		{-10, 5, true},
+		{5, 5, true},
+		{6, 64, true},
+		{65, 127, true},
+		{127, 127, true},
+	}
+	for _, test := range tests {
+		if actual, ok := s.NextSet(test.from); actual != test.expected || ok != test.ok {
+			t.Errorf("NextSet(%d): got %d, %t but expected %d, %t", test.from, actual, ok, test.expected, test.ok)
+		}
+	}
+	s.Remove(127)
+	if _, ok := s.NextSet(65); ok {
+		t.Errorf("NextSet after the maximum should not find anything")
+	}
+}
+
+func TestBitset_SetOperations(t *testing.T) {
+	a, b := createBitset(t, 1, 2, 200), createBitset(t, 2, 3)
+	basic := set.NewBasicMap[uint16](2)
+	basic.Add(2)
+	basic.Add(3)
+	tests := [...]struct {
+		name     string
+		actual   container.Set[uint16]
+		expected []uint16
+	}{
+		{"union", a.Union(b), []uint16{1, 2, 3, 200}},
+		{"union shorter", b.Union(a), []uint16{1, 2, 3, 200}},
+		{"union with basic", a.Union(basic), []uint16{1, 2, 3, 200}},
+		{"union with nil", a.Union(nil), []uint16{1, 2, 200}},
+		{"intersection", a.Intersection(b), []uint16{2}},
+		{"intersection shorter", b.Intersection(a), []uint16{2}},
+		{"intersection with basic", a.Intersection(basic), []uint16{2}},
+		{"intersection with nil", a.Intersection(nil), nil},
+		{"difference", a.Difference(b), []uint16{1, 200}},
+		{"difference shorter", b.Difference(a), []uint16{3}},
+		{"difference with basic", a.Difference(basic), []uint16{1, 200}},
+		{"symmetric difference", a.SymmetricDifference(b), []uint16{1, 3, 200}},
+		{"symmetric difference shorter", b.SymmetricDifference(a), []uint16{1, 3, 200}},
+		{"symmetric difference with basic", a.SymmetricDifference(basic), []uint16{1, 3, 200}},
+		{"of nil", (*set.Bitset[uint16])(nil).Union(b), []uint16{2, 3}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bs, ok := test.actual.(*set.Bitset[uint16])
+			if !ok {
+				t.Fatalf("got %T but expected a new bitset", test.actual)
+			}
+			if bs == a || bs == b {
+				t.Errorf("operations should not return an operand")
+			}
+			if actual := slices.Collect(bs.Items()); !slices.Equal(actual, test.expected) {
+				t.Errorf("got %v but expected %v", actual, test.expected)
+			}
+		})
+	}
+	if a.Len() != 3 || b.Len() != 2 {
+		t.Errorf("operands should not be modified")
+	}
+}
+
+func TestBitset_Predicates(t *testing.T) {
+	small, large := createBitset(t, 1, 2), createBitset(t, 1, 2, 300)
+	trimmed := createBitset(t, 1, 2, 300)
+	trimmed.Remove(300) // Same elements as small, with more words.
+	basic := set.NewBasicMap[uint16](3)
+	for _, e := range []uint16{1, 2, 3} {
+		basic.Add(e)
+	}
+	tests := [...]struct {
+		name                                      string
+		s, other                                  container.Set[uint16]
+		subset, superset, proper, equal, disjoint bool
+	}{
+		{"proper subset", small, large, true, false, true, false, false},
+		{"proper superset", large, small, false, true, false, false, false},
+		{"equal with trailing words", small, trimmed, true, true, false, true, false},
+		{"equal with trailing words reversed", trimmed, small, true, true, false, true, false},
+		{"disjoint", small, createBitset(t, 3, 400), false, false, false, false, true},
+		{"with basic", small, basic, true, false, true, false, false},
+		{"with nil", small, nil, false, true, false, false, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := test.s.IsSubsetOf(test.other); actual != test.subset {
+				t.Errorf("IsSubsetOf: got %t but expected %t", actual, test.subset)
+			}
+			if actual := test.s.IsSupersetOf(test.other); actual != test.superset {
+				t.Errorf("IsSupersetOf: got %t but expected %t", actual, test.superset)
+			}
+			if actual := test.s.IsProperSubsetOf(test.other); actual != test.proper {
+				t.Errorf("IsProperSubsetOf: got %t but expected %t", actual, test.proper)
+			}
+			if actual := test.s.Equal(test.other); actual != test.equal {
+				t.Errorf("Equal: got %t but expected %t", actual, test.equal)
+			}
+			if actual := test.s.IsDisjoint(test.other); actual != test.disjoint {
+				t.Errorf("IsDisjoint: got %t but expected %t", actual, test.disjoint)
+			}
+		})
+	}
+}
+
+func BenchmarkBitset_Intersection(b *testing.B) {
+	const size = 1 << 12
+	bs1, bs2 := createBitset(b), createBitset(b)
+	m1, m2 := set.NewBasicMap[uint16](size), set.NewBasicMap[uint16](size)
+	for i := range uint16(size) {
+		bs1.Add(i)
+		m1.Add(i)
+		if i%3 == 0 {
+			bs2.Add(i)
+			m2.Add(i)
+		}
+	}
+	b.Run("bitset", func(b *testing.B) {
+		for range b.N {
+			_ = bs1.Intersection(bs2)
+		}
+	})
+	b.Run("map", func(b *testing.B) {
+		for range b.N {
+			_ = m1.Intersection(m2)
+		}
+	})
+}
+
+func FuzzBitset(f *testing.F) {
+	// Add some seed corpus
+	f.Add([]byte{1, 2, 3}, []byte{3, 4})       // Overlapping
+	f.Add([]byte{}, []byte{255})               // Empty receiver
+	f.Add([]byte{0, 63, 64, 65}, []byte{0, 0}) // Word boundaries
+
+	f.Fuzz(func(t *testing.T, left, right []byte) {
+		// Compare the word-at-a-time operations with BasicMap.
+		b1, b2 := set.NewBitset[byte](0), set.NewBitset[byte](0)
+		m1, m2 := set.NewBasicMap[byte](len(left)), set.NewBasicMap[byte](len(right))
+		for _, e := range left {
+			b1.Add(e)
+			m1.Add(e)
+		}
+		for _, e := range right {
+			b2.Add(e)
+			m2.Add(e)
+		}
+		ops := []struct {
+			name             string
+			actual, expected container.Set[byte]
+		}{
+			{"Union", b1.Union(b2), m1.Union(m2)},
+			{"Intersection", b1.Intersection(b2), m1.Intersection(m2)},
+			{"Difference", b1.Difference(b2), m1.Difference(m2)},
+			{"SymmetricDifference", b1.SymmetricDifference(b2), m1.SymmetricDifference(m2)},
+		}
+		for _, op := range ops {
+			if !op.actual.Equal(op.expected) {
+				t.Errorf("%s: got %v but expected %v", op.name, op.actual, op.expected)
+			}
+		}
+		if b1.IsSubsetOf(b2) != m1.IsSubsetOf(m2) || b1.IsDisjoint(b2) != m1.IsDisjoint(m2) || b1.Equal(b2) != m1.Equal(m2) {
+			t.Errorf("predicates do not match BasicMap")
+		}
+	})
+}
@@ -0,0 +1,395 @@
+package set
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"iter"
+	"math"
+	"math/bits"
+)
+
+// The binary format of Bloom filters is, with all integers little-endian:
+//
+//   - The format, bloomFormat or countingBloomFormat, as 4 bytes.
+//   - The number of hash functions, as 32 bits.
+//   - The number of bits or counters, as 64 bits.
+//   - For Bloom, the bits, as 64 bits words. The bits beyond the size of the filter are zero.
+//   - For CountingBloom, the counters, as 8 bits each.
+//
+// Since filters only contain hashes, the format depends on stableHash.
+
+const (
+	bloomFormat         = "BLM1"
+	countingBloomFormat = "CBF1"
+
+	bloomSalt       = 0x9e3779b97f4a7c15 // Derives the second hash of an item from the first one
+	maxBloomHashes  = 64
+	maxBloomCounter = math.MaxUint8
+)
+
+var (
+	ErrExpectedItemsIsNotPositive    = errors.New("container: expected items must be positive")
+	ErrFalsePositiveRateIsOutOfRange = errors.New("container: false positive rate must be between 0 and 1")
+	ErrIncompatibleFilters           = errors.New("container: filters have different parameters")
+	ErrInvalidBloomFormat            = errors.New("container: invalid Bloom filter format")
+)
+
+// Bloom is a Bloom filter: a probabilistic set which may report items as present
+// while they were never added, at a configurable false positive rate,
+// but never reports added items as absent.
+//
+// It only stores a fixed number of bits, regardless of the number of items,
+// making it suitable to avoid most lookups of absent items in a large remote dataset.
+// It cannot remove items: use CountingBloom for that.
+//
+// Its MarshalBinary and UnmarshalBinary methods allow building filters in
+// advance, and loading them in another process.
+//
+// It is not concurrency-safe.
+type Bloom[K Key] struct {
+	shape bloomShape
+	words []uint64
+}
+
+// Add adds an item to the filter.
+// Returns true if the item was probably already present.
+func (f *Bloom[K]) Add(item K) (found bool) {
+	if f == nil {
+		return false
+	}
+	found = true
+	for loc := range f.shape.locations(stableHash(item)) {
+		word, mask := loc/wordBits, uint64(1)<<(loc%wordBits)
+		if f.words[word]&mask == 0 {
+			found = false
+			f.words[word] |= mask
+		}
+	}
+	return found
+}
+
+// Contains returns true if the item is probably present in the filter,
+// and false if it is certainly absent.
+func (f *Bloom[K]) Contains(item K) bool {
+	if f == nil {
+		return false
+	}
+	for loc := range f.shape.locations(stableHash(item)) {
+		if f.words[loc/wordBits]&(1<<(loc%wordBits)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Clear removes all items from the filter.
+func (f *Bloom[K]) Clear() {
+	if f == nil {
+		return
+	}
+	clear(f.words)
+}
+
+// EstimatedLen returns an estimate of the number of distinct items added to the filter.
+func (f *Bloom[K]) EstimatedLen() int {
+	if f == nil {
+		return 0
+	}
+	set := 0
+	for _, w := range f.words {
+		set += bits.OnesCount64(w)
+	}
+	return f.shape.estimate(set)
+}
+
+// Union returns a new filter containing the items added to either filter.
+//
+// It returns ErrIncompatibleFilters unless both filters were created with the same parameters.
+func (f *Bloom[K]) Union(other *Bloom[K]) (*Bloom[K], error) {
+	return f.combine(other, opOr)
+}
+
+// Intersection returns a new filter containing the items added to both filters.
+//
+// Its false positive rate is higher than the one of a filter containing only these items,
+// since bits set by distinct items in each filter may collide.
+// It returns ErrIncompatibleFilters unless both filters were created with the same parameters.
+func (f *Bloom[K]) Intersection(other *Bloom[K]) (*Bloom[K], error) {
+	return f.combine(other, opAnd)
+}
+
+func (f *Bloom[K]) combine(other *Bloom[K], op setOp) (*Bloom[K], error) {
+	if f == nil || other == nil || f.shape != other.shape {
+		return nil, ErrIncompatibleFilters
+	}
+	res := &Bloom[K]{shape: f.shape, words: make([]uint64, len(f.words))}
+	for i, w := range f.words {
+		res.words[i] = op.apply(w, other.words[i])
+	}
+	return res, nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (f *Bloom[K]) MarshalBinary() ([]byte, error) {
+	if f == nil {
+		return nil, fmt.Errorf("%w: nil filter", ErrInvalidBloomFormat)
+	}
+	buf := f.shape.appendHeader(make([]byte, 0, bloomHeaderSize+8*len(f.words)), bloomFormat)
+	for _, w := range f.words {
+		buf = binary.LittleEndian.AppendUint64(buf, w)
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, replacing the contents and parameters of the filter.
+//
+// Invalid data returns an error wrapping ErrInvalidBloomFormat, and leaves the filter unchanged.
+func (f *Bloom[K]) UnmarshalBinary(data []byte) error {
+	d := decoder{data: data, invalid: ErrInvalidBloomFormat}
+	shape, err := decodeBloomShape(&d, bloomFormat, 8)
+	if err != nil {
+		return err
+	}
+	res := &Bloom[K]{shape: shape, words: make([]uint64, (shape.m+wordBits-1)/wordBits)}
+	for i := range res.words {
+		res.words[i] = d.uint64()
+	}
+	if err := d.end(); err != nil {
+		return err
+	}
+	if extra := shape.m % wordBits; extra != 0 && res.words[len(res.words)-1]>>extra != 0 {
+		return fmt.Errorf("%w: bits set beyond the filter size", ErrInvalidBloomFormat)
+	}
+	*f = *res
+	return nil
+}
+
+// NewBloom returns an empty Bloom filter, sized for the expected number of
+// distinct items to have at most the given false positive rate.
+//
+// Adding more items increases the false positive rate.
+func NewBloom[K Key](expectedItems int, falsePositiveRate float64) (*Bloom[K], error) {
+	shape, err := newBloomShape(expectedItems, falsePositiveRate)
+	if err != nil {
+		return nil, err
+	}
+	return &Bloom[K]{shape: shape, words: make([]uint64, (shape.m+wordBits-1)/wordBits)}, nil
+}
+
+// CountingBloom is a Bloom filter supporting the removal of items,
+// by storing an 8 bits counter instead of each bit, making it 8 times larger.
+//
+// Removing an item which was not added may remove other items, so only items
+// known to have been added should be removed.
+// Counters reaching 255 stay at that value, so a few items may never be removed.
+//
+// Its MarshalBinary and UnmarshalBinary methods allow building filters in
+// advance, and loading them in another process.
+//
+// It is not concurrency-safe.
+type CountingBloom[K Key] struct {
+	shape    bloomShape
+	counters []uint8
+}
+
+// Add adds an item to the filter.
+// Returns true if the item was probably already present.
+func (f *CountingBloom[K]) Add(item K) (found bool) {
+	if f == nil {
+		return false
+	}
+	found = f.Contains(item)
+	for loc := range f.shape.locations(stableHash(item)) {
+		if f.counters[loc] < maxBloomCounter {
+			f.counters[loc]++
+		}
+	}
+	return found
+}
+
+// Remove removes an item from the filter.
+// Returns true if the item was probably present, false if it was certainly absent.
+func (f *CountingBloom[K]) Remove(item K) (found bool) {
+	if !f.Contains(item) {
+		return false
+	}
+	for loc := range f.shape.locations(stableHash(item)) {
+		if c := f.counters[loc]; c > 0 && c < maxBloomCounter {
+			f.counters[loc]--
+		}
+	}
+	return true
+}
+
+// Contains returns true if the item is probably present in the filter,
+// and false if it is certainly absent.
+func (f *CountingBloom[K]) Contains(item K) bool {
+	if f == nil {
+		return false
+	}
+	for loc := range f.shape.locations(stableHash(item)) {
+		if f.counters[loc] == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Clear removes all items from the filter.
+func (f *CountingBloom[K]) Clear() {
+	if f == nil {
+		return
+	}
+	clear(f.counters)
+}
+
+// EstimatedLen returns an estimate of the number of distinct items in the filter.
+func (f *CountingBloom[K]) EstimatedLen() int {
+	if f == nil {
+		return 0
+	}
+	set := 0
+	for _, c := range f.counters {
+		if c != 0 {
+			set++
+		}
+	}
+	return f.shape.estimate(set)
+}
+
+// Union returns a new filter containing the items in either filter,
+// adding their counters, so that items in both filters must be removed twice.
+//
+// It returns ErrIncompatibleFilters unless both filters were created with the same parameters.
+func (f *CountingBloom[K]) Union(other *CountingBloom[K]) (*CountingBloom[K], error) {
+	return f.combine(other, func(a, b uint8) uint8 { return uint8(min(int(a)+int(b), maxBloomCounter)) })
+}
+
+// Intersection returns a new filter containing the items in both filters,
+// keeping the minimum of their counters.
+//
+// Like for Bloom, its false positive rate is higher than the one of a filter containing only these items.
+// It returns ErrIncompatibleFilters unless both filters were created with the same parameters.
+func (f *CountingBloom[K]) Intersection(other *CountingBloom[K]) (*CountingBloom[K], error) {
+	return f.combine(other, func(a, b uint8) uint8 { return min(a, b) })
+}
+
+func (f *CountingBloom[K]) combine(other *CountingBloom[K], op func(a, b uint8) uint8) (*CountingBloom[K], error) {
+	if f == nil || other == nil || f.shape != other.shape {
+		return nil, ErrIncompatibleFilters
+	}
+	res := &CountingBloom[K]{shape: f.shape, counters: make([]uint8, len(f.counters))}
+	for i, c := range f.counters {
+		res.counters[i] = op(c, other.counters[i])
+	}
+	return res, nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (f *CountingBloom[K]) MarshalBinary() ([]byte, error) {
+	if f == nil {
+		return nil, fmt.Errorf("%w: nil filter", ErrInvalidBloomFormat)
+	}
+	buf := f.shape.appendHeader(make([]byte, 0, bloomHeaderSize+len(f.counters)), countingBloomFormat)
+	return append(buf, f.counters...), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, replacing the contents and parameters of the filter.
+//
+// Invalid data returns an error wrapping ErrInvalidBloomFormat, and leaves the filter unchanged.
+func (f *CountingBloom[K]) UnmarshalBinary(data []byte) error {
+	d := decoder{data: data, invalid: ErrInvalidBloomFormat}
+	shape, err := decodeBloomShape(&d, countingBloomFormat, 1)
+	if err != nil {
+		return err
+	}
+	counters := d.bytes(int(shape.m))
+	if err := d.end(); err != nil {
+		return err
+	}
+	f.shape, f.counters = shape, append([]uint8(nil), counters...)
+	return nil
+}
+
+// NewCountingBloom returns an empty counting Bloom filter, sized for the
+// expected number of distinct items to have at most the given false positive rate.
+//
+// Adding more items increases the false positive rate.
+func NewCountingBloom[K Key](expectedItems int, falsePositiveRate float64) (*CountingBloom[K], error) {
+	shape, err := newBloomShape(expectedItems, falsePositiveRate)
+	if err != nil {
+		return nil, err
+	}
+	return &CountingBloom[K]{shape: shape, counters: make([]uint8, shape.m)}, nil
+}
+
+// bloomHeaderSize is the size of the format, the number of hash functions, and the filter size.
+const bloomHeaderSize = 4 + 4 + 8
+
+// bloomShape holds the parameters of a Bloom filter, common to its variants.
+type bloomShape struct {
+	m uint64 // Number of bits or counters
+	k uint32 // Number of hash functions
+}
+
+// newBloomShape returns the optimal parameters for the number of items and false positive rate.
+func newBloomShape(expectedItems int, falsePositiveRate float64) (bloomShape, error) {
+	if expectedItems <= 0 {
+		return bloomShape{}, fmt.Errorf("%w: got %d", ErrExpectedItemsIsNotPositive, expectedItems)
+	}
+	if !(falsePositiveRate > 0 && falsePositiveRate < 1) {
+		return bloomShape{}, fmt.Errorf("%w: got %v", ErrFalsePositiveRateIsOutOfRange, falsePositiveRate)
+	}
+	n := float64(expectedItems)
+	m := math.Ceil(-n * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2))
+	k := math.Round(m / n * math.Ln2)
+	return bloomShape{m: uint64(m), k: uint32(min(max(k, 1), maxBloomHashes))}, nil
+}
+
+// locations returns an iterator over the k bit or counter indexes of an item, from its stableHash.
+//
+// It uses double hashing, deriving them from two hashes as h1 + i*h2,
+// which is as accurate as k independent hash functions.
+func (s bloomShape) locations(h uint64) iter.Seq[uint64] {
+	return func(yield func(uint64) bool) {
+		h1, h2 := h, mix64(h^bloomSalt)|1
+		for i := range uint64(s.k) {
+			if !yield((h1 + i*h2) % s.m) {
+				return
+			}
+		}
+	}
+}
+
+// estimate returns the estimated number of items in a filter with the given number of non-zero bits or counters,
+// using the Swamidass and Baldi formula. A saturated filter returns the largest estimate it can.
+func (s bloomShape) estimate(set int) int {
+	x := min(float64(set), float64(s.m)-1)
+	return int(math.Round(-float64(s.m) / float64(s.k) * math.Log1p(-x/float64(s.m))))
+}
+
+func (s bloomShape) appendHeader(buf []byte, format string) []byte {
+	buf = append(buf, format...)
+	buf = binary.LittleEndian.AppendUint32(buf, s.k)
+	return binary.LittleEndian.AppendUint64(buf, s.m)
+}
+
+// decodeBloomShape decodes the header of a filter in the given format, checking that
+// the remaining data holds enough bytes for the filter size.
+func decodeBloomShape(d *decoder, format string, locationsPerByte uint64) (bloomShape, error) {
+	if f := d.bytes(len(format)); d.err == nil && string(f) != format {
+		return bloomShape{}, fmt.Errorf("%w: unknown format %q", ErrInvalidBloomFormat, f)
+	}
+	s := bloomShape{k: d.uint32(), m: d.uint64()}
+	if d.err != nil {
+		return bloomShape{}, d.err
+	}
+	if s.k == 0 || s.k > maxBloomHashes {
+		return bloomShape{}, fmt.Errorf("%w: %d hash functions", ErrInvalidBloomFormat, s.k)
+	}
+	if s.m == 0 || s.m > uint64(len(d.data))*locationsPerByte {
+		return bloomShape{}, fmt.Errorf("%w: size %d does not match the data", ErrInvalidBloomFormat, s.m)
+	}
+	return s, nil
+}
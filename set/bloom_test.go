@@ -0,0 +1,296 @@
+package set_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/fgm/container/set"
+)
+
+func TestNewBloom(t *testing.T) {
+	tests := [...]struct {
+		name     string
+		items    int
+		rate     float64
+		expected error
+	}{
+		{"valid", 100, 0.01, nil},
+		{"tiny rate", 1, 1e-30, nil},
+		{"zero items", 0, 0.01, set.ErrExpectedItemsIsNotPositive},
+		{"negative items", -1, 0.01, set.ErrExpectedItemsIsNotPositive},
+		{"zero rate", 100, 0, set.ErrFalsePositiveRateIsOutOfRange},
+		{"rate of 1", 100, 1, set.ErrFalsePositiveRateIsOutOfRange},
+		{"NaN rate", 100, math.NaN(), set.ErrFalsePositiveRateIsOutOfRange},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := set.NewBloom[string](test.items, test.rate); !errors.Is(err, test.expected) {
+				t.Errorf("got %v but expected %v", err, test.expected)
+			}
+			if _, err := set.NewCountingBloom[string](test.items, test.rate); !errors.Is(err, test.expected) {
+				t.Errorf("got %v but expected %v", err, test.expected)
+			}
+		})
+	}
+}
+
+func TestBloom(t *testing.T) {
+	var nf *set.Bloom[string]
+	if nf.Add("a") || nf.Contains("a") || nf.EstimatedLen() != 0 {
+		t.Errorf("nil filter should be empty and ignore changes")
+	}
+	nf.Clear()
+
+	const n, rate = 10_000, 0.01
+	f, _ := set.NewBloom[string](n, rate)
+	for i := range n {
+		if f.Add(strconv.Itoa(i)) && i < 10 {
+			t.Errorf("item %d reported as present before being added", i)
+		}
+	}
+	if !f.Add("0") {
+		t.Errorf("Add should return true for existing items")
+	}
+	positives := 0
+	for i := range n {
+		if !f.Contains(strconv.Itoa(i)) {
+			t.Fatalf("false negative for %d", i)
+		}
+		if f.Contains(strconv.Itoa(n + i)) {
+			positives++
+		}
+	}
+	if actual := float64(positives) / n; actual > 2*rate {
+		t.Errorf("got false positive rate %f but expected about %f", actual, rate)
+	}
+	if actual := f.EstimatedLen(); math.Abs(float64(actual-n)) > n/20 {
+		t.Errorf("got estimated length %d but expected about %d", actual, n)
+	}
+	f.Clear()
+	if f.Contains("0") || f.EstimatedLen() != 0 {
+		t.Errorf("Clear should remove all items")
+	}
+}
+
+func TestBloom_Operations(t *testing.T) {
+	a, _ := set.NewBloom[string](100, 0.001)
+	b, _ := set.NewBloom[string](100, 0.001)
+	for i := range 50 {
+		a.Add(strconv.Itoa(i))
+		b.Add(strconv.Itoa(i + 25))
+	}
+	union, err := a.Union(b)
+	if err != nil {
+		t.Fatalf("failed union: %v", err)
+	}
+	inter, err := a.Intersection(b)
+	if err != nil {
+		t.Fatalf("failed intersection: %v", err)
+	}
+	for i := range 75 {
+		if item := strconv.Itoa(i); !union.Contains(item) {
+			t.Errorf("union should contain %s", item)
+		}
+	}
+	for i := 25; i < 50; i++ {
+		if item := strconv.Itoa(i); !inter.Contains(item) {
+			t.Errorf("intersection should contain %s", item)
+		}
+	}
+	if actual := union.EstimatedLen(); actual < 70 || actual > 80 {
+		t.Errorf("got estimated union length %d but expected about 75", actual)
+	}
+	if union == a || inter == a || a.EstimatedLen() > 55 {
+		t.Errorf("operations should not modify their operands")
+	}
+
+	other, _ := set.NewBloom[string](100, 0.01)
+	for _, c := range [...]*set.Bloom[string]{other, nil} {
+		if _, err := a.Union(c); !errors.Is(err, set.ErrIncompatibleFilters) {
+			t.Errorf("got %v but expected %v", err, set.ErrIncompatibleFilters)
+		}
+		if _, err := a.Intersection(c); !errors.Is(err, set.ErrIncompatibleFilters) {
+			t.Errorf("got %v but expected %v", err, set.ErrIncompatibleFilters)
+		}
+	}
+}
+
+func TestCountingBloom(t *testing.T) {
+	var nf *set.CountingBloom[[]byte]
+	if nf.Add([]byte("a")) || nf.Contains([]byte("a")) || nf.Remove([]byte("a")) || nf.EstimatedLen() != 0 {
+		t.Errorf("nil filter should be empty and ignore changes")
+	}
+	nf.Clear()
+
+	f, _ := set.NewCountingBloom[[]byte](100, 0.001)
+	for i := range 100 {
+		f.Add([]byte(strconv.Itoa(i)))
+	}
+	if !f.Add([]byte("0")) {
+		t.Errorf("Add should return true for existing items")
+	}
+	// "0" was added twice, so it needs two removals.
+	if !f.Remove([]byte("0")) || !f.Contains([]byte("0")) || !f.Remove([]byte("0")) || f.Contains([]byte("0")) {
+		t.Errorf("Remove should remove one occurrence")
+	}
+	for i := 1; i < 50; i++ {
+		if !f.Remove([]byte(strconv.Itoa(i))) {
+			t.Errorf("Remove should return true for existing item %d", i)
+		}
+	}
+	for i := 50; i < 100; i++ {
+		if !f.Contains([]byte(strconv.Itoa(i))) {
+			t.Fatalf("false negative for %d after removals", i)
+		}
+	}
+	if actual := f.EstimatedLen(); actual < 45 || actual > 55 {
+		t.Errorf("got estimated length %d but expected about 50", actual)
+	}
+
+	// Saturated counters are never decremented, to avoid false negatives.
+	for range 300 {
+		f.Add([]byte("hot"))
+	}
+	for range 300 {
+		f.Remove([]byte("hot"))
+	}
+	if !f.Contains([]byte("hot")) {
+		t.Errorf("items with saturated counters should not be removed")
+	}
+	f.Clear()
+	if f.Contains([]byte("50")) || f.EstimatedLen() != 0 {
+		t.Errorf("Clear should remove all items")
+	}
+}
+
+func TestCountingBloom_Operations(t *testing.T) {
+	a, _ := set.NewCountingBloom[string](100, 0.001)
+	b, _ := set.NewCountingBloom[string](100, 0.001)
+	a.Add("both")
+	a.Add("a")
+	b.Add("both")
+	b.Add("b")
+	union, _ := a.Union(b)
+	if !union.Contains("a") || !union.Contains("b") || !union.Remove("both") || !union.Contains("both") {
+		t.Errorf("union should add the counters")
+	}
+	inter, _ := a.Intersection(b)
+	if inter.Contains("a") || inter.Contains("b") || !inter.Remove("both") || inter.Contains("both") {
+		t.Errorf("intersection should keep the minimum counters")
+	}
+	other, _ := set.NewCountingBloom[string](1000, 0.001)
+	if _, err := a.Union(other); !errors.Is(err, set.ErrIncompatibleFilters) {
+		t.Errorf("got %v but expected %v", err, set.ErrIncompatibleFilters)
+	}
+	if _, err := a.Intersection(nil); !errors.Is(err, set.ErrIncompatibleFilters) {
+		t.Errorf("got %v but expected %v", err, set.ErrIncompatibleFilters)
+	}
+}
+
+func TestBloom_Format(t *testing.T) {
+	// The format must not change, for filters to be loaded by other versions.
+	f, _ := set.NewBloom[string](4, 0.1)
+	for _, item := range []string{"a", "b", "c", "d"} {
+		f.Add(item)
+	}
+	data, err := f.MarshalBinary()
+	if err != nil {
+		t.Fatalf("failed marshaling: %v", err)
+	}
+	const expected = "424c4d310300000014000000000000001ea6050000000000"
+	if actual := hex.EncodeToString(data); actual != expected {
+		t.Errorf("got %s but expected %s", actual, expected)
+	}
+
+	g := &set.Bloom[string]{}
+	if err := g.UnmarshalBinary(data); err != nil {
+		t.Fatalf("failed unmarshaling: %v", err)
+	}
+	if !g.Contains("a") || !g.Contains("d") {
+		t.Errorf("unmarshaled filter should contain the same items")
+	}
+	if other, err := g.MarshalBinary(); err != nil || !bytes.Equal(other, data) {
+		t.Errorf("got %x but expected %x", other, data)
+	}
+
+	c, _ := set.NewCountingBloom[string](4, 0.1)
+	c.Add("a")
+	data, _ = c.MarshalBinary()
+	d := &set.CountingBloom[string]{}
+	if err := d.UnmarshalBinary(data); err != nil || !d.Contains("a") || !d.Remove("a") || d.Contains("a") {
+		t.Errorf("unmarshaled filter should contain the same items: %v", err)
+	}
+	if _, err := (*set.Bloom[string])(nil).MarshalBinary(); err == nil {
+		t.Errorf("marshaling a nil filter should fail")
+	}
+}
+
+func TestBloom_UnmarshalInvalid(t *testing.T) {
+	valid, _ := hex.DecodeString("424c4d310300000014000000000000001ea6050000000000")
+	tests := [...]struct {
+		name string
+		data string
+	}{
+		{"empty", ""},
+		{"unknown format", "58584d310300000014000000000000001ea6050000000000"},
+		{"counting format", "434246310300000014000000000000001ea6050000000000"},
+		{"no hash function", "424c4d310000000014000000000000001ea6050000000000"},
+		{"too many hash functions", "424c4d314100000014000000000000001ea6050000000000"},
+		{"zero size", "424c4d310300000000000000000000001ea6050000000000"},
+		{"size too large", "424c4d31030000004100000000000000ffffffffffffffff"},
+		{"huge size", "424c4d310300000000000000000000801ea6050000000000"},
+		{"bits beyond size", "424c4d310300000014000000000000001ea6150000000000"},
+		{"truncated", "424c4d310300000014000000000000001ea60500000000"},
+		{"trailing data", "424c4d310300000014000000000000001ea605000000000000"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, _ := hex.DecodeString(test.data)
+			f := &set.Bloom[string]{}
+			if err := f.UnmarshalBinary(valid); err != nil {
+				t.Fatalf("failed unmarshaling valid data: %v", err)
+			}
+			if err := f.UnmarshalBinary(data); !errors.Is(err, set.ErrInvalidBloomFormat) {
+				t.Errorf("got %v but expected %v", err, set.ErrInvalidBloomFormat)
+			}
+			if !f.Contains("a") {
+				t.Errorf("failed unmarshaling should not modify the filter")
+			}
+		})
+	}
+}
+
+func FuzzBloomUnmarshal(f *testing.F) {
+	// Add some seed corpus
+	for _, seed := range []string{
+		"424c4d310300000014000000000000001ea6050000000000", // Bloom
+		"4342463101000000030000000000000001ff00",           // CountingBloom
+		"424c4d31",                                         // Truncated
+	} {
+		data, _ := hex.DecodeString(seed)
+		f.Add(data)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// Invalid data must not panic, and valid data must round-trip.
+		b := &set.Bloom[string]{}
+		if err := b.UnmarshalBinary(data); err == nil {
+			b.Add("fuzz")
+			if actual, _ := b.MarshalBinary(); len(actual) != len(data) {
+				t.Errorf("got %d bytes but expected %d", len(actual), len(data))
+			}
+		}
+		c := &set.CountingBloom[string]{}
+		if err := c.UnmarshalBinary(data); err == nil {
+			c.Add("fuzz")
+			c.Remove("fuzz")
+			if actual, _ := c.MarshalBinary(); len(actual) != len(data) {
+				t.Errorf("got %d bytes but expected %d", len(actual), len(data))
+			}
+		}
+	})
+}
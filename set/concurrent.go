@@ -0,0 +1,297 @@
+package set
+
+import (
+	"iter"
+	"math/bits"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fgm/container"
+)
+
+// Concurrent is a concurrency-safe set, sharded by hash to reduce contention.
+//
+// Single-element operations only lock the shard holding the element.
+// Other operations lock all shards, so they work on a consistent snapshot,
+// and operations between two Concurrent sets lock both in a fixed order,
+// so that concurrent calls like a.Union(b) and b.Union(a) cannot deadlock.
+//
+// Unlike BasicMap, its union/intersection/difference operations always return
+// a new Concurrent set.
+type Concurrent[E comparable] struct {
+	id     uint64 // Defines the lock order between sets
+	shards []shard[E]
+}
+
+type shard[E comparable] struct {
+	sync.RWMutex
+	items map[E]unit
+}
+
+// concurrentIDs generates the Concurrent set identifiers.
+var concurrentIDs atomic.Uint64
+
+// String returns an idiomatic unordered representation of the set items.
+func (s *Concurrent[E]) String() string {
+	return s.snapshot().String()
+}
+
+// Len returns the number of items in the Set.
+func (s *Concurrent[E]) Len() int {
+	if s == nil {
+		return 0
+	}
+	s.rLock()
+	defer s.rUnlock()
+	return s.len()
+}
+
+// Add adds an item to the set. Returns true if the item was already present.
+func (s *Concurrent[E]) Add(item E) (found bool) {
+	if s == nil {
+		return false
+	}
+	sh := s.shard(item)
+	sh.Lock()
+	defer sh.Unlock()
+	_, found = sh.items[item]
+	sh.items[item] = unit{}
+	return found
+}
+
+// Remove removes an item from the set.
+// It does not fail if the item was not present, and returns true if it was.
+func (s *Concurrent[E]) Remove(item E) (found bool) {
+	if s == nil {
+		return false
+	}
+	sh := s.shard(item)
+	sh.Lock()
+	defer sh.Unlock()
+	_, found = sh.items[item]
+	delete(sh.items, item)
+	return found
+}
+
+// Contains returns true if the item is present in the set.
+func (s *Concurrent[E]) Contains(item E) bool {
+	if s == nil {
+		return false
+	}
+	sh := s.shard(item)
+	sh.RLock()
+	defer sh.RUnlock()
+	_, found := sh.items[item]
+	return found
+}
+
+// Clear removes all items from the set and returns the number of items removed.
+func (s *Concurrent[E]) Clear() (count int) {
+	if s == nil {
+		return 0
+	}
+	for i := range s.shards {
+		s.shards[i].Lock()
+	}
+	for i := range s.shards {
+		sh := &s.shards[i]
+		count += len(sh.items)
+		sh.items = make(map[E]unit)
+		sh.Unlock()
+	}
+	return count
+}
+
+// Items returns an unordered iterator over a snapshot of the set elements,
+// taken when the iteration starts.
+//
+// The set may therefore be modified during the iteration.
+func (s *Concurrent[E]) Items() iter.Seq[E] {
+	return func(yield func(E) bool) {
+		if s == nil {
+			return
+		}
+		s.rLock()
+		items := make([]E, 0, s.len())
+		for i := range s.shards {
+			for item := range s.shards[i].items {
+				items = append(items, item)
+			}
+		}
+		s.rUnlock()
+
+		for _, item := range items {
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+// Union returns a new set containing elements present in either set.
+func (s *Concurrent[E]) Union(other container.Set[E]) container.Set[E] {
+	mine, theirs := s.snapshots(other)
+	return s.from(mine.Union(theirs))
+}
+
+// Intersection returns a new set containing elements present in both sets.
+func (s *Concurrent[E]) Intersection(other container.Set[E]) container.Set[E] {
+	mine, theirs := s.snapshots(other)
+	return s.from(mine.Intersection(theirs))
+}
+
+// Difference returns a new set containing elements present in this set but not in the other.
+func (s *Concurrent[E]) Difference(other container.Set[E]) container.Set[E] {
+	mine, theirs := s.snapshots(other)
+	return s.from(mine.Difference(theirs))
+}
+
+// SymmetricDifference returns a new set containing elements present in either set but not in both.
+func (s *Concurrent[E]) SymmetricDifference(other container.Set[E]) container.Set[E] {
+	mine, theirs := s.snapshots(other)
+	return s.from(mine.SymmetricDifference(theirs))
+}
+
+// IsSubsetOf returns true if all elements in this set are present in the other.
+//
+// The empty set is a subset of any set, and nil sets are considered empty.
+func (s *Concurrent[E]) IsSubsetOf(other container.Set[E]) bool {
+	mine, theirs := s.snapshots(other)
+	return mine.IsSubsetOf(theirs)
+}
+
+// IsSupersetOf returns true if all elements in the other set are present in this one.
+func (s *Concurrent[E]) IsSupersetOf(other container.Set[E]) bool {
+	mine, theirs := s.snapshots(other)
+	return mine.IsSupersetOf(theirs)
+}
+
+// IsProperSubsetOf returns true if this set is a subset of the other, and the
+// other contains at least one element not in this set.
+func (s *Concurrent[E]) IsProperSubsetOf(other container.Set[E]) bool {
+	mine, theirs := s.snapshots(other)
+	return mine.IsProperSubsetOf(theirs)
+}
+
+// Equal returns true if both sets contain the same elements.
+func (s *Concurrent[E]) Equal(other container.Set[E]) bool {
+	mine, theirs := s.snapshots(other)
+	return mine.Equal(theirs)
+}
+
+// IsDisjoint returns true if the sets have no element in common.
+func (s *Concurrent[E]) IsDisjoint(other container.Set[E]) bool {
+	mine, theirs := s.snapshots(other)
+	return mine.IsDisjoint(theirs)
+}
+
+func (s *Concurrent[E]) shard(item E) *shard[E] {
+	return &s.shards[hash(item)&uint64(len(s.shards)-1)]
+}
+
+// rLock read-locks all shards, always in the same order.
+func (s *Concurrent[E]) rLock() {
+	for i := range s.shards {
+		s.shards[i].RLock()
+	}
+}
+
+func (s *Concurrent[E]) rUnlock() {
+	for i := range s.shards {
+		s.shards[i].RUnlock()
+	}
+}
+
+// len returns the number of items in the set. The caller must hold the locks.
+func (s *Concurrent[E]) len() int {
+	n := 0
+	for i := range s.shards {
+		n += len(s.shards[i].items)
+	}
+	return n
+}
+
+// copy returns the set items as a BasicMap. The caller must hold the locks.
+func (s *Concurrent[E]) copy() *BasicMap[E] {
+	res := &BasicMap[E]{items: make(map[E]unit, s.len())}
+	for i := range s.shards {
+		for item := range s.shards[i].items {
+			res.items[item] = unit{}
+		}
+	}
+	return res
+}
+
+// snapshot returns a copy of the set items, as of a single point in time.
+func (s *Concurrent[E]) snapshot() *BasicMap[E] {
+	if s == nil {
+		return &BasicMap[E]{items: make(map[E]unit)}
+	}
+	s.rLock()
+	defer s.rUnlock()
+	return s.copy()
+}
+
+// snapshots returns copies of this set and of the other one if it is also a
+// Concurrent set, both taken at the same point in time. Other sets are returned as is.
+//
+// To avoid deadlocks, sets are always locked in the order of their identifiers.
+func (s *Concurrent[E]) snapshots(other container.Set[E]) (*BasicMap[E], container.Set[E]) {
+	o, ok := other.(*Concurrent[E])
+	switch {
+	case !ok:
+		return s.snapshot(), other
+	case s == nil || o == nil:
+		return s.snapshot(), o.snapshot()
+	case s == o:
+		mine := s.snapshot()
+		return mine, mine
+	}
+
+	first, second := s, o
+	if second.id < first.id {
+		first, second = second, first
+	}
+	first.rLock()
+	defer first.rUnlock()
+	second.rLock()
+	defer second.rUnlock()
+	return s.copy(), o.copy()
+}
+
+// from returns a new Concurrent set with the same number of shards as the receiver,
+// holding the items of the BasicMap set operation result. As such a result may
+// be the other operand of the operation, it is handled as any container.Set.
+func (s *Concurrent[E]) from(items container.Set[E]) *Concurrent[E] {
+	shards := 0
+	if s != nil {
+		shards = len(s.shards)
+	}
+	res := newConcurrent[E](0, shards)
+	for item := range items.Items() {
+		sh := res.shard(item)
+		sh.items[item] = unit{}
+	}
+	return res
+}
+
+// NewConcurrent returns a ready-for-use container.Set implemented by the Concurrent type.
+//
+// The number of shards is rounded up to a power of 2. If it is not positive,
+// it defaults to GOMAXPROCS, rounded up the same way. One shard disables sharding.
+func NewConcurrent[E comparable](sizeHint, shards int) container.Set[E] {
+	return newConcurrent[E](sizeHint, shards)
+}
+
+func newConcurrent[E comparable](sizeHint, shards int) *Concurrent[E] {
+	if shards <= 0 {
+		shards = runtime.GOMAXPROCS(0)
+	}
+	shards = 1 << bits.Len(uint(shards-1))
+	s := &Concurrent[E]{id: concurrentIDs.Add(1), shards: make([]shard[E], shards)}
+	for i := range s.shards {
+		s.shards[i].items = make(map[E]unit, sizeHint/shards)
+	}
+	return s
+}
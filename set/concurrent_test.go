@@ -0,0 +1,175 @@
+package set_test
+
+import (
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/fgm/container"
+	"github.com/fgm/container/set"
+)
+
+// mustConcurrent creates a Concurrent set holding the elements.
+func mustConcurrent(tb testing.TB, shards int, elements ...int) *set.Concurrent[int] {
+	tb.Helper()
+	s, ok := set.NewConcurrent[int](len(elements), shards).(*set.Concurrent[int])
+	if !ok {
+		tb.Fatalf("expected a *set.Concurrent")
+	}
+	for _, e := range elements {
+		s.Add(e)
+	}
+	return s
+}
+
+func TestConcurrent(t *testing.T) {
+	var ns *set.Concurrent[int]
+	if ns.Len() != 0 || ns.Contains(1) || ns.Add(1) || ns.Remove(1) || ns.Clear() != 0 || ns.String() != "{}" {
+		t.Errorf("nil set should be empty and ignore changes")
+	}
+
+	for _, shards := range []int{0, 1, 3} {
+		s := mustConcurrent(t, shards)
+		if s.Add(1) {
+			t.Errorf("Add to empty set should return false")
+		}
+		if !s.Add(1) {
+			t.Errorf("Add of existing element should return true")
+		}
+		s.Add(2)
+		if !s.Contains(1) || s.Len() != 2 {
+			t.Errorf("got %v but expected {1, 2}", s)
+		}
+		if !s.Remove(1) || s.Remove(1) {
+			t.Errorf("Remove should only return true for existing elements")
+		}
+		if s.Clear() != 1 || s.Len() != 0 {
+			t.Errorf("Clear should remove the remaining element")
+		}
+	}
+}
+
+func TestConcurrent_SetOperations(t *testing.T) {
+	a, b := mustConcurrent(t, 4, 1, 2, 3), mustConcurrent(t, 2, 2, 3, 4)
+	basic := createSet(t, 2, 3, 4)
+	tests := [...]struct {
+		name     string
+		actual   container.Set[int]
+		expected []int
+	}{
+		{"union", a.Union(b), []int{1, 2, 3, 4}},
+		{"union with basic", a.Union(basic), []int{1, 2, 3, 4}},
+		{"union with nil", a.Union(nil), []int{1, 2, 3}},
+		{"union of nil", (*set.Concurrent[int])(nil).Union(b), []int{2, 3, 4}},
+		{"intersection", a.Intersection(b), []int{2, 3}},
+		{"intersection with self", a.Intersection(a), []int{1, 2, 3}},
+		{"difference", a.Difference(b), []int{1}},
+		{"difference with basic", a.Difference(basic), []int{1}},
+		{"symmetric difference", a.SymmetricDifference(b), []int{1, 4}},
+		{"symmetric difference with self", a.SymmetricDifference(a), nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, ok := test.actual.(*set.Concurrent[int])
+			if !ok {
+				t.Fatalf("got %T but expected a new concurrent set", test.actual)
+			}
+			if c == a || c == b {
+				t.Errorf("operations should not return an operand")
+			}
+			if actual := slices.Sorted(c.Items()); !slices.Equal(actual, test.expected) {
+				t.Errorf("got %v but expected %v", actual, test.expected)
+			}
+		})
+	}
+
+	if !a.Intersection(b).IsSubsetOf(a) || !a.IsSupersetOf(mustConcurrent(t, 1, 1)) || !a.Equal(a) ||
+		a.IsProperSubsetOf(a) || a.IsDisjoint(b) || !a.Difference(b).IsDisjoint(basic) {
+		t.Errorf("predicates do not match the set operations")
+	}
+}
+
+func TestConcurrent_ItemsSnapshot(t *testing.T) {
+	s := mustConcurrent(t, 4, 1, 2, 3)
+	var actual []int
+	for item := range s.Items() {
+		// Modifications during the iteration do not affect it.
+		s.Add(item + 10)
+		actual = append(actual, item)
+	}
+	slices.Sort(actual)
+	if !slices.Equal(actual, []int{1, 2, 3}) {
+		t.Errorf("got %v but expected [1 2 3]", actual)
+	}
+	if s.Len() != 6 {
+		t.Errorf("got length %d but expected 6", s.Len())
+	}
+}
+
+// TestConcurrent_Race runs a mixed workload, including symmetrical operations
+// between two sets which would deadlock without a lock order. Use with -race.
+func TestConcurrent_Race(t *testing.T) {
+	const (
+		goroutines = 8
+		loops      = 200
+	)
+	a, b := mustConcurrent(t, 4), mustConcurrent(t, 4)
+	var wg sync.WaitGroup
+	for g := range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			mine, theirs := a, b
+			if g%2 == 1 {
+				mine, theirs = b, a
+			}
+			for i := range loops {
+				switch mine.Add(i); i % 8 {
+				case 0:
+					mine.Remove(i / 2)
+				case 1:
+					_ = mine.Union(theirs)
+				case 2:
+					_ = mine.Intersection(theirs)
+				case 3:
+					_ = mine.IsSubsetOf(theirs)
+				case 4:
+					_ = mine.Equal(theirs)
+				case 5:
+					// Snapshots are consistent: no element may appear twice.
+					seen := make(map[int]bool)
+					for item := range mine.Items() {
+						if seen[item] {
+							t.Errorf("duplicate item %d in snapshot", item)
+						}
+						seen[item] = true
+					}
+				case 6:
+					_ = mine.Contains(i) && mine.Len() > 0
+				case 7:
+					if i%64 == 7 {
+						mine.Clear()
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkConcurrent_Add(b *testing.B) {
+	for _, shards := range []int{1, 0} {
+		name := "sharded"
+		if shards == 1 {
+			name = "single"
+		}
+		b.Run(name, func(b *testing.B) {
+			s := mustConcurrent(b, shards)
+			b.RunParallel(func(pb *testing.PB) {
+				for i := 0; pb.Next(); i++ {
+					s.Add(i % 1024)
+				}
+			})
+		})
+	}
+}
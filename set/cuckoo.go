@@ -0,0 +1,318 @@
+package set
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+// The binary format of cuckoo filters is, with all integers little-endian:
+//
+//   - The format, cuckooFormat, as 4 bytes.
+//   - The fingerprint size in bits, and the bucket size, as 8 bits each.
+//   - The number of buckets, a power of 2, as 64 bits.
+//   - The packed fingerprints of all the bucket slots, zero for empty slots, as 64 bits words.
+//     The bits beyond the last slot are zero.
+//
+// The number of fingerprints is not stored, but deduced from the slots.
+// Since filters only contain hashes, the format depends on stableHash.
+
+const (
+	cuckooFormat = "CKO1"
+
+	// DefaultFingerprintBits gives a false positive rate of about 0.012% with the default bucket size.
+	DefaultFingerprintBits = 16
+	// DefaultBucketSize allows filling filters up to 95%.
+	DefaultBucketSize = 4
+
+	minFingerprintBits = 4
+	maxFingerprintBits = 32
+	maxBucketSize      = 8
+	maxCuckooKicks     = 500
+)
+
+var (
+	ErrBucketSizeIsOutOfRange      = errors.New("container: bucket size must be between 1 and 8")
+	ErrCapacityIsNotPositive       = errors.New("container: capacity must be positive")
+	ErrFilterIsFull                = errors.New("container: filter is full")
+	ErrFingerprintBitsIsOutOfRange = errors.New("container: fingerprint size must be between 4 and 32 bits")
+	ErrInvalidCuckooFormat         = errors.New("container: invalid cuckoo filter format")
+)
+
+// cuckooLoads are the typical maximum load factors of cuckoo filters, by bucket size.
+var cuckooLoads = [maxBucketSize + 1]float64{1: 0.5, 2: 0.84, 3: 0.9, 4: 0.95, 5: 0.95, 6: 0.95, 7: 0.95, 8: 0.98}
+
+// CuckooOptions configures a Cuckoo filter.
+type CuckooOptions struct {
+	// FingerprintBits is the number of bits stored per item, between 4 and 32.
+	// The false positive rate is about 2 * BucketSize / 2^FingerprintBits.
+	// Zero means DefaultFingerprintBits.
+	FingerprintBits int
+	// BucketSize is the number of fingerprints per bucket, between 1 and 8.
+	// Larger buckets allow higher loads, but increase the false positive rate.
+	// Zero means DefaultBucketSize.
+	BucketSize int
+}
+
+// Cuckoo is a cuckoo filter: a probabilistic set which may report items as present
+// while they were never added, but never reports added items as absent.
+//
+// Unlike Bloom, it supports removing items, while storing only a small
+// fingerprint of each item. Each item may be stored in one of two buckets:
+// when both are full, Add moves existing fingerprints to their alternate bucket,
+// and fails with ErrFilterIsFull if it cannot find room for the item.
+//
+// Adding an item twice stores it twice, so it must be removed twice.
+// Removing an item which was not added may remove another item sharing its fingerprint,
+// so only items known to have been added should be removed.
+//
+// Its MarshalBinary and UnmarshalBinary methods allow building filters in
+// advance, and loading them in another process.
+//
+// It is not concurrency-safe.
+type Cuckoo[K Key] struct {
+	fingerprintBits int
+	bucketSize      int
+	mask            uint64   // Number of buckets - 1
+	slots           []uint64 // Packed fingerprints, zero for empty slots
+	len             int
+}
+
+// Len returns the number of fingerprints in the filter.
+func (f *Cuckoo[K]) Len() int {
+	if f == nil {
+		return 0
+	}
+	return f.len
+}
+
+// Add adds an item to the filter.
+//
+// If the filter is too full to store the item, it returns ErrFilterIsFull and leaves the filter unchanged.
+func (f *Cuckoo[K]) Add(item K) error {
+	if f == nil {
+		return fmt.Errorf("%w: nil filter", ErrFilterIsFull)
+	}
+	fp, i1, i2 := f.locations(item)
+	if f.insert(i1, fp) || f.insert(i2, fp) {
+		f.len++
+		return nil
+	}
+
+	// Both buckets are full: relocate fingerprints, recording the moves to undo them on failure.
+	type move struct{ bucket, slot uint64 }
+	moves := make([]move, 0, maxCuckooKicks)
+	state, bucket := i1^i2^fp, i1
+	if fp&1 != 0 {
+		bucket = i2
+	}
+	for range maxCuckooKicks {
+		state = mix64(state + 1)
+		slot := state % uint64(f.bucketSize)
+		fp = f.swap(bucket, slot, fp)
+		moves = append(moves, move{bucket, slot})
+		bucket = f.alternate(bucket, fp)
+		if f.insert(bucket, fp) {
+			f.len++
+			return nil
+		}
+	}
+	for i := len(moves) - 1; i >= 0; i-- {
+		fp = f.swap(moves[i].bucket, moves[i].slot, fp)
+	}
+	return fmt.Errorf("%w: %d items", ErrFilterIsFull, f.len)
+}
+
+// Contains returns true if the item is probably present in the filter,
+// and false if it is certainly absent.
+func (f *Cuckoo[K]) Contains(item K) bool {
+	if f == nil {
+		return false
+	}
+	fp, i1, i2 := f.locations(item)
+	return f.find(i1, fp) >= 0 || f.find(i2, fp) >= 0
+}
+
+// Remove removes one occurrence of an item from the filter.
+// Returns true if the item was probably present, false if it was certainly absent.
+func (f *Cuckoo[K]) Remove(item K) (found bool) {
+	if f == nil {
+		return false
+	}
+	fp, i1, i2 := f.locations(item)
+	for _, bucket := range [...]uint64{i1, i2} {
+		if slot := f.find(bucket, fp); slot >= 0 {
+			f.swap(bucket, uint64(slot), 0)
+			f.len--
+			return true
+		}
+	}
+	return false
+}
+
+// Clear removes all items from the filter.
+func (f *Cuckoo[K]) Clear() {
+	if f == nil {
+		return
+	}
+	clear(f.slots)
+	f.len = 0
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (f *Cuckoo[K]) MarshalBinary() ([]byte, error) {
+	if f == nil {
+		return nil, fmt.Errorf("%w: nil filter", ErrInvalidCuckooFormat)
+	}
+	buf := make([]byte, 0, len(cuckooFormat)+2+8+8*len(f.slots))
+	buf = append(buf, cuckooFormat...)
+	buf = append(buf, byte(f.fingerprintBits), byte(f.bucketSize))
+	buf = binary.LittleEndian.AppendUint64(buf, f.mask+1)
+	for _, w := range f.slots {
+		buf = binary.LittleEndian.AppendUint64(buf, w)
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, replacing the contents and parameters of the filter.
+//
+// Invalid data returns an error wrapping ErrInvalidCuckooFormat, and leaves the filter unchanged.
+func (f *Cuckoo[K]) UnmarshalBinary(data []byte) error {
+	d := decoder{data: data, invalid: ErrInvalidCuckooFormat}
+	if format := d.bytes(len(cuckooFormat)); d.err == nil && string(format) != cuckooFormat {
+		return fmt.Errorf("%w: unknown format %q", ErrInvalidCuckooFormat, format)
+	}
+	header := d.bytes(2)
+	buckets := d.uint64()
+	if d.err != nil {
+		return d.err
+	}
+	fpBits, bucketSize := int(header[0]), int(header[1])
+	if fpBits < minFingerprintBits || fpBits > maxFingerprintBits || bucketSize < 1 || bucketSize > maxBucketSize {
+		return fmt.Errorf("%w: %d bits fingerprints in buckets of %d", ErrInvalidCuckooFormat, fpBits, bucketSize)
+	}
+	// Check the size before allocating, avoiding overflows.
+	if buckets == 0 || buckets&(buckets-1) != 0 || buckets > 8*uint64(len(d.data)) ||
+		cuckooWords(fpBits, bucketSize, buckets) != uint64(len(d.data))/8 {
+		return fmt.Errorf("%w: %d buckets", ErrInvalidCuckooFormat, buckets)
+	}
+
+	res := newCuckoo[K](fpBits, bucketSize, buckets)
+	for i := range res.slots {
+		res.slots[i] = d.uint64()
+	}
+	if err := d.end(); err != nil {
+		return err
+	}
+	used := uint64(bucketSize) * buckets * uint64(fpBits)
+	if extra := used % wordBits; extra != 0 && res.slots[len(res.slots)-1]>>extra != 0 {
+		return fmt.Errorf("%w: bits set beyond the last slot", ErrInvalidCuckooFormat)
+	}
+	for bucket := range buckets {
+		for slot := range uint64(bucketSize) {
+			if res.get(bucket, slot) != 0 {
+				res.len++
+			}
+		}
+	}
+	*f = *res
+	return nil
+}
+
+// locations returns the fingerprint of the item, and its two candidate buckets.
+func (f *Cuckoo[K]) locations(item K) (fp, i1, i2 uint64) {
+	h := stableHash(item)
+	// Use the high bits for the fingerprint, and the low bits for the bucket.
+	fp = h >> (64 - f.fingerprintBits)
+	if fp == 0 {
+		fp = 1 // Zero marks empty slots.
+	}
+	i1 = h & f.mask
+	return fp, i1, f.alternate(i1, fp)
+}
+
+// alternate returns the other bucket for a fingerprint in a bucket.
+// Since it only depends on the fingerprint, it can be applied to stored fingerprints.
+func (f *Cuckoo[K]) alternate(bucket, fp uint64) uint64 {
+	return (bucket ^ mix64(fp)) & f.mask
+}
+
+// insert stores a fingerprint in a free slot of the bucket, and returns false if there is none.
+func (f *Cuckoo[K]) insert(bucket, fp uint64) bool {
+	if slot := f.find(bucket, 0); slot >= 0 {
+		f.swap(bucket, uint64(slot), fp)
+		return true
+	}
+	return false
+}
+
+// find returns the first slot in the bucket holding the fingerprint, or -1.
+func (f *Cuckoo[K]) find(bucket, fp uint64) int {
+	for slot := range f.bucketSize {
+		if f.get(bucket, uint64(slot)) == fp {
+			return slot
+		}
+	}
+	return -1
+}
+
+// get returns the fingerprint in a slot.
+func (f *Cuckoo[K]) get(bucket, slot uint64) uint64 {
+	pos := (bucket*uint64(f.bucketSize) + slot) * uint64(f.fingerprintBits)
+	word, shift := pos/wordBits, pos%wordBits
+	fp := f.slots[word] >> shift
+	if shift+uint64(f.fingerprintBits) > wordBits {
+		fp |= f.slots[word+1] << (wordBits - shift)
+	}
+	return fp & (1<<f.fingerprintBits - 1)
+}
+
+// swap stores a fingerprint in a slot, and returns the fingerprint it replaced.
+func (f *Cuckoo[K]) swap(bucket, slot, fp uint64) (old uint64) {
+	old = f.get(bucket, slot)
+	pos := (bucket*uint64(f.bucketSize) + slot) * uint64(f.fingerprintBits)
+	word, shift := pos/wordBits, pos%wordBits
+	mask := uint64(1)<<f.fingerprintBits - 1
+	f.slots[word] = f.slots[word]&^(mask<<shift) | fp<<shift
+	if shift+uint64(f.fingerprintBits) > wordBits {
+		f.slots[word+1] = f.slots[word+1]&^(mask>>(wordBits-shift)) | fp>>(wordBits-shift)
+	}
+	return old
+}
+
+// NewCuckoo returns an empty cuckoo filter, able to hold at least capacity items.
+func NewCuckoo[K Key](capacity int, opts CuckooOptions) (*Cuckoo[K], error) {
+	if capacity <= 0 {
+		return nil, fmt.Errorf("%w: got %d", ErrCapacityIsNotPositive, capacity)
+	}
+	if opts.FingerprintBits == 0 {
+		opts.FingerprintBits = DefaultFingerprintBits
+	}
+	if opts.FingerprintBits < minFingerprintBits || opts.FingerprintBits > maxFingerprintBits {
+		return nil, fmt.Errorf("%w: got %d", ErrFingerprintBitsIsOutOfRange, opts.FingerprintBits)
+	}
+	if opts.BucketSize == 0 {
+		opts.BucketSize = DefaultBucketSize
+	}
+	if opts.BucketSize < 1 || opts.BucketSize > maxBucketSize {
+		return nil, fmt.Errorf("%w: got %d", ErrBucketSizeIsOutOfRange, opts.BucketSize)
+	}
+	buckets := math.Ceil(float64(capacity) / (float64(opts.BucketSize) * cuckooLoads[opts.BucketSize]))
+	return newCuckoo[K](opts.FingerprintBits, opts.BucketSize, 1<<bits.Len64(uint64(buckets)-1)), nil
+}
+
+func newCuckoo[K Key](fingerprintBits, bucketSize int, buckets uint64) *Cuckoo[K] {
+	return &Cuckoo[K]{
+		fingerprintBits: fingerprintBits,
+		bucketSize:      bucketSize,
+		mask:            buckets - 1,
+		slots:           make([]uint64, cuckooWords(fingerprintBits, bucketSize, buckets)),
+	}
+}
+
+// cuckooWords returns the number of words holding the packed fingerprints of a filter.
+func cuckooWords(fingerprintBits, bucketSize int, buckets uint64) uint64 {
+	return (buckets*uint64(bucketSize)*uint64(fingerprintBits) + wordBits - 1) / wordBits
+}
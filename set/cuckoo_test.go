@@ -0,0 +1,250 @@
+package set_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/fgm/container/set"
+)
+
+func TestNewCuckoo(t *testing.T) {
+	tests := [...]struct {
+		name     string
+		capacity int
+		opts     set.CuckooOptions
+		expected error
+	}{
+		{"defaults", 100, set.CuckooOptions{}, nil},
+		{"limits", 1, set.CuckooOptions{FingerprintBits: 32, BucketSize: 1}, nil},
+		{"other limits", 1, set.CuckooOptions{FingerprintBits: 4, BucketSize: 8}, nil},
+		{"zero capacity", 0, set.CuckooOptions{}, set.ErrCapacityIsNotPositive},
+		{"small fingerprints", 100, set.CuckooOptions{FingerprintBits: 3}, set.ErrFingerprintBitsIsOutOfRange},
+		{"large fingerprints", 100, set.CuckooOptions{FingerprintBits: 33}, set.ErrFingerprintBitsIsOutOfRange},
+		{"negative bucket size", 100, set.CuckooOptions{BucketSize: -1}, set.ErrBucketSizeIsOutOfRange},
+		{"large bucket size", 100, set.CuckooOptions{BucketSize: 9}, set.ErrBucketSizeIsOutOfRange},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := set.NewCuckoo[string](test.capacity, test.opts); !errors.Is(err, test.expected) {
+				t.Errorf("got %v but expected %v", err, test.expected)
+			}
+		})
+	}
+}
+
+func TestCuckoo(t *testing.T) {
+	var nf *set.Cuckoo[string]
+	if nf.Add("a") == nil || nf.Contains("a") || nf.Remove("a") || nf.Len() != 0 {
+		t.Errorf("nil filter should be empty and ignore changes")
+	}
+	nf.Clear()
+
+	const n = 10_000
+	f, _ := set.NewCuckoo[string](n, set.CuckooOptions{})
+	for i := range n {
+		if err := f.Add(strconv.Itoa(i)); err != nil {
+			t.Fatalf("failed adding %d: %v", i, err)
+		}
+	}
+	positives := 0
+	for i := range n {
+		if !f.Contains(strconv.Itoa(i)) {
+			t.Fatalf("false negative for %d", i)
+		}
+		if f.Contains(strconv.Itoa(n + i)) {
+			positives++
+		}
+	}
+	// The expected rate is about 0.012%.
+	if positives > 10 {
+		t.Errorf("got %d false positives for %d items", positives, n)
+	}
+
+	// Duplicates are stored twice.
+	if err := f.Add("0"); err != nil || f.Len() != n+1 {
+		t.Errorf("got %d items but expected %d: %v", f.Len(), n+1, err)
+	}
+	if !f.Remove("0") || !f.Contains("0") || !f.Remove("0") || f.Contains("0") || f.Len() != n-1 {
+		t.Errorf("Remove should remove one occurrence")
+	}
+	for i := 1; i < n; i++ {
+		if !f.Remove(strconv.Itoa(i)) {
+			t.Fatalf("Remove should return true for existing item %d", i)
+		}
+	}
+	if f.Len() != 0 {
+		t.Errorf("got %d items but expected 0", f.Len())
+	}
+	f.Add("a")
+	f.Clear()
+	if f.Len() != 0 || f.Contains("a") {
+		t.Errorf("Clear should remove all items")
+	}
+}
+
+func TestCuckoo_Full(t *testing.T) {
+	tests := [...]struct {
+		name string
+		opts set.CuckooOptions
+	}{
+		{"small fingerprints", set.CuckooOptions{FingerprintBits: 5, BucketSize: 2}},
+		{"odd fingerprints", set.CuckooOptions{FingerprintBits: 13, BucketSize: 4}},
+		{"large fingerprints", set.CuckooOptions{FingerprintBits: 32, BucketSize: 8}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			const capacity = 500
+			f, _ := set.NewCuckoo[string](capacity, test.opts)
+			var err error
+			added := 0
+			for ; err == nil; added++ {
+				err = f.Add(strconv.Itoa(added))
+			}
+			added-- // The last Add failed.
+			if !errors.Is(err, set.ErrFilterIsFull) {
+				t.Fatalf("got %v but expected %v", err, set.ErrFilterIsFull)
+			}
+			if added < capacity || f.Len() != added {
+				t.Errorf("got %d items but expected at least %d", added, capacity)
+			}
+			// A failed Add leaves the filter unchanged.
+			for i := range added {
+				if !f.Contains(strconv.Itoa(i)) {
+					t.Fatalf("false negative for %d after a failed Add", i)
+				}
+			}
+		})
+	}
+}
+
+func TestCuckoo_Format(t *testing.T) {
+	// The format must not change, for filters to be loaded by other versions.
+	f, _ := set.NewCuckoo[string](4, set.CuckooOptions{FingerprintBits: 8, BucketSize: 2})
+	for _, item := range []string{"a", "b", "c"} {
+		_ = f.Add(item)
+	}
+	data, err := f.MarshalBinary()
+	if err != nil {
+		t.Fatalf("failed marshaling: %v", err)
+	}
+	const expected = "434b4f31080204000000000000006e00000077008200"
+	if actual := hex.EncodeToString(data); actual != expected {
+		t.Errorf("got %s but expected %s", actual, expected)
+	}
+
+	g := &set.Cuckoo[string]{}
+	if err := g.UnmarshalBinary(data); err != nil {
+		t.Fatalf("failed unmarshaling: %v", err)
+	}
+	if g.Len() != 3 || !g.Contains("a") || !g.Contains("c") || !g.Remove("b") || g.Contains("b") {
+		t.Errorf("unmarshaled filter should contain the same items")
+	}
+	if other, err := f.MarshalBinary(); err != nil || !bytes.Equal(other, data) {
+		t.Errorf("got %x but expected %x", other, data)
+	}
+	if _, err := (*set.Cuckoo[string])(nil).MarshalBinary(); err == nil {
+		t.Errorf("marshaling a nil filter should fail")
+	}
+}
+
+func TestCuckoo_UnmarshalInvalid(t *testing.T) {
+	valid, _ := hex.DecodeString("434b4f31080204000000000000006e00000077008200")
+	tests := [...]struct {
+		name string
+		data string
+	}{
+		{"empty", ""},
+		{"unknown format", "584b4f31080204000000000000006e00000077008200"},
+		{"small fingerprints", "434b4f31030204000000000000006e00000077008200"},
+		{"no bucket", "434b4f31080004000000000000006e00000077008200"},
+		{"zero buckets", "434b4f31080200000000000000006e00000077008200"},
+		{"buckets not a power of 2", "434b4f31080203000000000000006e00000077008200"},
+		{"buckets not matching the data", "434b4f31080208000000000000006e00000077008200"},
+		{"huge buckets", "434b4f31080200000000000000806e00000077008200"},
+		{"truncated", "434b4f31080204000000000000006e000000770082"},
+		{"trailing data", "434b4f31080204000000000000006e0000007700820000"},
+		{"bits beyond last slot", "434b4f31040101000000000000001f00000000000000"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, _ := hex.DecodeString(test.data)
+			f := &set.Cuckoo[string]{}
+			if err := f.UnmarshalBinary(valid); err != nil {
+				t.Fatalf("failed unmarshaling valid data: %v", err)
+			}
+			if err := f.UnmarshalBinary(data); !errors.Is(err, set.ErrInvalidCuckooFormat) {
+				t.Errorf("got %v but expected %v", err, set.ErrInvalidCuckooFormat)
+			}
+			if !f.Contains("a") {
+				t.Errorf("failed unmarshaling should not modify the filter")
+			}
+		})
+	}
+}
+
+func FuzzCuckooAddRemove(f *testing.F) {
+	// Add some seed corpus
+	f.Add([]byte{1, 2, 3, 130}, byte(4))    // Add and remove
+	f.Add([]byte{1, 1, 129, 129}, byte(13)) // Duplicates
+	f.Add([]byte{}, byte(32))               // Empty
+
+	f.Fuzz(func(t *testing.T, ops []byte, fpBits byte) {
+		// Compare with a multiset: the high bit selects removals.
+		filter, err := set.NewCuckoo[[]byte](64, set.CuckooOptions{FingerprintBits: 4 + int(fpBits)%29, BucketSize: 1 + int(fpBits)%8})
+		if err != nil {
+			t.Fatalf("failed creating filter: %v", err)
+		}
+		model := make(map[byte]int)
+		for _, op := range ops {
+			item := op & 0x7F
+			if op&0x80 == 0 {
+				if filter.Add([]byte{item}) == nil {
+					model[item]++
+				}
+			} else if model[item] > 0 {
+				if !filter.Remove([]byte{item}) {
+					t.Fatalf("failed removing %d", item)
+				}
+				model[item]--
+			}
+		}
+		total := 0
+		for item, count := range model {
+			if count > 0 && !filter.Contains([]byte{item}) {
+				t.Errorf("false negative for %d", item)
+			}
+			total += count
+		}
+		if filter.Len() != total {
+			t.Errorf("got %d items but expected %d", filter.Len(), total)
+		}
+	})
+}
+
+func FuzzCuckooUnmarshal(f *testing.F) {
+	// Add some seed corpus
+	for _, seed := range []string{
+		"434b4f31080204000000000000006e00000077008200", // Valid
+		"434b4f310501010000000000000001",               // Small
+		"434b4f31",                                     // Truncated
+	} {
+		data, _ := hex.DecodeString(seed)
+		f.Add(data)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// Invalid data must not panic, and valid data must round-trip.
+		c := &set.Cuckoo[string]{}
+		if err := c.UnmarshalBinary(data); err == nil {
+			if actual, _ := c.MarshalBinary(); !bytes.Equal(actual, data) {
+				t.Errorf("got %x but expected %x", actual, data)
+			}
+			if c.Add("fuzz") == nil && !c.Remove("fuzz") {
+				t.Errorf("failed removing an added item")
+			}
+		}
+	})
+}
@@ -0,0 +1,56 @@
+package set
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// decoder reads little-endian values, with a sticky error on truncated data.
+type decoder struct {
+	data    []byte
+	err     error
+	invalid error // The error wrapped by d.err, specific to the decoded format
+}
+
+func (d *decoder) bytes(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(d.data) {
+		d.err = fmt.Errorf("%w: truncated data", d.invalid)
+		d.data = nil
+		return nil
+	}
+	b := d.data[:n]
+	d.data = d.data[n:]
+	return b
+}
+
+func (d *decoder) uint16() uint16 {
+	if b := d.bytes(2); b != nil {
+		return binary.LittleEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (d *decoder) uint32() uint32 {
+	if b := d.bytes(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (d *decoder) uint64() uint64 {
+	if b := d.bytes(8); b != nil {
+		return binary.LittleEndian.Uint64(b)
+	}
+	return 0
+}
+
+// end checks that all the data was decoded, and returns the decoding error if any.
+func (d *decoder) end() error {
+	if d.err == nil && len(d.data) > 0 {
+		d.err = fmt.Errorf("%w: %d trailing bytes", d.invalid, len(d.data))
+	}
+	return d.err
+}
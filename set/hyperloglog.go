@@ -0,0 +1,347 @@
+package set
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"iter"
+	"math"
+	"math/bits"
+	"slices"
+)
+
+// The binary format of HyperLogLog sketches is, with all integers little-endian:
+//
+//   - The format, hllFormat, as 4 bytes.
+//   - The precision, as 8 bits.
+//   - The representation, hllSparse or hllDense, as 8 bits.
+//   - For sparse sketches, the number of entries as 32 bits, then the entries
+//     as 32 bits each, sorted by increasing register index.
+//     Each entry holds the index of a register at precision sparsePrecision,
+//     shifted left by 6 bits, and its rank in the lower 6 bits.
+//   - For dense sketches, the rank of each of the 2^precision registers, as 8 bits each.
+//
+// Since sketches only contain hashes, the format depends on stableHash.
+
+const (
+	hllFormat = "HLL1"
+	hllSparse = 0
+	hllDense  = 1
+
+	// DefaultPrecision gives a standard error of about 0.8%, using 16 KiB once dense.
+	DefaultPrecision = 14
+
+	minPrecision    = 4
+	maxPrecision    = 18
+	sparsePrecision = 25
+	hllMinBuffer    = 64
+)
+
+var (
+	ErrInvalidHyperLogLogFormat = errors.New("container: invalid HyperLogLog format")
+	ErrPrecisionIsOutOfRange    = errors.New("container: precision must be between 4 and 18")
+)
+
+// HyperLogLog is a HyperLogLog++ sketch, estimating the number of distinct
+// items added to it in a fixed amount of memory, instead of storing them like a set.
+//
+// With precision p, it uses 2^p registers of 8 bits, for a standard error of about 1.04/√(2^p).
+// Small sketches use a sparse representation at a higher precision, using less
+// memory and giving nearly exact estimates, until it would use more memory
+// than the dense registers.
+//
+// Like HyperLogLog++, it uses 64 bits hashes and sparse and dense representations,
+// but it estimates the cardinality with the improved estimator from Otmar Ertl's
+// "New cardinality estimation algorithms for HyperLogLog sketches" (2017),
+// which is accurate over the whole range without empirical bias correction.
+//
+// Sketches can be merged, including sketches with a different precision, and
+// their MarshalBinary and UnmarshalBinary methods use a stable format, allowing
+// merging sketches built by different processes.
+//
+// It is not concurrency-safe, even for Estimate and MarshalBinary.
+type HyperLogLog[K Key] struct {
+	precision int
+	registers []uint8  // Dense rank of each register. Nil while the sketch is sparse
+	sparse    []uint32 // Sparse entries, sorted by register index, with one entry per index
+	buffer    []uint32 // Sparse entries not merged yet into sparse
+}
+
+// Precision returns the precision of the sketch, as the base 2 logarithm of its number of registers.
+func (s *HyperLogLog[K]) Precision() int {
+	if s == nil {
+		return 0
+	}
+	return s.precision
+}
+
+// Add adds an item to the sketch.
+func (s *HyperLogLog[K]) Add(item K) {
+	if s == nil {
+		return
+	}
+	h := stableHash(item)
+	if s.registers != nil {
+		idx, rank := hllRank(h, s.precision)
+		s.registers[idx] = max(s.registers[idx], rank)
+		return
+	}
+	idx, rank := hllRank(h, sparsePrecision)
+	s.buffer = append(s.buffer, uint32(idx)<<6|uint32(rank))
+	if len(s.buffer) >= max(hllMinBuffer, len(s.sparse)/4) {
+		s.flush()
+	}
+}
+
+// Estimate returns the estimated number of distinct items added to the sketch.
+func (s *HyperLogLog[K]) Estimate() int {
+	if s == nil {
+		return 0
+	}
+	s.flush()
+	p := s.precision
+	if s.registers == nil {
+		p = sparsePrecision
+	}
+	// counts[k] is the number of registers with rank k, from 0 for empty registers, to 65-p.
+	counts := make([]int, 66-p)
+	counts[0] = 1 << p
+	for _, rank := range s.ranks(p) {
+		counts[0]--
+		counts[rank]++
+	}
+	return int(math.Round(hllEstimate(counts)))
+}
+
+// Merge adds the items of the other sketch to this one.
+//
+// If the other sketch has a lower precision, this sketch is reduced to that precision.
+func (s *HyperLogLog[K]) Merge(other *HyperLogLog[K]) {
+	if s == nil || other == nil {
+		return
+	}
+	p := min(s.precision, other.precision)
+	if s.registers == nil && other.registers == nil {
+		s.precision = p
+		s.buffer = append(append(s.buffer, other.sparse...), other.buffer...)
+		s.flush()
+		return
+	}
+	s.densify(p)
+	for idx, rank := range other.ranks(p) {
+		s.registers[idx] = max(s.registers[idx], rank)
+	}
+}
+
+// Clear removes all items from the sketch, returning it to the sparse representation.
+func (s *HyperLogLog[K]) Clear() {
+	if s == nil {
+		return
+	}
+	s.registers, s.sparse, s.buffer = nil, nil, nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (s *HyperLogLog[K]) MarshalBinary() ([]byte, error) {
+	if s == nil {
+		return nil, fmt.Errorf("%w: nil sketch", ErrInvalidHyperLogLogFormat)
+	}
+	s.flush()
+	buf := append(make([]byte, 0, len(hllFormat)+2+4+max(len(s.registers), 4*len(s.sparse))), hllFormat...)
+	if s.registers != nil {
+		buf = append(buf, byte(s.precision), hllDense)
+		return append(buf, s.registers...), nil
+	}
+	buf = append(buf, byte(s.precision), hllSparse)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(s.sparse)))
+	for _, e := range s.sparse {
+		buf = binary.LittleEndian.AppendUint32(buf, e)
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, replacing the contents and precision of the sketch.
+//
+// Invalid data returns an error wrapping ErrInvalidHyperLogLogFormat, and leaves the sketch unchanged.
+func (s *HyperLogLog[K]) UnmarshalBinary(data []byte) error {
+	d := decoder{data: data, invalid: ErrInvalidHyperLogLogFormat}
+	if format := d.bytes(len(hllFormat)); d.err == nil && string(format) != hllFormat {
+		return fmt.Errorf("%w: unknown format %q", ErrInvalidHyperLogLogFormat, format)
+	}
+	header := d.bytes(2)
+	if d.err != nil {
+		return d.err
+	}
+	res := &HyperLogLog[K]{precision: int(header[0])}
+	if res.precision < minPrecision || res.precision > maxPrecision {
+		return fmt.Errorf("%w: precision %d", ErrInvalidHyperLogLogFormat, res.precision)
+	}
+	switch header[1] {
+	case hllSparse:
+		n := d.uint32()
+		if d.err == nil && uint64(n) > uint64(len(d.data))/4 {
+			return fmt.Errorf("%w: %d entries", ErrInvalidHyperLogLogFormat, n)
+		}
+		res.sparse = make([]uint32, 0, n)
+		for i := uint32(0); i < n && d.err == nil; i++ {
+			e := d.uint32()
+			if rank := e & 63; e>>6 >= 1<<sparsePrecision || rank == 0 || rank > 65-sparsePrecision {
+				return fmt.Errorf("%w: invalid entry %#x", ErrInvalidHyperLogLogFormat, e)
+			}
+			if i > 0 && e>>6 <= res.sparse[i-1]>>6 {
+				return fmt.Errorf("%w: unsorted entries", ErrInvalidHyperLogLogFormat)
+			}
+			res.sparse = append(res.sparse, e)
+		}
+	case hllDense:
+		res.registers = append([]uint8(nil), d.bytes(1<<res.precision)...)
+		for _, rank := range res.registers {
+			if int(rank) > 65-res.precision {
+				return fmt.Errorf("%w: rank %d", ErrInvalidHyperLogLogFormat, rank)
+			}
+		}
+	default:
+		return fmt.Errorf("%w: unknown representation %d", ErrInvalidHyperLogLogFormat, header[1])
+	}
+	if err := d.end(); err != nil {
+		return err
+	}
+	*s = *res
+	return nil
+}
+
+// flush merges the buffered entries into the sparse ones, and converts the
+// sketch to the dense representation if they would use more memory.
+func (s *HyperLogLog[K]) flush() {
+	if len(s.buffer) == 0 {
+		return
+	}
+	entries := append(s.sparse, s.buffer...)
+	slices.Sort(entries)
+	// Keep the last entry for each index, which has the highest rank.
+	res := entries[:0]
+	for _, e := range entries {
+		if n := len(res); n > 0 && res[n-1]>>6 == e>>6 {
+			res[n-1] = e
+		} else {
+			res = append(res, e)
+		}
+	}
+	s.sparse, s.buffer = res, nil
+	if 4*len(s.sparse) > 1<<s.precision {
+		s.densify(s.precision)
+	}
+}
+
+// densify converts the sketch to the dense representation at the given precision,
+// which must not exceed its current precision.
+func (s *HyperLogLog[K]) densify(p int) {
+	if s.registers != nil && s.precision == p {
+		return
+	}
+	registers := make([]uint8, 1<<p)
+	for idx, rank := range s.ranks(p) {
+		registers[idx] = max(registers[idx], rank)
+	}
+	s.precision, s.registers, s.sparse, s.buffer = p, registers, nil, nil
+}
+
+// ranks returns an iterator over the register indexes and ranks of the sketch,
+// reduced to the given precision, which must not exceed its current precision.
+//
+// It skips empty registers, and may yield the same index several times,
+// unless the sketch is dense at that precision, or sparse with no buffered entries.
+func (s *HyperLogLog[K]) ranks(p int) iter.Seq2[uint64, uint8] {
+	return func(yield func(uint64, uint8) bool) {
+		if s.registers != nil {
+			for idx, rank := range s.registers {
+				if rank != 0 && !yield(hllReduce(uint64(idx), rank, s.precision, p)) {
+					return
+				}
+			}
+			return
+		}
+		for _, entries := range [...][]uint32{s.sparse, s.buffer} {
+			for _, e := range entries {
+				if !yield(hllReduce(uint64(e>>6), uint8(e&63), sparsePrecision, p)) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// hllRank returns the register index of a hash at precision p, from its high p bits,
+// and its rank: the position of the leftmost 1 in the other bits, from 1 to 65-p.
+func hllRank(h uint64, p int) (idx uint64, rank uint8) {
+	return h >> (64 - p), uint8(min(bits.LeadingZeros64(h<<p), 64-p) + 1)
+}
+
+// hllReduce converts a non-empty register from a precision to a lower one.
+//
+// The low bits of the index, which are not part of the index at the lower precision,
+// become the leading bits for the rank.
+func hllReduce(idx uint64, rank uint8, from, to int) (uint64, uint8) {
+	d := from - to
+	if low := idx & (1<<d - 1); low != 0 {
+		return idx >> d, uint8(d - bits.Len64(low) + 1)
+	}
+	return idx >> d, rank + uint8(d)
+}
+
+// hllEstimate is the improved raw estimator from Ertl's algorithm 6,
+// where counts[k] is the number of registers with rank k.
+func hllEstimate(counts []int) float64 {
+	q := len(counts) - 2
+	m := 0.0
+	for _, c := range counts {
+		m += float64(c)
+	}
+	z := m * hllTau(1-float64(counts[q+1])/m)
+	for k := q; k >= 1; k-- {
+		z = 0.5 * (z + float64(counts[k]))
+	}
+	z += m * hllSigma(float64(counts[0])/m)
+	return m * m / (2 * math.Ln2 * z)
+}
+
+func hllSigma(x float64) float64 {
+	if x == 1 {
+		return math.Inf(1)
+	}
+	y, z := 1.0, x
+	for {
+		x *= x
+		prev := z
+		z += x * y
+		y += y
+		if z == prev {
+			return z
+		}
+	}
+}
+
+func hllTau(x float64) float64 {
+	if x == 0 || x == 1 {
+		return 0
+	}
+	y, z := 1.0, 1-x
+	for {
+		x = math.Sqrt(x)
+		prev := z
+		y *= 0.5
+		z -= (1 - x) * (1 - x) * y
+		if z == prev {
+			return z / 3
+		}
+	}
+}
+
+// NewHyperLogLog returns an empty sketch with the given precision, between 4 and 18.
+// See DefaultPrecision for a typical value.
+func NewHyperLogLog[K Key](precision int) (*HyperLogLog[K], error) {
+	if precision < minPrecision || precision > maxPrecision {
+		return nil, fmt.Errorf("%w: got %d", ErrPrecisionIsOutOfRange, precision)
+	}
+	return &HyperLogLog[K]{precision: precision}, nil
+}
@@ -0,0 +1,252 @@
+package set_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/fgm/container/set"
+)
+
+// createHyperLogLog creates a sketch holding the decimal representation of the integers in [from, to).
+func createHyperLogLog(tb testing.TB, precision, from, to int) *set.HyperLogLog[string] {
+	tb.Helper()
+	s, err := set.NewHyperLogLog[string](precision)
+	if err != nil {
+		tb.Fatalf("failed creating sketch: %v", err)
+	}
+	for i := from; i < to; i++ {
+		s.Add(strconv.Itoa(i))
+	}
+	return s
+}
+
+func TestNewHyperLogLog(t *testing.T) {
+	tests := [...]struct {
+		name      string
+		precision int
+		expected  error
+	}{
+		{"default", set.DefaultPrecision, nil},
+		{"minimum", 4, nil},
+		{"maximum", 18, nil},
+		{"too low", 3, set.ErrPrecisionIsOutOfRange},
+		{"too high", 19, set.ErrPrecisionIsOutOfRange},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := set.NewHyperLogLog[string](test.precision); !errors.Is(err, test.expected) {
+				t.Errorf("got %v but expected %v", err, test.expected)
+			}
+		})
+	}
+}
+
+func TestHyperLogLog(t *testing.T) {
+	var ns *set.HyperLogLog[string]
+	ns.Add("a")
+	ns.Merge(createHyperLogLog(t, 4, 0, 10))
+	ns.Clear()
+	if ns.Estimate() != 0 || ns.Precision() != 0 {
+		t.Errorf("nil sketch should be empty and ignore changes")
+	}
+
+	// Sparse sketches are nearly exact, while dense ones have an error of about 1.04/√(2^p).
+	tests := [...]struct {
+		name      string
+		precision int
+		n         int
+		tolerance float64
+	}{
+		{"empty", 14, 0, 0},
+		{"one", 14, 1, 0},
+		{"sparse", 14, 1000, 0.005},
+		{"sparse limit", 14, 4000, 0.005},
+		{"small dense", 10, 2000, 3 * 1.04 / 32},
+		{"dense", 14, 100_000, 3 * 1.04 / 128},
+		{"large", 12, 1_000_000, 3 * 1.04 / 64},
+		{"low precision", 4, 100, 3 * 1.04 / 4},
+		{"high precision", 18, 50_000, 0.01},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := createHyperLogLog(t, test.precision, 0, test.n)
+			// Duplicates do not change the estimate.
+			for i := range min(test.n, 1000) {
+				s.Add(strconv.Itoa(i))
+			}
+			actual := s.Estimate()
+			if math.Abs(float64(actual-test.n)) > test.tolerance*float64(test.n) {
+				t.Errorf("got %d but expected %d within %.1f%%", actual, test.n, 100*test.tolerance)
+			}
+		})
+	}
+
+	s := createHyperLogLog(t, 8, 0, 1000)
+	s.Clear()
+	if s.Estimate() != 0 || s.Precision() != 8 {
+		t.Errorf("Clear should remove all items")
+	}
+}
+
+func TestHyperLogLog_Merge(t *testing.T) {
+	tests := [...]struct {
+		name           string
+		pa, pb         int
+		na, nb         int // Sizes of overlapping ranges
+		expectedPrec   int
+		sameAsDirectly bool // The result is the same as adding all items to a single sketch
+	}{
+		{"sparse and sparse", 14, 14, 500, 700, 14, true},
+		{"sparse and dense", 10, 10, 50, 5000, 10, true},
+		{"dense and sparse", 10, 10, 5000, 50, 10, true},
+		{"dense and dense", 12, 12, 20_000, 30_000, 12, true},
+		{"to lower precision", 14, 10, 20_000, 30_000, 10, false},
+		{"from higher precision", 10, 14, 20_000, 30_000, 10, false},
+		{"sparse to lower precision", 14, 10, 100, 200, 10, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// a holds [0, na), b holds [na/2, na/2+nb).
+			a := createHyperLogLog(t, test.pa, 0, test.na)
+			b := createHyperLogLog(t, test.pb, test.na/2, test.na/2+test.nb)
+			a.Merge(b)
+			if a.Precision() != test.expectedPrec {
+				t.Errorf("got precision %d but expected %d", a.Precision(), test.expectedPrec)
+			}
+			n := max(test.na, test.na/2+test.nb)
+			direct := createHyperLogLog(t, test.expectedPrec, 0, n)
+			if actual, expected := a.Estimate(), direct.Estimate(); math.Abs(float64(actual-expected)) > 0.02*float64(n) {
+				t.Errorf("got %d but expected about %d", actual, expected)
+			}
+			if test.sameAsDirectly {
+				actual, _ := a.MarshalBinary()
+				expected, _ := direct.MarshalBinary()
+				if !bytes.Equal(actual, expected) {
+					t.Errorf("merged sketch differs from the sketch built directly")
+				}
+			}
+		})
+	}
+
+	// A higher precision sketch reduced by merging is the same as one built at the lower precision.
+	high, low := createHyperLogLog(t, 16, 0, 100_000), createHyperLogLog(t, 8, 0, 0)
+	low.Merge(high)
+	actual, _ := low.MarshalBinary()
+	expected, _ := createHyperLogLog(t, 8, 0, 100_000).MarshalBinary()
+	if !bytes.Equal(actual, expected) {
+		t.Errorf("reduced sketch differs from the sketch built at its precision")
+	}
+	for _, n := range []int{10, 1000} {
+		s := createHyperLogLog(t, 8, 0, n)
+		before := s.Estimate()
+		s.Merge(nil)
+		s.Merge(s)
+		if s.Estimate() != before {
+			t.Errorf("got %d but expected %d", s.Estimate(), before)
+		}
+	}
+}
+
+func TestHyperLogLog_Format(t *testing.T) {
+	// The format must not change, for sketches to be merged by other versions.
+	tests := [...]struct {
+		name     string
+		sketch   *set.HyperLogLog[string]
+		expected string
+	}{
+		{"empty", createHyperLogLog(t, 4, 0, 0), "484c4c31040000000000"},
+		{"sparse", createHyperLogLog(t, 8, 0, 2), "484c4c3108000200000041191c3e4115f260"},
+		{"dense", createHyperLogLog(t, 4, 0, 8), "484c4c31040100000000010000010000020504000000"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := test.sketch.MarshalBinary()
+			if err != nil {
+				t.Fatalf("failed marshaling: %v", err)
+			}
+			if actual := hex.EncodeToString(data); actual != test.expected {
+				t.Errorf("got %s but expected %s", actual, test.expected)
+			}
+			s := &set.HyperLogLog[string]{}
+			if err := s.UnmarshalBinary(data); err != nil {
+				t.Fatalf("failed unmarshaling: %v", err)
+			}
+			if s.Estimate() != test.sketch.Estimate() || s.Precision() != test.sketch.Precision() {
+				t.Errorf("got %d but expected %d", s.Estimate(), test.sketch.Estimate())
+			}
+		})
+	}
+	if _, err := (*set.HyperLogLog[string])(nil).MarshalBinary(); err == nil {
+		t.Errorf("marshaling a nil sketch should fail")
+	}
+}
+
+func TestHyperLogLog_UnmarshalInvalid(t *testing.T) {
+	valid, _ := hex.DecodeString("484c4c3108000200000041191c3e4115f260")
+	tests := [...]struct {
+		name string
+		data string
+	}{
+		{"empty", ""},
+		{"unknown format", "584c4c3108000200000041191c3e4115f260"},
+		{"low precision", "484c4c3103000200000041191c3e4115f260"},
+		{"high precision", "484c4c3113000200000041191c3e4115f260"},
+		{"unknown representation", "484c4c3108020200000041191c3e4115f260"},
+		{"too many entries", "484c4c3108000300000041191c3e4115f260"},
+		{"truncated", "484c4c3108000200000041191c3e4115f2"},
+		{"trailing data", "484c4c3108000200000041191c3e4115f26000"},
+		{"zero rank", "484c4c3108000200000040191c3e4115f260"},
+		{"rank too high", "484c4c3108000200000069191c3e4115f260"},
+		{"index too large", "484c4c3108000200000041191c8e4115f260"},
+		{"unsorted entries", "484c4c31080002000000" + "4115f26041191c3e"},
+		{"duplicate index", "484c4c31080002000000" + "41191c3e42191c3e"},
+		{"truncated dense", "484c4c310401000000000100000100000205040000"},
+		{"dense rank too high", "484c4c310401000000000100000100000205043e0000"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, _ := hex.DecodeString(test.data)
+			s := &set.HyperLogLog[string]{}
+			if err := s.UnmarshalBinary(valid); err != nil {
+				t.Fatalf("failed unmarshaling valid data: %v", err)
+			}
+			if err := s.UnmarshalBinary(data); !errors.Is(err, set.ErrInvalidHyperLogLogFormat) {
+				t.Errorf("got %v but expected %v", err, set.ErrInvalidHyperLogLogFormat)
+			}
+			if s.Estimate() != 2 {
+				t.Errorf("failed unmarshaling should not modify the sketch")
+			}
+		})
+	}
+}
+
+func FuzzHyperLogLogUnmarshal(f *testing.F) {
+	// Add some seed corpus
+	for _, seed := range []string{
+		"484c4c3108000200000041191c3e4115f260",         // Sparse
+		"484c4c31040100000000010000010000020504000000", // Dense
+		"484c4c31", // Truncated
+	} {
+		data, _ := hex.DecodeString(seed)
+		f.Add(data)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// Invalid data must not panic, and valid data must round-trip.
+		s := &set.HyperLogLog[string]{}
+		if err := s.UnmarshalBinary(data); err == nil {
+			if actual, _ := s.MarshalBinary(); !bytes.Equal(actual, data) {
+				t.Errorf("got %x but expected %x", actual, data)
+			}
+			before := s.Estimate()
+			s.Merge(s)
+			if s.Estimate() != before {
+				t.Errorf("merging a sketch with itself changed its estimate from %d to %d", before, s.Estimate())
+			}
+		}
+	})
+}
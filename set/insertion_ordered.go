@@ -0,0 +1,202 @@
+package set
+
+import (
+	"container/list"
+	"fmt"
+	"iter"
+	"strings"
+
+	"github.com/fgm/container"
+)
+
+// InsertionOrdered is a set iterating its elements in insertion order,
+// making its iteration and String output deterministic, unlike BasicMap.
+//
+// Adding an element already present does not change its position.
+// Add, Remove and Contains are O(1), using a map to a doubly-linked list.
+//
+// Its union/intersection/difference operations always return a new InsertionOrdered set,
+// holding the elements of the receiver in its order, followed by those of the
+// other set in its iteration order.
+// Equal and the other predicates ignore the order.
+//
+// It is not concurrency-safe.
+type InsertionOrdered[E comparable] struct {
+	items map[E]*list.Element
+	order *list.List
+}
+
+// String returns the set items in insertion order.
+func (s *InsertionOrdered[E]) String() string {
+	var b strings.Builder
+	b.WriteByte('{')
+	i := 0
+	for item := range s.Items() {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		_, _ = fmt.Fprintf(&b, "%v", item)
+		i++
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// Len returns the number of items in the Set.
+func (s *InsertionOrdered[E]) Len() int {
+	if s == nil {
+		return 0
+	}
+	return len(s.items)
+}
+
+// Add adds an item at the end of the set, unless it is already present.
+// Returns true if the item was already present.
+func (s *InsertionOrdered[E]) Add(item E) (found bool) {
+	if s == nil {
+		return false
+	}
+	if _, found = s.items[item]; !found {
+		s.items[item] = s.order.PushBack(item)
+	}
+	return found
+}
+
+// Remove removes an item from the set.
+// It does not fail if the item was not present, and returns true if it was.
+func (s *InsertionOrdered[E]) Remove(item E) (found bool) {
+	if s == nil {
+		return false
+	}
+	e, found := s.items[item]
+	if found {
+		s.order.Remove(e)
+		delete(s.items, item)
+	}
+	return found
+}
+
+// Contains returns true if the item is present in the set.
+func (s *InsertionOrdered[E]) Contains(item E) bool {
+	if s == nil {
+		return false
+	}
+	_, found := s.items[item]
+	return found
+}
+
+// Clear removes all items from the set and returns the number of items removed.
+func (s *InsertionOrdered[E]) Clear() (count int) {
+	if s == nil {
+		return 0
+	}
+	count = s.Len()
+	clear(s.items)
+	s.order.Init()
+	return count
+}
+
+// Items returns an iterator over the set elements, in insertion order.
+//
+// Only the element being yielded may be removed during the iteration:
+// removing any other element may end the iteration early.
+func (s *InsertionOrdered[E]) Items() iter.Seq[E] {
+	return func(yield func(E) bool) {
+		if s == nil {
+			return
+		}
+		for e := s.order.Front(); e != nil; {
+			next := e.Next()
+			if !yield(e.Value.(E)) {
+				return
+			}
+			e = next
+		}
+	}
+}
+
+// Union returns a new set containing elements present in either set.
+func (s *InsertionOrdered[E]) Union(other container.Set[E]) container.Set[E] {
+	res := s.filter(nil, false)
+	if other != nil {
+		for item := range other.Items() {
+			res.Add(item)
+		}
+	}
+	return res
+}
+
+// Intersection returns a new set containing elements present in both sets.
+func (s *InsertionOrdered[E]) Intersection(other container.Set[E]) container.Set[E] {
+	if other == nil {
+		return newInsertionOrdered[E](0)
+	}
+	return s.filter(other, true)
+}
+
+// Difference returns a new set containing elements present in this set but not in the other.
+func (s *InsertionOrdered[E]) Difference(other container.Set[E]) container.Set[E] {
+	return s.filter(other, false)
+}
+
+// SymmetricDifference returns a new set containing elements present in either set but not in both.
+func (s *InsertionOrdered[E]) SymmetricDifference(other container.Set[E]) container.Set[E] {
+	res := s.filter(other, false)
+	if other != nil {
+		for item := range other.Items() {
+			if !s.Contains(item) {
+				res.Add(item)
+			}
+		}
+	}
+	return res
+}
+
+// IsSubsetOf returns true if all elements in this set are present in the other.
+//
+// The empty set is a subset of any set, and nil sets are considered empty.
+func (s *InsertionOrdered[E]) IsSubsetOf(other container.Set[E]) bool {
+	return isSubset(s, other)
+}
+
+// IsSupersetOf returns true if all elements in the other set are present in this one.
+func (s *InsertionOrdered[E]) IsSupersetOf(other container.Set[E]) bool {
+	return isSuperset(s, other)
+}
+
+// IsProperSubsetOf returns true if this set is a subset of the other, and the
+// other contains at least one element not in this set.
+func (s *InsertionOrdered[E]) IsProperSubsetOf(other container.Set[E]) bool {
+	return isProperSubset(s, other)
+}
+
+// Equal returns true if both sets contain the same elements, regardless of their order.
+func (s *InsertionOrdered[E]) Equal(other container.Set[E]) bool {
+	return equal(s, other)
+}
+
+// IsDisjoint returns true if the sets have no element in common.
+func (s *InsertionOrdered[E]) IsDisjoint(other container.Set[E]) bool {
+	return isDisjoint(s, other)
+}
+
+// filter returns a new set with the elements of this set which are, or are not,
+// in the other, in the same order. A nil other set is considered empty.
+func (s *InsertionOrdered[E]) filter(other container.Set[E], in bool) *InsertionOrdered[E] {
+	res := newInsertionOrdered[E](s.Len())
+	for item := range s.Items() {
+		if (other != nil && other.Contains(item)) == in {
+			res.Add(item)
+		}
+	}
+	return res
+}
+
+// NewInsertionOrdered returns a ready-for-use container.Set implemented by the InsertionOrdered type.
+func NewInsertionOrdered[E comparable](sizeHint int) container.Set[E] {
+	return newInsertionOrdered[E](sizeHint)
+}
+
+func newInsertionOrdered[E comparable](sizeHint int) *InsertionOrdered[E] {
+	return &InsertionOrdered[E]{items: make(map[E]*list.Element, sizeHint), order: list.New()}
+}
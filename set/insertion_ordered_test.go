@@ -0,0 +1,122 @@
+package set_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/fgm/container"
+	"github.com/fgm/container/set"
+)
+
+// createInsertionOrdered creates an InsertionOrdered set holding the elements.
+func createInsertionOrdered(tb testing.TB, elements ...int) *set.InsertionOrdered[int] {
+	tb.Helper()
+	s, ok := set.NewInsertionOrdered[int](len(elements)).(*set.InsertionOrdered[int])
+	if !ok {
+		tb.Fatalf("expected a *set.InsertionOrdered")
+	}
+	for _, e := range elements {
+		s.Add(e)
+	}
+	return s
+}
+
+func TestInsertionOrdered(t *testing.T) {
+	var ns *set.InsertionOrdered[int]
+	if ns.Len() != 0 || ns.Contains(1) || ns.Add(1) || ns.Remove(1) || ns.Clear() != 0 || ns.String() != "{}" {
+		t.Errorf("nil set should be empty and ignore changes")
+	}
+
+	s := createInsertionOrdered(t, 3, 1, 2)
+	if s.Add(4) || !s.Add(3) {
+		t.Errorf("Add should only return true for existing elements")
+	}
+	// Re-adding 3 does not move it.
+	if actual := s.String(); actual != "{3, 1, 2, 4}" {
+		t.Errorf("got %s but expected {3, 1, 2, 4}", actual)
+	}
+	if !s.Remove(1) || s.Remove(1) || s.Contains(1) {
+		t.Errorf("Remove should only return true for existing elements")
+	}
+	s.Add(1)
+	if actual := slices.Collect(s.Items()); !slices.Equal(actual, []int{3, 2, 4, 1}) {
+		t.Errorf("got %v but expected [3 2 4 1]", actual)
+	}
+
+	// Removing the current element during the iteration.
+	for item := range s.Items() {
+		if item%2 == 0 {
+			s.Remove(item)
+		}
+	}
+	if actual := slices.Collect(s.Items()); !slices.Equal(actual, []int{3, 1}) {
+		t.Errorf("got %v but expected [3 1]", actual)
+	}
+	if s.Clear() != 2 || s.Len() != 0 || s.String() != "{}" {
+		t.Errorf("Clear should remove all elements")
+	}
+}
+
+func TestInsertionOrdered_SetOperations(t *testing.T) {
+	a, b := createInsertionOrdered(t, 5, 1, 3, 2), createInsertionOrdered(t, 4, 3, 6, 5)
+	tests := [...]struct {
+		name     string
+		actual   container.Set[int]
+		expected []int
+	}{
+		{"union", a.Union(b), []int{5, 1, 3, 2, 4, 6}},
+		{"union with nil", a.Union(nil), []int{5, 1, 3, 2}},
+		{"intersection", a.Intersection(b), []int{5, 3}},
+		{"intersection reversed", b.Intersection(a), []int{3, 5}},
+		{"intersection with nil", a.Intersection(nil), nil},
+		{"difference", a.Difference(b), []int{1, 2}},
+		{"difference with nil", a.Difference(nil), []int{5, 1, 3, 2}},
+		{"symmetric difference", a.SymmetricDifference(b), []int{1, 2, 4, 6}},
+		{"symmetric difference with basic", a.SymmetricDifference(createSet(t, 2)), []int{5, 1, 3}},
+		{"of nil", (*set.InsertionOrdered[int])(nil).Union(b), []int{4, 3, 6, 5}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, ok := test.actual.(*set.InsertionOrdered[int])
+			if !ok {
+				t.Fatalf("got %T but expected a new insertion-ordered set", test.actual)
+			}
+			if s == a || s == b {
+				t.Errorf("operations should not return an operand")
+			}
+			if actual := slices.Collect(s.Items()); !slices.Equal(actual, test.expected) {
+				t.Errorf("got %v but expected %v", actual, test.expected)
+			}
+		})
+	}
+
+	// Predicates ignore the order.
+	if !a.Equal(createInsertionOrdered(t, 1, 2, 3, 5)) || !a.Equal(createSet(t, 1, 2, 3, 5)) {
+		t.Errorf("sets with the same elements in a different order should be equal")
+	}
+	if !a.Intersection(b).IsProperSubsetOf(a) || !a.IsSupersetOf(a.Difference(b)) || a.IsDisjoint(b) {
+		t.Errorf("predicates do not match the set operations")
+	}
+}
+
+func FuzzInsertionOrdered(f *testing.F) {
+	// Add some seed corpus
+	f.Add([]byte{3, 1, 2})    // Unsorted
+	f.Add([]byte{1, 1, 2, 1}) // Duplicates
+	f.Add([]byte{})           // Empty
+
+	f.Fuzz(func(t *testing.T, items []byte) {
+		// The iteration order is the order of first insertion.
+		s := set.NewInsertionOrdered[byte](len(items))
+		var expected []byte
+		for _, item := range items {
+			if !slices.Contains(expected, item) {
+				expected = append(expected, item)
+			}
+			s.Add(item)
+		}
+		if actual := slices.Collect(s.Items()); !slices.Equal(actual, expected) {
+			t.Errorf("got %v but expected %v", actual, expected)
+		}
+	})
+}
@@ -0,0 +1,245 @@
+package set
+
+import (
+	"cmp"
+	"fmt"
+	"iter"
+	"slices"
+	"strings"
+
+	"github.com/fgm/container"
+)
+
+// MultiSet is a bag of elements, counting the occurrences of each element.
+//
+// It is not a container.Set, since its Add and Remove methods take a number
+// of occurrences, but Distinct returns the set of its elements.
+//
+// Its operations follow the usual multiset semantics:
+//   - Union keeps the maximum count of each element in both multisets,
+//   - Sum adds the counts,
+//   - Intersection keeps the minimum count,
+//   - Difference subtracts the counts, dropping elements with no occurrence left.
+//
+// They always return a new MultiSet, and consider nil multisets as empty.
+//
+// It is not concurrency-safe.
+type MultiSet[E comparable] struct {
+	counts map[E]int // Never zero
+	total  int
+}
+
+// ElementCount is an element of a MultiSet with its number of occurrences.
+type ElementCount[E comparable] struct {
+	Item  E
+	Count int
+}
+
+// String returns an unordered representation of the multiset items with their counts.
+func (s *MultiSet[E]) String() string {
+	var b strings.Builder
+	b.WriteByte('{')
+	i := 0
+	for item, count := range s.All() {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		_, _ = fmt.Fprintf(&b, "%v: %d", item, count)
+		i++
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// Len returns the number of distinct items in the MultiSet. See Total for the number of occurrences.
+func (s *MultiSet[E]) Len() int {
+	if s == nil {
+		return 0
+	}
+	return len(s.counts)
+}
+
+// Total returns the number of occurrences of all items in the MultiSet.
+func (s *MultiSet[E]) Total() int {
+	if s == nil {
+		return 0
+	}
+	return s.total
+}
+
+// Add adds n occurrences of an item to the multiset, and returns its new count.
+//
+// It panics if n is negative.
+func (s *MultiSet[E]) Add(item E, n int) (count int) {
+	if n < 0 {
+		panic(fmt.Errorf("negative multiset count %d", n))
+	}
+	if s == nil {
+		return 0
+	}
+	if n == 0 {
+		return s.counts[item]
+	}
+	s.counts[item] += n
+	s.total += n
+	return s.counts[item]
+}
+
+// Remove removes up to n occurrences of an item from the multiset,
+// and returns the number of occurrences actually removed.
+//
+// It panics if n is negative.
+func (s *MultiSet[E]) Remove(item E, n int) (removed int) {
+	if n < 0 {
+		panic(fmt.Errorf("negative multiset count %d", n))
+	}
+	if s == nil {
+		return 0
+	}
+	count := s.counts[item]
+	removed = min(count, n)
+	if removed == count {
+		delete(s.counts, item)
+	} else {
+		s.counts[item] = count - removed
+	}
+	s.total -= removed
+	return removed
+}
+
+// Count returns the number of occurrences of the item in the multiset.
+func (s *MultiSet[E]) Count(item E) int {
+	if s == nil {
+		return 0
+	}
+	return s.counts[item]
+}
+
+// Contains returns true if the item occurs at least once in the multiset.
+func (s *MultiSet[E]) Contains(item E) bool {
+	return s.Count(item) > 0
+}
+
+// Clear removes all items from the multiset and returns the number of occurrences removed.
+func (s *MultiSet[E]) Clear() (total int) {
+	if s == nil {
+		return 0
+	}
+	total = s.total
+	clear(s.counts)
+	s.total = 0
+	return total
+}
+
+// All returns an iterator over the distinct items in the multiset and their counts, in no specific order.
+func (s *MultiSet[E]) All() iter.Seq2[E, int] {
+	return func(yield func(E, int) bool) {
+		if s == nil {
+			return
+		}
+		for item, count := range s.counts {
+			if !yield(item, count) {
+				return
+			}
+		}
+	}
+}
+
+// Distinct returns a new set holding the distinct items in the multiset.
+func (s *MultiSet[E]) Distinct() container.Set[E] {
+	res := NewBasicMap[E](s.Len())
+	for item := range s.All() {
+		res.Add(item)
+	}
+	return res
+}
+
+// MostCommon returns the k items with the highest counts, by decreasing count.
+// Items with the same count are returned in no specific order.
+//
+// If k is negative or exceeds the number of distinct items, it returns all of them.
+func (s *MultiSet[E]) MostCommon(k int) []ElementCount[E] {
+	res := make([]ElementCount[E], 0, s.Len())
+	for item, count := range s.All() {
+		res = append(res, ElementCount[E]{Item: item, Count: count})
+	}
+	slices.SortFunc(res, func(a, b ElementCount[E]) int {
+		return cmp.Compare(b.Count, a.Count)
+	})
+	if k >= 0 && k < len(res) {
+		res = res[:k]
+	}
+	return res
+}
+
+// Union returns a new multiset holding each item with the maximum of its counts in both multisets.
+func (s *MultiSet[E]) Union(other *MultiSet[E]) *MultiSet[E] {
+	res := s.clone()
+	for item, count := range other.All() {
+		if extra := count - res.counts[item]; extra > 0 {
+			res.Add(item, extra)
+		}
+	}
+	return res
+}
+
+// Sum returns a new multiset holding each item with the sum of its counts in both multisets.
+func (s *MultiSet[E]) Sum(other *MultiSet[E]) *MultiSet[E] {
+	res := s.clone()
+	for item, count := range other.All() {
+		res.Add(item, count)
+	}
+	return res
+}
+
+// Intersection returns a new multiset holding each item with the minimum of its counts in both multisets.
+func (s *MultiSet[E]) Intersection(other *MultiSet[E]) *MultiSet[E] {
+	res := NewMultiSet[E](min(s.Len(), other.Len()))
+	for item, count := range s.All() {
+		if n := min(count, other.Count(item)); n > 0 {
+			res.Add(item, n)
+		}
+	}
+	return res
+}
+
+// Difference returns a new multiset holding each item with its count in this
+// multiset minus its count in the other one, if the result is positive.
+func (s *MultiSet[E]) Difference(other *MultiSet[E]) *MultiSet[E] {
+	res := NewMultiSet[E](s.Len())
+	for item, count := range s.All() {
+		if n := count - other.Count(item); n > 0 {
+			res.Add(item, n)
+		}
+	}
+	return res
+}
+
+// Equal returns true if both multisets hold the same items with the same counts.
+func (s *MultiSet[E]) Equal(other *MultiSet[E]) bool {
+	if s.Len() != other.Len() || s.Total() != other.Total() {
+		return false
+	}
+	for item, count := range s.All() {
+		if other.Count(item) != count {
+			return false
+		}
+	}
+	return true
+}
+
+// clone returns a copy of the multiset. A nil receiver returns an empty multiset.
+func (s *MultiSet[E]) clone() *MultiSet[E] {
+	res := NewMultiSet[E](s.Len())
+	for item, count := range s.All() {
+		res.counts[item] = count
+	}
+	res.total = s.Total()
+	return res
+}
+
+// NewMultiSet returns a ready-for-use MultiSet.
+// The size hint is the expected number of distinct items.
+func NewMultiSet[E comparable](sizeHint int) *MultiSet[E] {
+	return &MultiSet[E]{counts: make(map[E]int, sizeHint)}
+}
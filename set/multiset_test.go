@@ -0,0 +1,163 @@
+package set_test
+
+import (
+	"maps"
+	"testing"
+
+	"github.com/fgm/container"
+	"github.com/fgm/container/set"
+)
+
+// createMultiSet creates a MultiSet holding the elements, each once per occurrence.
+func createMultiSet(tb testing.TB, elements ...string) *set.MultiSet[string] {
+	tb.Helper()
+	s := set.NewMultiSet[string](len(elements))
+	for _, e := range elements {
+		s.Add(e, 1)
+	}
+	return s
+}
+
+func TestMultiSet(t *testing.T) {
+	var ns *set.MultiSet[string]
+	if ns.Len() != 0 || ns.Total() != 0 || ns.Count("a") != 0 || ns.Add("a", 1) != 0 || ns.Remove("a", 1) != 0 || ns.Clear() != 0 || ns.String() != "{}" {
+		t.Errorf("nil multiset should be empty and ignore changes")
+	}
+
+	s := createMultiSet(t, "a", "b", "a")
+	if s.Add("a", 3) != 5 || s.Add("c", 0) != 0 || s.Contains("c") {
+		t.Errorf("Add should return the new count")
+	}
+	if s.Len() != 2 || s.Total() != 6 || s.Count("a") != 5 || !s.Contains("b") {
+		t.Errorf("got %v but expected {a: 5, b: 1}", s)
+	}
+	if s.Remove("a", 2) != 2 || s.Remove("b", 3) != 1 || s.Remove("c", 1) != 0 {
+		t.Errorf("Remove should return the number of occurrences removed")
+	}
+	if s.String() != "{a: 3}" || s.Len() != 1 || s.Total() != 3 || s.Contains("b") {
+		t.Errorf("got %v but expected {a: 3}", s)
+	}
+	if s.Clear() != 3 || s.Len() != 0 || s.Total() != 0 {
+		t.Errorf("Clear should remove all occurrences")
+	}
+
+	for _, f := range [...]func(){func() { s.Add("a", -1) }, func() { s.Remove("a", -1) }} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("negative counts should panic")
+				}
+			}()
+			f()
+		}()
+	}
+}
+
+func TestMultiSet_Views(t *testing.T) {
+	s := createMultiSet(t, "a", "b", "b", "c", "c", "c", "d", "d", "d", "d")
+	distinct := s.Distinct()
+	if distinct.(container.Countable).Len() != 4 {
+		t.Errorf("got %v but expected 4 distinct items", distinct)
+	}
+	for _, e := range []string{"a", "b", "c", "d"} {
+		if !distinct.Contains(e) {
+			t.Errorf("got %v but expected it to contain %s", distinct, e)
+		}
+	}
+	if distinct.Contains("e") {
+		t.Errorf("got %v but expected it not to contain e", distinct)
+	}
+
+	tests := [...]struct {
+		name     string
+		k        int
+		expected []set.ElementCount[string]
+	}{
+		{"zero", 0, []set.ElementCount[string]{}},
+		{"some", 2, []set.ElementCount[string]{{Item: "d", Count: 4}, {Item: "c", Count: 3}}},
+		{"all", -1, []set.ElementCount[string]{{"d", 4}, {"c", 3}, {"b", 2}, {"a", 1}}},
+		{"more", 10, []set.ElementCount[string]{{"d", 4}, {"c", 3}, {"b", 2}, {"a", 1}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := s.MostCommon(test.k)
+			if len(actual) != len(test.expected) {
+				t.Fatalf("got %v but expected %v", actual, test.expected)
+			}
+			for i := range actual {
+				if actual[i] != test.expected[i] {
+					t.Errorf("got %v but expected %v", actual, test.expected)
+				}
+			}
+		})
+	}
+}
+
+func TestMultiSet_Operations(t *testing.T) {
+	a := createMultiSet(t, "a", "a", "a", "b", "c")
+	b := createMultiSet(t, "a", "b", "b", "d")
+	tests := [...]struct {
+		name     string
+		actual   *set.MultiSet[string]
+		expected map[string]int
+	}{
+		{"union", a.Union(b), map[string]int{"a": 3, "b": 2, "c": 1, "d": 1}},
+		{"union with nil", a.Union(nil), map[string]int{"a": 3, "b": 1, "c": 1}},
+		{"sum", a.Sum(b), map[string]int{"a": 4, "b": 3, "c": 1, "d": 1}},
+		{"intersection", a.Intersection(b), map[string]int{"a": 1, "b": 1}},
+		{"intersection with nil", a.Intersection(nil), map[string]int{}},
+		{"difference", a.Difference(b), map[string]int{"a": 2, "c": 1}},
+		{"difference reversed", b.Difference(a), map[string]int{"b": 1, "d": 1}},
+		{"of nil", (*set.MultiSet[string])(nil).Sum(b), map[string]int{"a": 1, "b": 2, "d": 1}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.actual == a || test.actual == b {
+				t.Errorf("operations should not return an operand")
+			}
+			if actual := maps.Collect(test.actual.All()); !maps.Equal(actual, test.expected) {
+				t.Errorf("got %v but expected %v", actual, test.expected)
+			}
+			total := 0
+			for _, count := range test.expected {
+				total += count
+			}
+			if test.actual.Total() != total {
+				t.Errorf("got total %d but expected %d", test.actual.Total(), total)
+			}
+		})
+	}
+	if a.Total() != 5 || b.Total() != 4 {
+		t.Errorf("operations should not modify the operands")
+	}
+	if !a.Equal(createMultiSet(t, "c", "a", "b", "a", "a")) || a.Equal(b) || a.Equal(a.Union(b)) {
+		t.Errorf("Equal should compare items and counts")
+	}
+}
+
+func FuzzMultiSet(f *testing.F) {
+	// Add some seed corpus
+	f.Add([]byte{1, 2, 2}, []byte{2, 3})       // Overlapping
+	f.Add([]byte{1, 1, 1}, []byte{})           // Empty
+	f.Add([]byte{5, 5, 6, 6, 6}, []byte{6, 5}) // Repeated
+
+	f.Fuzz(func(t *testing.T, as, bs []byte) {
+		// Check the multiset identities: A + B = (A ∪ B) + (A ∩ B), and (A - B) + (A ∩ B) = A.
+		a, b := set.NewMultiSet[byte](len(as)), set.NewMultiSet[byte](len(bs))
+		for _, item := range as {
+			a.Add(item, 1)
+		}
+		for _, item := range bs {
+			b.Add(item, 1)
+		}
+		if sum, other := a.Sum(b), a.Union(b).Sum(a.Intersection(b)); !sum.Equal(other) {
+			t.Errorf("got %v but expected %v", other, sum)
+		}
+		if other := a.Difference(b).Sum(a.Intersection(b)); !other.Equal(a) {
+			t.Errorf("got %v but expected %v", other, a)
+		}
+		if a.Total() != len(as) {
+			t.Errorf("got total %d but expected %d", a.Total(), len(as))
+		}
+	})
+}
@@ -0,0 +1,124 @@
+package set
+
+import (
+	"github.com/fgm/container"
+)
+
+// countableSet is implemented by all sets in this package.
+type countableSet[E comparable] interface {
+	ReadOnly[E]
+	container.Countable
+}
+
+// The functions in this file implement the set predicates on top of the
+// ReadOnly API, for all implementations without a more specific algorithm.
+// They consider nil sets as empty.
+
+// isSubset returns true if all elements in s are present in other.
+func isSubset[E comparable](s countableSet[E], other ReadOnly[E]) bool {
+	// Shortcut degenerate cases.
+	if s.Len() == 0 {
+		return true
+	}
+	if other == nil {
+		return false
+	}
+	if other, ok := other.(container.Countable); ok && other.Len() < s.Len() {
+		return false
+	}
+
+	// Non-degenerate case.
+	for item := range s.Items() {
+		if !other.Contains(item) {
+			return false
+		}
+	}
+	return true
+}
+
+// isSuperset returns true if all elements in other are present in s.
+func isSuperset[E comparable](s countableSet[E], other ReadOnly[E]) bool {
+	// Shortcut degenerate cases.
+	if isEmpty(other) {
+		return true
+	}
+	if s.Len() == 0 {
+		return false
+	}
+	if other, ok := other.(container.Countable); ok && other.Len() > s.Len() {
+		return false
+	}
+
+	// Non-degenerate case.
+	for item := range other.Items() {
+		if !s.Contains(item) {
+			return false
+		}
+	}
+	return true
+}
+
+// isProperSubset returns true if s is a subset of other, and other has more elements.
+func isProperSubset[E comparable](s countableSet[E], other ReadOnly[E]) bool {
+	// Shortcut degenerate cases: no set is a proper subset of the empty set.
+	if isEmpty(other) || !isSubset(s, other) {
+		return false
+	}
+	if other, ok := other.(container.Countable); ok {
+		return other.Len() > s.Len()
+	}
+
+	// Non-countable other set: look for an element not in s.
+	for item := range other.Items() {
+		if !s.Contains(item) {
+			return true
+		}
+	}
+	return false
+}
+
+// equal returns true if both sets contain the same elements.
+func equal[E comparable](s countableSet[E], other ReadOnly[E]) bool {
+	if other, ok := other.(container.Countable); ok && other.Len() != s.Len() {
+		return false
+	}
+	return isSubset(s, other) && isSuperset(s, other)
+}
+
+// isDisjoint returns true if the sets have no element in common.
+func isDisjoint[E comparable](s countableSet[E], other ReadOnly[E]) bool {
+	// Shortcut degenerate cases.
+	if s.Len() == 0 || other == nil {
+		return true
+	}
+
+	// Non-degenerate case: iterate on the smaller set if it is known.
+	if countable, ok := other.(container.Countable); ok && countable.Len() < s.Len() {
+		for item := range other.Items() {
+			if s.Contains(item) {
+				return false
+			}
+		}
+		return true
+	}
+	for item := range s.Items() {
+		if other.Contains(item) {
+			return false
+		}
+	}
+	return true
+}
+
+// isEmpty returns true if the set is nil or contains no elements.
+func isEmpty[E comparable](s ReadOnly[E]) bool {
+	if s == nil {
+		return true
+	}
+	if s, ok := s.(container.Countable); ok {
+		return s.Len() == 0
+	}
+	for range s.Items() {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,449 @@
+package set
+
+import (
+	"fmt"
+	"hash/maphash"
+	"iter"
+	"math/bits"
+	"slices"
+	"strings"
+
+	"github.com/fgm/container"
+)
+
+// ReadOnly is the read-only part of container.Set.
+//
+// It is implemented by all sets, including Persistent, which cannot implement
+// container.Set since it is immutable.
+type ReadOnly[E comparable] interface {
+	Contains(item E) bool
+	Items() iter.Seq[E]
+}
+
+// Persistent is an immutable set, implemented as a hash array mapped trie (HAMT).
+//
+// Since it cannot be modified, it does not implement container.Set, only ReadOnly:
+// its set operations and predicates accept any set, but other sets only accept
+// a container.Set, so combine them with Persistent methods.
+//
+// Its With and Without methods, and its union/intersection/difference operations,
+// return new versions of the set, sharing most of their structure with the
+// receiver, which is never modified. Since no version is ever modified,
+// they are concurrency-safe, and may be shared without cloning.
+//
+// The zero value and nil are usable empty sets.
+type Persistent[E comparable] struct {
+	root *hamtNode[E] // nil for the empty set
+	len  int
+}
+
+// String returns an idiomatic unordered representation of the set items.
+func (s *Persistent[E]) String() string {
+	// Shortcut empty case.
+	if s.Len() == 0 {
+		return "{}"
+	}
+
+	var b strings.Builder
+	b.WriteByte('{')
+	i := 0
+	for item := range s.Items() {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		_, _ = fmt.Fprintf(&b, "%v", item)
+		i++
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// Len returns the number of items in the Set.
+func (s *Persistent[E]) Len() int {
+	if s == nil {
+		return 0
+	}
+	return s.len
+}
+
+// Contains returns true if the item is present in the set.
+func (s *Persistent[E]) Contains(item E) bool {
+	if s.Len() == 0 {
+		return false
+	}
+	return s.root.contains(hash(item), item)
+}
+
+// Items returns an unordered iterator over the set elements.
+func (s *Persistent[E]) Items() iter.Seq[E] {
+	return func(yield func(E) bool) {
+		if s.Len() == 0 {
+			return
+		}
+		s.root.all(yield)
+	}
+}
+
+// With returns a version of the set including item.
+//
+// If the item was already present, it returns the receiver.
+func (s *Persistent[E]) With(item E) *Persistent[E] {
+	res, _ := s.with(item)
+	return res
+}
+
+// Without returns a version of the set excluding item.
+//
+// If the item was not present, it returns the receiver.
+func (s *Persistent[E]) Without(item E) *Persistent[E] {
+	res, _ := s.without(item)
+	return res
+}
+
+// Union returns a set containing elements present in either set.
+//
+// Note that, the result being immutable, it may be the receiver if other adds no element.
+func (s *Persistent[E]) Union(other ReadOnly[E]) *Persistent[E] {
+	// Shortcut degenerate cases.
+	if isEmpty(other) {
+		return s.self()
+	}
+
+	// Non-degenerate case: insert the elements of the smaller set into the larger one.
+	res := s.self()
+	if p, ok := other.(*Persistent[E]); ok && p.Len() > res.Len() {
+		res, other = p, res
+	}
+	for item := range other.Items() {
+		res, _ = res.with(item)
+	}
+	return res
+}
+
+// Intersection returns a set containing elements present in both sets.
+func (s *Persistent[E]) Intersection(other ReadOnly[E]) *Persistent[E] {
+	// Shortcut degenerate cases.
+	if s.Len() == 0 || other == nil {
+		return &Persistent[E]{}
+	}
+
+	// Non-degenerate case: build from the smaller set if it is known,
+	// otherwise remove the elements of the receiver missing from other.
+	if countable, ok := other.(container.Countable); ok && countable.Len() < s.Len()/2 {
+		res := &Persistent[E]{}
+		for item := range other.Items() {
+			if s.Contains(item) {
+				res, _ = res.with(item)
+			}
+		}
+		return res
+	}
+	res := s
+	for item := range s.Items() {
+		if !other.Contains(item) {
+			res, _ = res.without(item)
+		}
+	}
+	return res
+}
+
+// Difference returns a set containing elements present in this set but not in the other.
+func (s *Persistent[E]) Difference(other ReadOnly[E]) *Persistent[E] {
+	// Shortcut degenerate cases.
+	if s.Len() == 0 || isEmpty(other) {
+		return s.self()
+	}
+
+	// Non-degenerate case: iterate on the smaller set if it is known.
+	res := s
+	if countable, ok := other.(container.Countable); ok && countable.Len() < s.Len() {
+		for item := range other.Items() {
+			res, _ = res.without(item)
+		}
+		return res
+	}
+	for item := range s.Items() {
+		if other.Contains(item) {
+			res, _ = res.without(item)
+		}
+	}
+	return res
+}
+
+// SymmetricDifference returns a set containing elements present in either set but not in both.
+func (s *Persistent[E]) SymmetricDifference(other ReadOnly[E]) *Persistent[E] {
+	// Shortcut degenerate cases.
+	if isEmpty(other) {
+		return s.self()
+	}
+
+	// Non-degenerate case.
+	res := s.self()
+	for item := range other.Items() {
+		if s.Contains(item) {
+			res, _ = res.without(item)
+		} else {
+			res, _ = res.with(item)
+		}
+	}
+	return res
+}
+
+// IsSubsetOf returns true if all elements in this set are present in the other.
+//
+// The empty set is a subset of any set, and nil sets are considered empty.
+func (s *Persistent[E]) IsSubsetOf(other ReadOnly[E]) bool {
+	return isSubset(s, other)
+}
+
+// IsSupersetOf returns true if all elements in the other set are present in this one.
+func (s *Persistent[E]) IsSupersetOf(other ReadOnly[E]) bool {
+	return isSuperset(s, other)
+}
+
+// IsProperSubsetOf returns true if this set is a subset of the other, and the
+// other contains at least one element not in this set.
+func (s *Persistent[E]) IsProperSubsetOf(other ReadOnly[E]) bool {
+	return isProperSubset(s, other)
+}
+
+// Equal returns true if both sets contain the same elements.
+func (s *Persistent[E]) Equal(other ReadOnly[E]) bool {
+	return equal(s, other)
+}
+
+// IsDisjoint returns true if the sets have no element in common.
+func (s *Persistent[E]) IsDisjoint(other ReadOnly[E]) bool {
+	return isDisjoint(s, other)
+}
+
+// self returns the receiver, or an empty set if it is nil.
+func (s *Persistent[E]) self() *Persistent[E] {
+	if s == nil {
+		return &Persistent[E]{}
+	}
+	return s
+}
+
+// with returns a version of the set including item, and whether it was added.
+func (s *Persistent[E]) with(item E) (*Persistent[E], bool) {
+	s = s.self()
+	root := s.root
+	if root == nil {
+		root = &hamtNode[E]{}
+	}
+	root, added := root.with(hash(item), 0, item)
+	if !added {
+		return s, false
+	}
+	return &Persistent[E]{root: root, len: s.len + 1}, true
+}
+
+// without returns a version of the set excluding item, and whether it was removed.
+func (s *Persistent[E]) without(item E) (*Persistent[E], bool) {
+	s = s.self()
+	if s.len == 0 {
+		return s, false
+	}
+	root, removed := s.root.without(hash(item), 0, item)
+	if !removed {
+		return s, false
+	}
+	if s.len == 1 {
+		return &Persistent[E]{}, true
+	}
+	return &Persistent[E]{root: root, len: s.len - 1}, true
+}
+
+// NewPersistent returns an immutable set containing the items.
+func NewPersistent[E comparable](items ...E) *Persistent[E] {
+	s := &Persistent[E]{}
+	for _, item := range items {
+		s, _ = s.with(item)
+	}
+	return s
+}
+
+const (
+	hamtBits = 5               // Bits of the hash used at each level of the trie
+	hamtMask = 1<<hamtBits - 1 // Mask extracting these bits
+)
+
+var seed = maphash.MakeSeed()
+
+func hash[E comparable](item E) uint64 {
+	return maphash.Comparable(seed, item)
+}
+
+// hamtNode is a node in the trie. Nodes are never modified once built.
+//
+// A branch node holds up to 32 children, one per value of the hash bits at its
+// level, in a compressed slice indexed by the population count of the bitmap.
+//
+// A collision node holds the items sharing the same full hash, in which case
+// its bitmap and children are unused.
+type hamtNode[E comparable] struct {
+	bitmap   uint32
+	children []hamtChild[E]
+
+	hash       uint64
+	collisions []E
+}
+
+// hamtChild is either a leaf holding an item and its hash, or a sub-node.
+type hamtChild[E comparable] struct {
+	node *hamtNode[E] // nil for a leaf
+	hash uint64
+	item E
+}
+
+// position returns the bitmap bit for the hash at the shift level, and the index of the matching child.
+func (n *hamtNode[E]) position(h uint64, shift uint) (bit uint32, index int) {
+	bit = 1 << ((h >> shift) & hamtMask)
+	return bit, bits.OnesCount32(n.bitmap & (bit - 1))
+}
+
+func (n *hamtNode[E]) contains(h uint64, item E) bool {
+	for shift := uint(0); ; shift += hamtBits {
+		if n.collisions != nil {
+			return n.hash == h && slices.Contains(n.collisions, item)
+		}
+		bit, i := n.position(h, shift)
+		if n.bitmap&bit == 0 {
+			return false
+		}
+		c := n.children[i]
+		if c.node == nil {
+			return c.item == item
+		}
+		n = c.node
+	}
+}
+
+func (n *hamtNode[E]) all(yield func(E) bool) bool {
+	for _, item := range n.collisions {
+		if !yield(item) {
+			return false
+		}
+	}
+	for _, c := range n.children {
+		if c.node != nil {
+			if !c.node.all(yield) {
+				return false
+			}
+		} else if !yield(c.item) {
+			return false
+		}
+	}
+	return true
+}
+
+// with returns a node including item, copying the path to its position.
+func (n *hamtNode[E]) with(h uint64, shift uint, item E) (*hamtNode[E], bool) {
+	if n.collisions != nil {
+		if h != n.hash {
+			return branch(shift, n.hash, hamtChild[E]{node: n}, h, hamtChild[E]{hash: h, item: item}), true
+		}
+		if slices.Contains(n.collisions, item) {
+			return n, false
+		}
+		return &hamtNode[E]{hash: h, collisions: append(slices.Clip(n.collisions), item)}, true
+	}
+
+	bit, i := n.position(h, shift)
+	leaf := hamtChild[E]{hash: h, item: item}
+	if n.bitmap&bit == 0 {
+		children := make([]hamtChild[E], len(n.children)+1)
+		copy(children, n.children[:i])
+		children[i] = leaf
+		copy(children[i+1:], n.children[i:])
+		return &hamtNode[E]{bitmap: n.bitmap | bit, children: children}, true
+	}
+
+	c := n.children[i]
+	switch {
+	case c.node != nil:
+		node, added := c.node.with(h, shift+hamtBits, item)
+		if !added {
+			return n, false
+		}
+		c = hamtChild[E]{node: node}
+	case c.item == item:
+		return n, false
+	case c.hash == h:
+		c = hamtChild[E]{node: &hamtNode[E]{hash: h, collisions: []E{c.item, item}}}
+	default:
+		c = hamtChild[E]{node: branch(shift+hamtBits, c.hash, c, h, leaf)}
+	}
+	return n.replace(i, c), true
+}
+
+// without returns a node excluding item, copying the path to its position.
+//
+// The returned node is nil if it would be empty.
+func (n *hamtNode[E]) without(h uint64, shift uint, item E) (*hamtNode[E], bool) {
+	if n.collisions != nil {
+		i := slices.Index(n.collisions, item)
+		if h != n.hash || i < 0 {
+			return n, false
+		}
+		return &hamtNode[E]{hash: h, collisions: slices.Delete(slices.Clone(n.collisions), i, i+1)}, true
+	}
+
+	bit, i := n.position(h, shift)
+	if n.bitmap&bit == 0 {
+		return n, false
+	}
+	c := n.children[i]
+	if c.node == nil {
+		if c.item != item {
+			return n, false
+		}
+		if len(n.children) == 1 {
+			return nil, true
+		}
+		return &hamtNode[E]{bitmap: n.bitmap &^ bit, children: slices.Delete(slices.Clone(n.children), i, i+1)}, true
+	}
+
+	node, removed := c.node.without(h, shift+hamtBits, item)
+	if !removed {
+		return n, false
+	}
+	// Sub-nodes always hold at least two items, so node cannot be nil, but it
+	// may be a single leaf to pull up, keeping the trie as shallow as possible.
+	if leaf, ok := node.single(); ok {
+		return n.replace(i, leaf), true
+	}
+	return n.replace(i, hamtChild[E]{node: node}), true
+}
+
+// replace returns a copy of the node with its i-th child replaced.
+func (n *hamtNode[E]) replace(i int, c hamtChild[E]) *hamtNode[E] {
+	children := slices.Clone(n.children)
+	children[i] = c
+	return &hamtNode[E]{bitmap: n.bitmap, children: children}
+}
+
+// single returns the only leaf in the node, if it holds a single item.
+func (n *hamtNode[E]) single() (hamtChild[E], bool) {
+	switch {
+	case len(n.collisions) == 1:
+		return hamtChild[E]{hash: n.hash, item: n.collisions[0]}, true
+	case n.collisions == nil && len(n.children) == 1 && n.children[0].node == nil:
+		return n.children[0], true
+	default:
+		return hamtChild[E]{}, false
+	}
+}
+
+// branch builds a node at the shift level holding two children with different hashes.
+func branch[E comparable](shift uint, h1 uint64, c1 hamtChild[E], h2 uint64, c2 hamtChild[E]) *hamtNode[E] {
+	b1, b2 := (h1>>shift)&hamtMask, (h2>>shift)&hamtMask
+	switch {
+	case b1 == b2:
+		return &hamtNode[E]{bitmap: 1 << b1, children: []hamtChild[E]{{node: branch(shift+hamtBits, h1, c1, h2, c2)}}}
+	case b1 > b2:
+		c1, c2 = c2, c1
+	}
+	return &hamtNode[E]{bitmap: 1<<b1 | 1<<b2, children: []hamtChild[E]{c1, c2}}
+}
@@ -0,0 +1,177 @@
+package set_test
+
+import (
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/fgm/container"
+	"github.com/fgm/container/set"
+)
+
+func TestPersistent_Versions(t *testing.T) {
+	var empty *set.Persistent[int]
+	if empty.Len() != 0 || empty.Contains(1) || empty.String() != "{}" {
+		t.Errorf("nil set should be empty")
+	}
+	v1 := empty.With(1)
+	v2 := v1.With(2)
+	v3 := v2.Without(1)
+
+	tests := [...]struct {
+		name     string
+		version  *set.Persistent[int]
+		expected []int
+	}{
+		{"v1", v1, []int{1}},
+		{"v2", v2, []int{1, 2}},
+		{"v3", v3, []int{2}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := slices.Sorted(test.version.Items()); !slices.Equal(actual, test.expected) {
+				t.Errorf("got %v but expected %v", actual, test.expected)
+			}
+			if test.version.Len() != len(test.expected) {
+				t.Errorf("got length %d but expected %d", test.version.Len(), len(test.expected))
+			}
+		})
+	}
+
+	// No-op changes return the receiver.
+	if v2.With(1) != v2 || v2.Without(3) != v2 {
+		t.Errorf("no-op changes should return the receiver")
+	}
+	if v3.Without(2).Len() != 0 {
+		t.Errorf("removing the last element should return an empty set")
+	}
+}
+
+func TestPersistent_Immutable(t *testing.T) {
+	// Code modifying a container.Set cannot be handed a Persistent, while code reading sets can.
+	var s any = set.NewPersistent(1, 2)
+	if _, ok := s.(container.Set[int]); ok {
+		t.Errorf("persistent set should not implement container.Set")
+	}
+	if _, ok := s.(set.ReadOnly[int]); !ok {
+		t.Errorf("persistent set should implement set.ReadOnly")
+	}
+}
+
+func TestPersistent_SetOperations(t *testing.T) {
+	const size = 1000
+	evens, odds, small := set.NewPersistent[int](), set.NewPersistent[int](), set.NewPersistent(1, 2, 3)
+	for i := range size {
+		if i%2 == 0 {
+			evens = evens.With(i)
+		} else {
+			odds = odds.With(i)
+		}
+	}
+	all := evens.Union(odds)
+	other := createSet(t, 1, 2, 3)
+
+	tests := [...]struct {
+		name     string
+		actual   *set.Persistent[int]
+		expected int
+	}{
+		{"union", all, size},
+		{"union with basic", evens.Union(other), size/2 + 2},
+		{"union from empty", set.NewPersistent[int]().Union(other), 3},
+		{"intersection", all.Intersection(evens), size / 2},
+		{"intersection of small", small.Intersection(all), 3},
+		{"intersection with small", all.Intersection(small), 3},
+		{"intersection with nil", all.Intersection(nil), 0},
+		{"difference", all.Difference(evens), size / 2},
+		{"difference with small", all.Difference(small), size - 3},
+		{"difference with basic", evens.Difference(other), size/2 - 1},
+		{"symmetric difference", evens.SymmetricDifference(small), size/2 - 1 + 2},
+		{"symmetric difference with nil", evens.SymmetricDifference(nil), size / 2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := test.actual.Len(); actual != test.expected {
+				t.Errorf("got length %d but expected %d", actual, test.expected)
+			}
+		})
+	}
+
+	// Operands are unchanged.
+	if evens.Len() != size/2 || odds.Len() != size/2 || small.Len() != 3 {
+		t.Errorf("operands should not be modified")
+	}
+	if !all.Equal(evens.Union(odds)) || !all.IsSupersetOf(evens) || !evens.IsProperSubsetOf(all) || !evens.IsDisjoint(odds) {
+		t.Errorf("predicates do not match the set operations")
+	}
+}
+
+func TestPersistent_Concurrency(t *testing.T) {
+	base := set.NewPersistent[int]()
+	for i := range 100 {
+		base = base.With(i)
+	}
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Each goroutine derives its own versions from the shared one.
+			s := base
+			for i := range 100 {
+				s = s.Without(i).With(1000*(g+1) + i)
+				if !base.Contains(i) {
+					t.Errorf("shared version was modified")
+				}
+			}
+			if s.Len() != 100 || !s.IsDisjoint(base) {
+				t.Errorf("got %v, expected 100 elements disjoint from the base", s)
+			}
+		}()
+	}
+	wg.Wait()
+	if base.Len() != 100 {
+		t.Errorf("got length %d but expected 100", base.Len())
+	}
+}
+
+func BenchmarkPersistent_With(b *testing.B) {
+	s := set.NewPersistent[int]()
+	for i := range 1 << 16 {
+		s = s.With(i)
+	}
+	b.ResetTimer()
+	for i := range b.N {
+		_ = s.With(-i - 1)
+	}
+}
+
+func FuzzPersistent(f *testing.F) {
+	// Add some seed corpus
+	f.Add([]byte{1, 2, 3, 2, 1})        // Adds, then removes
+	f.Add([]byte{0, 0, 0})              // Only duplicates
+	f.Add([]byte{255, 128, 127, 0, 64}) // Spread values
+
+	f.Fuzz(func(t *testing.T, ops []byte) {
+		// Compare with a BasicMap model: odd values remove their half, even ones add it.
+		model := set.NewBasicMap[int](len(ops))
+		s := set.NewPersistent[int]()
+		for _, op := range ops {
+			item := int(op / 2)
+			prev, present := s, s.Contains(item)
+			if op%2 == 0 {
+				model.Add(item)
+				s = s.With(item)
+			} else {
+				model.Remove(item)
+				s = s.Without(item)
+			}
+			if prev.Contains(item) != present {
+				t.Errorf("previous version was modified by %d", op)
+			}
+		}
+		if !s.Equal(model) {
+			t.Errorf("got %v but expected %v", s, model)
+		}
+	})
+}
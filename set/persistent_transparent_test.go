@@ -0,0 +1,77 @@
+package set
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestHAMT_Collisions checks the trie with forced hashes, since actual
+// collisions of 64-bit hashes cannot be produced on demand.
+func TestHAMT_Collisions(t *testing.T) {
+	const (
+		shared  = 0x1234_5678_9abc_def0     // Full collision for items 1 and 2
+		partial = shared ^ 1<<(12*hamtBits) // Same path as shared down to the last level
+		other   = shared ^ 1                // Different at the first level
+	)
+	items := [...]struct {
+		hash uint64
+		item int
+	}{
+		{shared, 1},
+		{shared, 2},
+		{partial, 3},
+		{other, 4},
+	}
+
+	root := &hamtNode[int]{}
+	for _, it := range items {
+		var added bool
+		if root, added = root.with(it.hash, 0, it.item); !added {
+			t.Fatalf("item %d should have been added", it.item)
+		}
+		if _, added = root.with(it.hash, 0, it.item); added {
+			t.Errorf("item %d should not be added twice", it.item)
+		}
+	}
+	for _, it := range items {
+		if !root.contains(it.hash, it.item) {
+			t.Errorf("item %d should be present", it.item)
+		}
+	}
+	if root.contains(shared, 3) || root.contains(partial, 1) {
+		t.Errorf("items should only be found under their own hash")
+	}
+	all := func(n *hamtNode[int]) []int {
+		var res []int
+		n.all(func(i int) bool { res = append(res, i); return true })
+		slices.Sort(res)
+		return res
+	}
+	if actual := all(root); !slices.Equal(actual, []int{1, 2, 3, 4}) {
+		t.Errorf("got %v but expected [1 2 3 4]", actual)
+	}
+
+	// Removing items collapses the collision node and the branches leading to it.
+	full := root
+	for _, it := range items[:3] {
+		var removed bool
+		if root, removed = root.without(it.hash, 0, it.item); !removed {
+			t.Fatalf("item %d should have been removed", it.item)
+		}
+		if _, removed = root.without(it.hash, 0, it.item); removed {
+			t.Errorf("item %d should not be removed twice", it.item)
+		}
+	}
+	if actual := all(root); !slices.Equal(actual, []int{4}) {
+		t.Errorf("got %v but expected [4]", actual)
+	}
+	if len(root.children) != 1 || root.children[0].node != nil {
+		t.Errorf("the remaining item should be a leaf of the root")
+	}
+	if actual := all(full); !slices.Equal(actual, []int{1, 2, 3, 4}) {
+		t.Errorf("previous version was modified: got %v", actual)
+	}
+	if root, _ = root.without(other, 0, 4); root != nil {
+		t.Errorf("empty node should be nil")
+	}
+}
@@ -0,0 +1,326 @@
+package set
+
+import (
+	"fmt"
+	"iter"
+	"slices"
+	"strings"
+
+	"github.com/fgm/container"
+)
+
+// Roaring is a compressed set of uint32, implemented as a roaring bitmap.
+//
+// Elements are partitioned by their high 16 bits, and the low 16 bits of each
+// partition are stored in the most compact of a sorted array, a bitmap, or a
+// list of runs of consecutive values. This makes it suitable for large sparse
+// sets, like posting lists holding millions of IDs.
+//
+// Set operations between two Roaring sets work chunk by chunk, merging arrays
+// and combining bitmaps a word at a time. With other set implementations,
+// they fall back to element-by-element operations.
+//
+// It iterates its elements in increasing order, and its MarshalBinary and
+// UnmarshalBinary methods use the portable roaring format, for interoperability
+// with other roaring implementations.
+//
+// It is not concurrency-safe.
+// Unlike BasicMap, its union/intersection/difference operations always return a new Roaring set.
+type Roaring struct {
+	keys   []uint16 // Sorted high 16 bits of the elements in each chunk
+	chunks []chunk  // Never empty
+}
+
+// String returns the set items in increasing order.
+func (s *Roaring) String() string {
+	var b strings.Builder
+	b.WriteByte('{')
+	i := 0
+	for item := range s.Items() {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		_, _ = fmt.Fprintf(&b, "%v", item)
+		i++
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// Len returns the number of items in the Set.
+func (s *Roaring) Len() int {
+	if s == nil {
+		return 0
+	}
+	n := 0
+	for _, c := range s.chunks {
+		n += c.card()
+	}
+	return n
+}
+
+// Add adds an item to the set. Returns true if the item was already present.
+func (s *Roaring) Add(item uint32) (found bool) {
+	if s == nil {
+		return false
+	}
+	key, low := split(item)
+	i, ok := slices.BinarySearch(s.keys, key)
+	if !ok {
+		s.keys = slices.Insert(s.keys, i, key)
+		s.chunks = slices.Insert(s.chunks, i, chunk(arrayChunk{low}))
+		return false
+	}
+	c, added := s.chunks[i].add(low)
+	s.chunks[i] = c
+	return !added
+}
+
+// Remove removes an item from the set.
+// It does not fail if the item was not present, and returns true if it was.
+func (s *Roaring) Remove(item uint32) (found bool) {
+	if s == nil {
+		return false
+	}
+	key, low := split(item)
+	i, ok := slices.BinarySearch(s.keys, key)
+	if !ok {
+		return false
+	}
+	c, removed := s.chunks[i].remove(low)
+	if c.card() == 0 {
+		s.keys = slices.Delete(s.keys, i, i+1)
+		s.chunks = slices.Delete(s.chunks, i, i+1)
+	} else {
+		s.chunks[i] = c
+	}
+	return removed
+}
+
+// Contains returns true if the item is present in the set.
+func (s *Roaring) Contains(item uint32) bool {
+	if s == nil {
+		return false
+	}
+	key, low := split(item)
+	i, ok := slices.BinarySearch(s.keys, key)
+	return ok && s.chunks[i].contains(low)
+}
+
+// Clear removes all items from the set and returns the number of items removed.
+func (s *Roaring) Clear() (count int) {
+	if s == nil {
+		return 0
+	}
+	count = s.Len()
+	s.keys, s.chunks = nil, nil
+	return count
+}
+
+// Items returns an iterator over the set elements, in increasing order.
+func (s *Roaring) Items() iter.Seq[uint32] {
+	return func(yield func(uint32) bool) {
+		if s == nil {
+			return
+		}
+		for i, c := range s.chunks {
+			high := uint32(s.keys[i]) << 16
+			if !c.all(func(low uint16) bool { return yield(high | uint32(low)) }) {
+				return
+			}
+		}
+	}
+}
+
+// RunOptimize converts the chunks of the set to lists of runs of consecutive
+// values where this makes them smaller, and returns true if it converted any.
+//
+// Adding or removing elements in a chunk converts it back to an array or a bitmap.
+func (s *Roaring) RunOptimize() bool {
+	if s == nil {
+		return false
+	}
+	converted := false
+	for i, c := range s.chunks {
+		s.chunks[i] = optimize(c)
+		if _, ok := s.chunks[i].(runChunk); ok {
+			converted = true
+		}
+	}
+	return converted
+}
+
+// Union returns a new set containing elements present in either set.
+func (s *Roaring) Union(other container.Set[uint32]) container.Set[uint32] {
+	if o, ok := other.(*Roaring); ok {
+		return s.combine(o, opOr)
+	}
+	res := s.clone()
+	if other != nil {
+		for item := range other.Items() {
+			res.Add(item)
+		}
+	}
+	return res
+}
+
+// Intersection returns a new set containing elements present in both sets.
+func (s *Roaring) Intersection(other container.Set[uint32]) container.Set[uint32] {
+	if o, ok := other.(*Roaring); ok {
+		return s.combine(o, opAnd)
+	}
+	return s.filter(other, true)
+}
+
+// Difference returns a new set containing elements present in this set but not in the other.
+func (s *Roaring) Difference(other container.Set[uint32]) container.Set[uint32] {
+	if o, ok := other.(*Roaring); ok {
+		return s.combine(o, opAndNot)
+	}
+	return s.filter(other, false)
+}
+
+// SymmetricDifference returns a new set containing elements present in either set but not in both.
+func (s *Roaring) SymmetricDifference(other container.Set[uint32]) container.Set[uint32] {
+	if o, ok := other.(*Roaring); ok {
+		return s.combine(o, opXor)
+	}
+	res := s.clone()
+	if other != nil {
+		for item := range other.Items() {
+			if !res.Remove(item) {
+				res.Add(item)
+			}
+		}
+	}
+	return res
+}
+
+// IsSubsetOf returns true if all elements in this set are present in the other.
+//
+// The empty set is a subset of any set, and nil sets are considered empty.
+func (s *Roaring) IsSubsetOf(other container.Set[uint32]) bool {
+	if o, ok := other.(*Roaring); ok {
+		return s.combine(o, opAndNot).Len() == 0
+	}
+	return isSubset(s, other)
+}
+
+// IsSupersetOf returns true if all elements in the other set are present in this one.
+func (s *Roaring) IsSupersetOf(other container.Set[uint32]) bool {
+	if o, ok := other.(*Roaring); ok {
+		return o.IsSubsetOf(s)
+	}
+	return isSuperset(s, other)
+}
+
+// IsProperSubsetOf returns true if this set is a subset of the other, and the
+// other contains at least one element not in this set.
+func (s *Roaring) IsProperSubsetOf(other container.Set[uint32]) bool {
+	if o, ok := other.(*Roaring); ok {
+		return s.IsSubsetOf(o) && o.Len() > s.Len()
+	}
+	return isProperSubset(s, other)
+}
+
+// Equal returns true if both sets contain the same elements.
+func (s *Roaring) Equal(other container.Set[uint32]) bool {
+	if o, ok := other.(*Roaring); ok {
+		return s.Len() == o.Len() && s.IsSubsetOf(o)
+	}
+	return equal(s, other)
+}
+
+// IsDisjoint returns true if the sets have no element in common.
+func (s *Roaring) IsDisjoint(other container.Set[uint32]) bool {
+	if o, ok := other.(*Roaring); ok {
+		return s.combine(o, opAnd).Len() == 0
+	}
+	return isDisjoint(s, other)
+}
+
+// clone returns a deep copy of the set. A nil receiver returns an empty set.
+func (s *Roaring) clone() *Roaring {
+	res := &Roaring{}
+	if s == nil {
+		return res
+	}
+	res.keys = slices.Clone(s.keys)
+	res.chunks = make([]chunk, len(s.chunks))
+	for i, c := range s.chunks {
+		res.chunks[i] = c.clone()
+	}
+	return res
+}
+
+// combine applies the operation chunk by chunk, merging the sorted keys of both sets.
+func (s *Roaring) combine(other *Roaring, op setOp) *Roaring {
+	s, other = s.self(), other.self()
+	res := &Roaring{}
+	i, j := 0, 0
+	for i < len(s.keys) && j < len(other.keys) {
+		switch ki, kj := s.keys[i], other.keys[j]; {
+		case ki < kj:
+			if op.keepsLeft() {
+				res.append(ki, s.chunks[i].clone())
+			}
+			i++
+		case ki > kj:
+			if op.keepsRight() {
+				res.append(kj, other.chunks[j].clone())
+			}
+			j++
+		default:
+			if c := combine(s.chunks[i], other.chunks[j], op); c != nil {
+				res.append(ki, c)
+			}
+			i++
+			j++
+		}
+	}
+	if op.keepsLeft() {
+		for ; i < len(s.keys); i++ {
+			res.append(s.keys[i], s.chunks[i].clone())
+		}
+	}
+	if op.keepsRight() {
+		for ; j < len(other.keys); j++ {
+			res.append(other.keys[j], other.chunks[j].clone())
+		}
+	}
+	return res
+}
+
+// filter returns a new set with the elements of this set which are, or are not, in the other.
+func (s *Roaring) filter(other container.Set[uint32], in bool) *Roaring {
+	res := &Roaring{}
+	for item := range s.Items() {
+		if (other != nil && other.Contains(item)) == in {
+			res.Add(item)
+		}
+	}
+	return res
+}
+
+func (s *Roaring) append(key uint16, c chunk) {
+	s.keys = append(s.keys, key)
+	s.chunks = append(s.chunks, c)
+}
+
+// self returns the receiver, or an empty set if it is nil.
+func (s *Roaring) self() *Roaring {
+	if s == nil {
+		return &Roaring{}
+	}
+	return s
+}
+
+// split returns the high and low 16 bits of an element.
+func split(item uint32) (key, low uint16) {
+	return uint16(item >> 16), uint16(item)
+}
+
+// NewRoaring returns a ready-for-use container.Set implemented by the Roaring type.
+func NewRoaring() container.Set[uint32] {
+	return &Roaring{}
+}
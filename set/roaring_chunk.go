@@ -0,0 +1,338 @@
+package set
+
+import (
+	"math/bits"
+	"slices"
+)
+
+// The chunks of a Roaring set are the "containers" of the roaring bitmap
+// literature, holding the low 16 bits of the elements sharing the same high 16 bits.
+// They are renamed here to avoid confusion with the container package.
+//
+// Non-run chunks follow the roaring invariant: a chunk with up to arrayMaxCard
+// elements is an array, a larger one is a bitmap. Run chunks are only created
+// by Roaring.RunOptimize and deserialization, and modifying them converts them
+// back to an array or a bitmap.
+
+const (
+	arrayMaxCard = 4096               // Larger chunks are bitmaps
+	bitmapWords  = 1 << 16 / wordBits // Words in a bitmap chunk
+	bitmapBytes  = bitmapWords * 8    // Size of a bitmap chunk
+)
+
+type chunk interface {
+	card() int
+	contains(x uint16) bool
+	// add returns the chunk including x, possibly converted, and whether x was added.
+	add(x uint16) (chunk, bool)
+	// remove returns the chunk excluding x, possibly converted, and whether x was removed.
+	remove(x uint16) (chunk, bool)
+	// all yields the elements in increasing order, and returns false if yield did.
+	all(yield func(uint16) bool) bool
+	clone() chunk
+	bitmap() *bitmapChunk // May return the receiver, which must then not be modified
+}
+
+// arrayChunk is a sorted array of elements.
+type arrayChunk []uint16
+
+func (a arrayChunk) card() int { return len(a) }
+
+func (a arrayChunk) contains(x uint16) bool {
+	_, found := slices.BinarySearch(a, x)
+	return found
+}
+
+func (a arrayChunk) add(x uint16) (chunk, bool) {
+	i, found := slices.BinarySearch(a, x)
+	if found {
+		return a, false
+	}
+	if len(a) == arrayMaxCard {
+		return a.bitmap().add(x)
+	}
+	return slices.Insert(a, i, x), true
+}
+
+func (a arrayChunk) remove(x uint16) (chunk, bool) {
+	i, found := slices.BinarySearch(a, x)
+	if !found {
+		return a, false
+	}
+	return slices.Delete(a, i, i+1), true
+}
+
+func (a arrayChunk) all(yield func(uint16) bool) bool {
+	for _, x := range a {
+		if !yield(x) {
+			return false
+		}
+	}
+	return true
+}
+
+func (a arrayChunk) clone() chunk { return slices.Clone(a) }
+
+func (a arrayChunk) bitmap() *bitmapChunk {
+	b := &bitmapChunk{n: len(a)}
+	for _, x := range a {
+		b.words[x/wordBits] |= 1 << (x % wordBits)
+	}
+	return b
+}
+
+// bitmapChunk is a bitmap of the 65536 possible elements, with its cardinality.
+type bitmapChunk struct {
+	words [bitmapWords]uint64
+	n     int
+}
+
+func (b *bitmapChunk) card() int { return b.n }
+
+func (b *bitmapChunk) contains(x uint16) bool {
+	return b.words[x/wordBits]&(1<<(x%wordBits)) != 0
+}
+
+func (b *bitmapChunk) add(x uint16) (chunk, bool) {
+	w, mask := &b.words[x/wordBits], uint64(1)<<(x%wordBits)
+	if *w&mask != 0 {
+		return b, false
+	}
+	*w |= mask
+	b.n++
+	return b, true
+}
+
+func (b *bitmapChunk) remove(x uint16) (chunk, bool) {
+	w, mask := &b.words[x/wordBits], uint64(1)<<(x%wordBits)
+	if *w&mask == 0 {
+		return b, false
+	}
+	*w &^= mask
+	b.n--
+	if b.n <= arrayMaxCard {
+		return b.array(), true
+	}
+	return b, true
+}
+
+func (b *bitmapChunk) all(yield func(uint16) bool) bool {
+	for i, w := range b.words[:] {
+		for w != 0 {
+			if !yield(uint16(i*wordBits + bits.TrailingZeros64(w))) {
+				return false
+			}
+			w &= w - 1
+		}
+	}
+	return true
+}
+
+func (b *bitmapChunk) clone() chunk {
+	c := *b
+	return &c
+}
+
+func (b *bitmapChunk) bitmap() *bitmapChunk { return b }
+
+func (b *bitmapChunk) array() arrayChunk {
+	a := make(arrayChunk, 0, b.n)
+	b.all(func(x uint16) bool {
+		a = append(a, x)
+		return true
+	})
+	return a
+}
+
+// runs returns the number of runs of consecutive elements in the bitmap:
+// a run starts at each bit set whose preceding bit is not.
+func (b *bitmapChunk) runs() int {
+	n := 0
+	var carry uint64
+	for _, w := range b.words[:] {
+		n += bits.OnesCount64(w &^ (w<<1 | carry))
+		carry = w >> (wordBits - 1)
+	}
+	return n
+}
+
+// run is an interval of consecutive elements, bounds included.
+type run struct {
+	start, last uint16
+}
+
+// runChunk is a sorted list of disjoint runs.
+type runChunk []run
+
+func (r runChunk) card() int {
+	n := 0
+	for _, rn := range r {
+		n += int(rn.last-rn.start) + 1
+	}
+	return n
+}
+
+func (r runChunk) contains(x uint16) bool {
+	// Find the first run ending at or after x.
+	i, _ := slices.BinarySearchFunc(r, x, func(rn run, x uint16) int {
+		return int(rn.last) - int(x)
+	})
+	return i < len(r) && r[i].start <= x
+}
+
+func (r runChunk) add(x uint16) (chunk, bool) {
+	if r.contains(x) {
+		return r, false
+	}
+	return normalize(r).add(x)
+}
+
+func (r runChunk) remove(x uint16) (chunk, bool) {
+	if !r.contains(x) {
+		return r, false
+	}
+	return normalize(r).remove(x)
+}
+
+func (r runChunk) all(yield func(uint16) bool) bool {
+	for _, rn := range r {
+		for x := int(rn.start); x <= int(rn.last); x++ {
+			if !yield(uint16(x)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (r runChunk) clone() chunk { return slices.Clone(r) }
+
+func (r runChunk) bitmap() *bitmapChunk {
+	b := &bitmapChunk{}
+	for _, rn := range r {
+		for x := int(rn.start); x <= int(rn.last); x++ {
+			b.words[x/wordBits] |= 1 << (x % wordBits)
+		}
+		b.n += int(rn.last-rn.start) + 1
+	}
+	return b
+}
+
+// normalize converts a chunk to an array or a bitmap, depending on its cardinality.
+//
+// It returns nil for an empty chunk.
+func normalize(c chunk) chunk {
+	switch n := c.card(); {
+	case n == 0:
+		return nil
+	case n <= arrayMaxCard:
+		if a, ok := c.(arrayChunk); ok {
+			return a
+		}
+		return c.bitmap().array()
+	default:
+		if _, ok := c.(*bitmapChunk); ok {
+			return c
+		}
+		return c.bitmap()
+	}
+}
+
+// optimize returns the smallest representation of a chunk, possibly as runs,
+// based on their serialized size.
+func optimize(c chunk) chunk {
+	c = normalize(c)
+	var runs int
+	switch c := c.(type) {
+	case arrayChunk:
+		runs = 1
+		for i := 1; i < len(c); i++ {
+			if c[i] != c[i-1]+1 {
+				runs++
+			}
+		}
+	case *bitmapChunk:
+		runs = c.runs()
+	}
+	if runSize(runs) >= serializedSize(c) {
+		return c
+	}
+	r := make(runChunk, 0, runs)
+	c.all(func(x uint16) bool {
+		if n := len(r); n > 0 && r[n-1].last+1 == x {
+			r[n-1].last = x
+		} else {
+			r = append(r, run{x, x})
+		}
+		return true
+	})
+	return r
+}
+
+// setOp is a set operation between chunks, or between sorted sequences of elements.
+type setOp int
+
+const (
+	opOr setOp = iota
+	opAnd
+	opAndNot
+	opXor
+)
+
+// keepsLeft returns true if the operation keeps elements only present in its left operand.
+func (op setOp) keepsLeft() bool { return op != opAnd }
+
+// keepsRight returns true if the operation keeps elements only present in its right operand.
+func (op setOp) keepsRight() bool { return op == opOr || op == opXor }
+
+// keepsBoth returns true if the operation keeps elements present in both operands.
+func (op setOp) keepsBoth() bool { return op == opOr || op == opAnd }
+
+func (op setOp) apply(a, b uint64) uint64 {
+	switch op {
+	case opOr:
+		return a | b
+	case opAnd:
+		return a & b
+	case opAndNot:
+		return a &^ b
+	default:
+		return a ^ b
+	}
+}
+
+// combine applies the operation to two chunks, returning a new normalized chunk,
+// or nil if the result is empty. Its operands are not modified.
+func combine(a, b chunk, op setOp) chunk {
+	aa, aIsArray := a.(arrayChunk)
+	ba, bIsArray := b.(arrayChunk)
+	switch {
+	case aIsArray && bIsArray:
+		return normalize(arrayChunk(mergeSorted(aa, ba, op)))
+	case aIsArray && (op == opAnd || op == opAndNot):
+		return normalize(filterArray(aa, b, op == opAnd))
+	case bIsArray && op == opAnd:
+		return normalize(filterArray(ba, a, true))
+	}
+
+	// General case: word-at-a-time on bitmaps.
+	ab, bb := a.bitmap(), b.bitmap()
+	res := &bitmapChunk{}
+	for i := range res.words {
+		w := op.apply(ab.words[i], bb.words[i])
+		res.words[i] = w
+		res.n += bits.OnesCount64(w)
+	}
+	return normalize(res)
+}
+
+// filterArray returns the elements of the array which are, or are not, in the other chunk.
+func filterArray(a arrayChunk, other chunk, in bool) arrayChunk {
+	res := make(arrayChunk, 0, len(a))
+	for _, x := range a {
+		if other.contains(x) == in {
+			res = append(res, x)
+		}
+	}
+	return res
+}
@@ -0,0 +1,217 @@
+package set
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
+// The portable roaring format is defined by https://github.com/RoaringBitmap/RoaringFormatSpec
+// All integers are little-endian.
+//
+//   - A cookie, either:
+//     serialCookieNoRun as 32 bits, followed by the number of chunks as 32 bits, or
+//     serialCookie as 16 bits, followed by the number of chunks minus 1 as 16 bits,
+//     and a bitset flagging run chunks, 1 bit per chunk.
+//   - For each chunk, its key and its cardinality minus 1, as 16 bits each.
+//   - Unless there are run chunks and fewer than noOffsetThreshold chunks,
+//     for each chunk, the 32 bits offset of its data from the start of the stream.
+//   - For each chunk, its data:
+//     for runs, the number of runs, followed by the start and length minus 1 of each, as 16 bits each,
+//     for arrays, their elements as 16 bits each,
+//     for bitmaps, their 1024 words as 64 bits each.
+//
+// The kind of non-run chunks is not stored, but deduced from their cardinality.
+
+const (
+	serialCookieNoRun = 12346
+	serialCookie      = 12347
+	noOffsetThreshold = 4
+)
+
+var ErrInvalidRoaringFormat = errors.New("container: invalid roaring bitmap format")
+
+// MarshalBinary implements encoding.BinaryMarshaler using the portable roaring format.
+//
+// Call RunOptimize first to use run chunks where they are smaller.
+func (s *Roaring) MarshalBinary() ([]byte, error) {
+	s = s.self()
+	size := len(s.chunks)
+	hasRuns := false
+	runFlags := make([]byte, (size+7)/8)
+	for i, c := range s.chunks {
+		if _, ok := c.(runChunk); ok {
+			hasRuns = true
+			runFlags[i/8] |= 1 << (i % 8)
+		}
+	}
+
+	var buf []byte
+	if hasRuns {
+		buf = binary.LittleEndian.AppendUint32(buf, serialCookie|uint32(size-1)<<16)
+		buf = append(buf, runFlags...)
+	} else {
+		buf = binary.LittleEndian.AppendUint32(buf, serialCookieNoRun)
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(size))
+	}
+	for i, c := range s.chunks {
+		buf = binary.LittleEndian.AppendUint16(buf, s.keys[i])
+		buf = binary.LittleEndian.AppendUint16(buf, uint16(c.card()-1))
+	}
+	if !hasRuns || size >= noOffsetThreshold {
+		offset := len(buf) + 4*size
+		for _, c := range s.chunks {
+			buf = binary.LittleEndian.AppendUint32(buf, uint32(offset))
+			offset += serializedSize(c)
+		}
+	}
+	for _, c := range s.chunks {
+		buf = appendChunk(buf, c)
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler using the portable roaring format,
+// replacing the contents of the set.
+//
+// Invalid data returns an error wrapping ErrInvalidRoaringFormat, and leaves the set unchanged.
+func (s *Roaring) UnmarshalBinary(data []byte) error {
+	d := decoder{data: data, invalid: ErrInvalidRoaringFormat}
+	var size int
+	var runFlags []byte
+	switch cookie := d.uint32(); {
+	case cookie&0xFFFF == serialCookie:
+		size = int(cookie>>16) + 1
+		runFlags = d.bytes((size + 7) / 8)
+	case cookie == serialCookieNoRun:
+		size = int(d.uint32())
+		if size > 1<<16 {
+			return fmt.Errorf("%w: %d chunks", ErrInvalidRoaringFormat, size)
+		}
+	default:
+		if d.err == nil {
+			return fmt.Errorf("%w: unknown cookie %d", ErrInvalidRoaringFormat, cookie)
+		}
+	}
+	isRun := func(i int) bool { return runFlags != nil && runFlags[i/8]&(1<<(i%8)) != 0 }
+
+	res := &Roaring{keys: make([]uint16, 0, min(size, len(data)/4))}
+	cards := make([]int, 0, cap(res.keys))
+	for i := 0; i < size && d.err == nil; i++ {
+		key := d.uint16()
+		if i > 0 && key <= res.keys[i-1] {
+			return fmt.Errorf("%w: unsorted keys", ErrInvalidRoaringFormat)
+		}
+		res.keys = append(res.keys, key)
+		cards = append(cards, int(d.uint16())+1)
+	}
+	if runFlags == nil || size >= noOffsetThreshold {
+		d.bytes(4 * size) // Offsets are only needed for random access.
+	}
+
+	res.chunks = make([]chunk, 0, len(res.keys))
+	for i := 0; i < size && d.err == nil; i++ {
+		var c chunk
+		var err error
+		switch {
+		case isRun(i):
+			c, err = d.runs()
+		case cards[i] > arrayMaxCard:
+			c, err = d.bitmap()
+		default:
+			c, err = d.array(cards[i])
+		}
+		if err != nil {
+			return err
+		}
+		if d.err == nil && c.card() != cards[i] {
+			return fmt.Errorf("%w: chunk %d has cardinality %d instead of %d", ErrInvalidRoaringFormat, i, c.card(), cards[i])
+		}
+		res.chunks = append(res.chunks, c)
+	}
+	if d.err != nil {
+		return d.err
+	}
+	*s = *res
+	return nil
+}
+
+// serializedSize returns the size of the chunk data in the portable format.
+func serializedSize(c chunk) int {
+	switch c := c.(type) {
+	case runChunk:
+		return runSize(len(c))
+	case arrayChunk:
+		return 2 * len(c)
+	default:
+		return bitmapBytes
+	}
+}
+
+// runSize returns the size of a run chunk data in the portable format.
+func runSize(runs int) int {
+	return 2 + 4*runs
+}
+
+func appendChunk(buf []byte, c chunk) []byte {
+	switch c := c.(type) {
+	case runChunk:
+		buf = binary.LittleEndian.AppendUint16(buf, uint16(len(c)))
+		for _, rn := range c {
+			buf = binary.LittleEndian.AppendUint16(buf, rn.start)
+			buf = binary.LittleEndian.AppendUint16(buf, rn.last-rn.start)
+		}
+	case arrayChunk:
+		for _, x := range c {
+			buf = binary.LittleEndian.AppendUint16(buf, x)
+		}
+	case *bitmapChunk:
+		for _, w := range c.words[:] {
+			buf = binary.LittleEndian.AppendUint64(buf, w)
+		}
+	}
+	return buf
+}
+
+func (d *decoder) runs() (chunk, error) {
+	n := int(d.uint16())
+	r := make(runChunk, 0, min(n, len(d.data)/4))
+	for i := 0; i < n && d.err == nil; i++ {
+		start, length := d.uint16(), d.uint16()
+		if int(start)+int(length) > 0xFFFF {
+			return nil, fmt.Errorf("%w: run overflows its chunk", ErrInvalidRoaringFormat)
+		}
+		// Runs must be sorted and disjoint. Adjacent runs are tolerated.
+		if i > 0 && start <= r[i-1].last {
+			return nil, fmt.Errorf("%w: unsorted runs", ErrInvalidRoaringFormat)
+		}
+		r = append(r, run{start, start + length})
+	}
+	return r, nil
+}
+
+func (d *decoder) array(card int) (chunk, error) {
+	a := make(arrayChunk, 0, card)
+	for i := 0; i < card && d.err == nil; i++ {
+		x := d.uint16()
+		if i > 0 && x <= a[i-1] {
+			return nil, fmt.Errorf("%w: unsorted array", ErrInvalidRoaringFormat)
+		}
+		a = append(a, x)
+	}
+	return a, nil
+}
+
+func (d *decoder) bitmap() (chunk, error) {
+	data := d.bytes(bitmapBytes)
+	b := &bitmapChunk{}
+	for i := range b.words {
+		if data == nil {
+			break
+		}
+		b.words[i] = binary.LittleEndian.Uint64(data[8*i:])
+		b.n += bits.OnesCount64(b.words[i])
+	}
+	return b, nil
+}
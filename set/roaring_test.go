@@ -0,0 +1,308 @@
+package set_test
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/fgm/container"
+	"github.com/fgm/container/set"
+)
+
+// createRoaring creates a Roaring set holding the elements.
+func createRoaring(tb testing.TB, elements ...uint32) *set.Roaring {
+	tb.Helper()
+	s, ok := set.NewRoaring().(*set.Roaring)
+	if !ok {
+		tb.Fatalf("expected a *set.Roaring")
+	}
+	for _, e := range elements {
+		s.Add(e)
+	}
+	return s
+}
+
+// rangeRoaring creates a Roaring set holding the elements in [from, to), by step.
+func rangeRoaring(tb testing.TB, from, to, step uint32) *set.Roaring {
+	tb.Helper()
+	s := createRoaring(tb)
+	for e := from; e < to; e += step {
+		s.Add(e)
+	}
+	return s
+}
+
+func TestRoaring(t *testing.T) {
+	var ns *set.Roaring
+	if ns.Len() != 0 || ns.Contains(1) || ns.Add(1) || ns.Remove(1) || ns.Clear() != 0 || ns.String() != "{}" || ns.RunOptimize() {
+		t.Errorf("nil set should be empty and ignore changes")
+	}
+
+	s := createRoaring(t, 1<<20, 3, 1<<16, 3, 70000)
+	if actual := slices.Collect(s.Items()); !slices.Equal(actual, []uint32{3, 1 << 16, 70000, 1 << 20}) {
+		t.Errorf("got %v but expected ordered [3 65536 70000 1048576]", actual)
+	}
+	if !s.Contains(70000) || s.Contains(70001) || s.Contains(4) {
+		t.Errorf("got %v, which does not match Contains", s)
+	}
+	if !s.Remove(70000) || s.Remove(70000) || s.Remove(1<<30) {
+		t.Errorf("Remove should only return true for existing elements")
+	}
+	if !s.Remove(1<<16) || s.Len() != 2 {
+		t.Errorf("got %v but expected {3, 1048576}", s)
+	}
+	if s.Clear() != 2 || s.Len() != 0 {
+		t.Errorf("Clear should remove all elements")
+	}
+}
+
+// TestRoaring_Chunks checks the conversions between array, bitmap and run chunks.
+func TestRoaring_Chunks(t *testing.T) {
+	const n = 10_000 // More than the 4096 elements of an array chunk.
+	s := rangeRoaring(t, 0, n, 1)
+	if s.Len() != n || !s.Contains(n-1) || s.Contains(n) {
+		t.Fatalf("got length %d but expected %d", s.Len(), n)
+	}
+	// Back to an array below 4096 elements.
+	for e := uint32(0); e < n; e += 2 {
+		s.Remove(e)
+	}
+	if s.Len() != n/2 || s.Contains(0) || !s.Contains(1) {
+		t.Errorf("got length %d but expected %d", s.Len(), n/2)
+	}
+
+	// Runs are used when smaller, and converted back on changes.
+	s = rangeRoaring(t, 0, n, 1)
+	if !s.RunOptimize() {
+		t.Errorf("consecutive elements should be converted to runs")
+	}
+	if !s.Contains(0) || !s.Contains(n-1) || s.Contains(n) || s.Len() != n {
+		t.Errorf("run chunk does not match its elements")
+	}
+	if !s.Add(5) || s.Add(n) || !s.Remove(5) || s.Remove(5) {
+		t.Errorf("run chunk changes do not match its elements")
+	}
+	if actual := s.Len(); actual != n {
+		t.Errorf("got length %d but expected %d", actual, n)
+	}
+	if rangeRoaring(t, 0, 100, 2).RunOptimize() {
+		t.Errorf("sparse elements should not be converted to runs")
+	}
+}
+
+func TestRoaring_SetOperations(t *testing.T) {
+	optimized := rangeRoaring(t, 60000, 80000, 1) // Runs, crossing chunks.
+	optimized.RunOptimize()
+	operands := map[string]*set.Roaring{
+		"array":     rangeRoaring(t, 0, 70000, 1000),
+		"bitmap":    rangeRoaring(t, 0, 140000, 3),
+		"run":       optimized,
+		"empty":     createRoaring(t),
+		"far array": createRoaring(t, 1<<31, 5),
+	}
+	for lName, left := range operands {
+		for rName, right := range operands {
+			// Compare with the same operation on BasicMap sets.
+			lMap, rMap := set.NewBasicMap[uint32](left.Len()), set.NewBasicMap[uint32](right.Len())
+			for e := range left.Items() {
+				lMap.Add(e)
+			}
+			for e := range right.Items() {
+				rMap.Add(e)
+			}
+			ops := []struct {
+				name                      string
+				actual, generic, expected container.Set[uint32]
+			}{
+				{"Union", left.Union(right), left.Union(rMap), lMap.Union(rMap)},
+				{"Intersection", left.Intersection(right), left.Intersection(rMap), lMap.Intersection(rMap)},
+				{"Difference", left.Difference(right), left.Difference(rMap), lMap.Difference(rMap)},
+				{"SymmetricDifference", left.SymmetricDifference(right), left.SymmetricDifference(rMap), lMap.SymmetricDifference(rMap)},
+			}
+			for _, op := range ops {
+				if !op.actual.Equal(op.expected) {
+					t.Errorf("%s %s %s: got %d elements but expected %d", lName, op.name, rName,
+						op.actual.(container.Countable).Len(), op.expected.(container.Countable).Len())
+				}
+				if !op.generic.Equal(op.expected) {
+					t.Errorf("%s %s generic %s: got %d elements but expected %d", lName, op.name, rName,
+						op.generic.(container.Countable).Len(), op.expected.(container.Countable).Len())
+				}
+			}
+
+			predicates := []struct {
+				name             string
+				actual, expected bool
+			}{
+				{"IsSubsetOf", left.IsSubsetOf(right), lMap.IsSubsetOf(rMap)},
+				{"IsSupersetOf", left.IsSupersetOf(right), lMap.IsSupersetOf(rMap)},
+				{"IsProperSubsetOf", left.IsProperSubsetOf(right), lMap.IsProperSubsetOf(rMap)},
+				{"Equal", left.Equal(right), lMap.Equal(rMap)},
+				{"IsDisjoint", left.IsDisjoint(right), lMap.IsDisjoint(rMap)},
+				{"generic IsSubsetOf", left.IsSubsetOf(rMap), lMap.IsSubsetOf(rMap)},
+				{"generic IsDisjoint", left.IsDisjoint(rMap), lMap.IsDisjoint(rMap)},
+			}
+			for _, p := range predicates {
+				if p.actual != p.expected {
+					t.Errorf("%s %s %s: got %t but expected %t", lName, p.name, rName, p.actual, p.expected)
+				}
+			}
+		}
+	}
+	if optimized.Len() != 20000 {
+		t.Errorf("operands should not be modified")
+	}
+	if (*set.Roaring)(nil).Union(nil).(container.Countable).Len() != 0 {
+		t.Errorf("union of nil sets should be empty")
+	}
+}
+
+func TestRoaring_Format(t *testing.T) {
+	// Vectors built from the portable format specification.
+	withoutRuns := []byte{
+		0x3A, 0x30, 0, 0, // Cookie without runs
+		2, 0, 0, 0, // 2 chunks
+		0, 0, 2, 0, // Key 0, 3 elements
+		1, 0, 0, 0, // Key 1, 1 element
+		24, 0, 0, 0, // Offset of chunk 0
+		30, 0, 0, 0, // Offset of chunk 1
+		1, 0, 2, 0, 3, 0, // Array of chunk 0
+		0, 0, // Array of chunk 1
+	}
+	withRuns := []byte{
+		0x3B, 0x30, 0, 0, // Cookie with runs, 1 chunk
+		1,          // Chunk 0 is a run
+		0, 0, 9, 0, // Key 0, 10 elements
+		1, 0, // 1 run
+		1, 0, 9, 0, // Starting at 1, 10 elements
+	}
+	tests := [...]struct {
+		name     string
+		s        *set.Roaring
+		optimize bool
+		expected []byte
+	}{
+		{"empty", createRoaring(t), false, []byte{0x3A, 0x30, 0, 0, 0, 0, 0, 0}},
+		{"without runs", createRoaring(t, 1, 2, 3, 1<<16), false, withoutRuns},
+		{"with runs", rangeRoaring(t, 1, 11, 1), true, withRuns},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.optimize {
+				test.s.RunOptimize()
+			}
+			actual, err := test.s.MarshalBinary()
+			if err != nil {
+				t.Fatalf("Failed to marshal: %v", err)
+			}
+			if !bytes.Equal(actual, test.expected) {
+				t.Errorf("got %v but expected %v", actual, test.expected)
+			}
+			decoded := createRoaring(t, 42)
+			if err := decoded.UnmarshalBinary(test.expected); err != nil {
+				t.Fatalf("Failed to unmarshal: %v", err)
+			}
+			if !decoded.Equal(test.s) {
+				t.Errorf("got %v but expected %v", decoded, test.s)
+			}
+		})
+	}
+}
+
+func TestRoaring_FormatRoundTrip(t *testing.T) {
+	// Enough chunks to use offsets with runs, and all kinds of chunks.
+	s := rangeRoaring(t, 0, 1<<18, 3)                                 // Bitmaps
+	s = s.Union(rangeRoaring(t, 1<<20, 1<<20+5000, 1)).(*set.Roaring) // Run
+	s = s.Union(createRoaring(t, 1<<30, 1<<31)).(*set.Roaring)        // Arrays
+	s.RunOptimize()
+	data, err := s.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	decoded := createRoaring(t)
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+	if !decoded.Equal(s) || decoded.Len() != s.Len() {
+		t.Errorf("got %d elements but expected %d", decoded.Len(), s.Len())
+	}
+}
+
+func TestRoaring_FormatErrors(t *testing.T) {
+	valid, _ := createRoaring(t, 1, 2, 3, 1<<16).MarshalBinary()
+	tests := [...]struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"unknown cookie", []byte{1, 2, 3, 4, 0, 0, 0, 0}},
+		{"truncated", valid[:len(valid)-1]},
+		{"unsorted keys", []byte{0x3A, 0x30, 0, 0, 2, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 24, 0, 0, 0, 26, 0, 0, 0, 1, 0, 1, 0}},
+		{"unsorted array", []byte{0x3A, 0x30, 0, 0, 1, 0, 0, 0, 0, 0, 1, 0, 16, 0, 0, 0, 2, 0, 1, 0}},
+		{"run overflow", []byte{0x3B, 0x30, 0, 0, 1, 0, 0, 1, 0, 1, 0, 255, 255, 1, 0}},
+		{"wrong cardinality", []byte{0x3B, 0x30, 0, 0, 1, 0, 0, 5, 0, 1, 0, 1, 0, 1, 0}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := createRoaring(t, 42)
+			if err := s.UnmarshalBinary(test.data); !errors.Is(err, set.ErrInvalidRoaringFormat) {
+				t.Errorf("got %v but expected %v", err, set.ErrInvalidRoaringFormat)
+			}
+			if s.Len() != 1 || !s.Contains(42) {
+				t.Errorf("set should be unchanged after an error, got %v", s)
+			}
+		})
+	}
+}
+
+func BenchmarkRoaring_Intersection(b *testing.B) {
+	r1, r2 := rangeRoaring(b, 0, 1<<22, 7), rangeRoaring(b, 0, 1<<22, 11)
+	m1, m2 := set.NewBasicMap[uint32](r1.Len()), set.NewBasicMap[uint32](r2.Len())
+	for e := range r1.Items() {
+		m1.Add(e)
+	}
+	for e := range r2.Items() {
+		m2.Add(e)
+	}
+	b.Run("roaring", func(b *testing.B) {
+		for range b.N {
+			_ = r1.Intersection(r2)
+		}
+	})
+	b.Run("map", func(b *testing.B) {
+		for range b.N {
+			_ = m1.Intersection(m2)
+		}
+	})
+}
+
+func FuzzRoaringUnmarshal(f *testing.F) {
+	// Add some seed corpus
+	for _, s := range []*set.Roaring{createRoaring(f), createRoaring(f, 1, 2, 3, 1<<16), rangeRoaring(f, 0, 5000, 1)} {
+		data, _ := s.MarshalBinary()
+		f.Add(data)
+		s.RunOptimize()
+		data, _ = s.MarshalBinary()
+		f.Add(data)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// Invalid data must not panic, and valid data must round-trip.
+		s := createRoaring(t)
+		if err := s.UnmarshalBinary(data); err != nil {
+			if !errors.Is(err, set.ErrInvalidRoaringFormat) {
+				t.Errorf("got %v but expected %v", err, set.ErrInvalidRoaringFormat)
+			}
+			return
+		}
+		encoded, err := s.MarshalBinary()
+		if err != nil {
+			t.Fatalf("Failed to marshal: %v", err)
+		}
+		decoded := createRoaring(t)
+		if err := decoded.UnmarshalBinary(encoded); err != nil || !decoded.Equal(s) {
+			t.Errorf("round trip failed: %v", err)
+		}
+	})
+}
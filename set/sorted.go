@@ -0,0 +1,485 @@
+package set
+
+import (
+	"cmp"
+	"fmt"
+	"iter"
+	"slices"
+	"strings"
+
+	"github.com/fgm/container"
+)
+
+// Sorted is a set of ordered elements, iterating them in increasing order,
+// and supporting order-based queries like Floor, Ceiling, Rank or Select.
+//
+// It is implemented as an AVL tree where each node also holds the size of its
+// subtree, so that all single-element operations are O(log n).
+// Elements are compared with cmp.Compare, so a NaN is considered equal to
+// another NaN, and less than any other value.
+//
+// Union, Intersection, Difference and SymmetricDifference between two Sorted sets
+// merge their elements in O(n+m). All of them return a new Sorted set.
+//
+// The set MUST NOT be modified during an iteration.
+// It is not concurrency-safe.
+type Sorted[E cmp.Ordered] struct {
+	root *sortedNode[E]
+}
+
+// String returns the set items in increasing order.
+func (s *Sorted[E]) String() string {
+	var b strings.Builder
+	b.WriteByte('{')
+	i := 0
+	for item := range s.Items() {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		_, _ = fmt.Fprintf(&b, "%v", item)
+		i++
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// Len returns the number of items in the Set.
+func (s *Sorted[E]) Len() int {
+	if s == nil {
+		return 0
+	}
+	return s.root.len()
+}
+
+// Add adds an item to the set. Returns true if the item was already present.
+func (s *Sorted[E]) Add(item E) (found bool) {
+	if s == nil {
+		return false
+	}
+	var added bool
+	s.root, added = s.root.insert(item)
+	return !added
+}
+
+// Remove removes an item from the set.
+// It does not fail if the item was not present, and returns true if it was.
+func (s *Sorted[E]) Remove(item E) (found bool) {
+	if s == nil {
+		return false
+	}
+	s.root, found = s.root.remove(item)
+	return found
+}
+
+// Contains returns true if the item is present in the set.
+func (s *Sorted[E]) Contains(item E) bool {
+	if s == nil {
+		return false
+	}
+	for n := s.root; n != nil; {
+		switch c := cmp.Compare(item, n.item); {
+		case c < 0:
+			n = n.left
+		case c > 0:
+			n = n.right
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// Clear removes all items from the set and returns the number of items removed.
+func (s *Sorted[E]) Clear() (count int) {
+	if s == nil {
+		return 0
+	}
+	count = s.Len()
+	s.root = nil
+	return count
+}
+
+// Items returns an iterator over the set elements, in increasing order.
+func (s *Sorted[E]) Items() iter.Seq[E] {
+	return func(yield func(E) bool) {
+		if s == nil {
+			return
+		}
+		s.root.all(yield)
+	}
+}
+
+// Range returns an iterator over the set elements from from included to to
+// excluded, in increasing order.
+func (s *Sorted[E]) Range(from, to E) iter.Seq[E] {
+	return func(yield func(E) bool) {
+		if s == nil {
+			return
+		}
+		s.root.between(from, to, yield)
+	}
+}
+
+// Min returns the smallest element in the set, or false if the set is empty.
+func (s *Sorted[E]) Min() (E, bool) {
+	return s.Select(0)
+}
+
+// Max returns the largest element in the set, or false if the set is empty.
+func (s *Sorted[E]) Max() (E, bool) {
+	return s.Select(s.Len() - 1)
+}
+
+// Floor returns the largest element less than or equal to item, or false if there is none.
+func (s *Sorted[E]) Floor(item E) (res E, found bool) {
+	if s == nil {
+		return res, false
+	}
+	for n := s.root; n != nil; {
+		switch c := cmp.Compare(item, n.item); {
+		case c < 0:
+			n = n.left
+		case c > 0:
+			res, found = n.item, true
+			n = n.right
+		default:
+			return n.item, true
+		}
+	}
+	return res, found
+}
+
+// Ceiling returns the smallest element greater than or equal to item, or false if there is none.
+func (s *Sorted[E]) Ceiling(item E) (res E, found bool) {
+	if s == nil {
+		return res, false
+	}
+	for n := s.root; n != nil; {
+		switch c := cmp.Compare(item, n.item); {
+		case c < 0:
+			res, found = n.item, true
+			n = n.left
+		case c > 0:
+			n = n.right
+		default:
+			return n.item, true
+		}
+	}
+	return res, found
+}
+
+// Rank returns the number of elements strictly less than item,
+// which is the index of item in the set if it is present.
+func (s *Sorted[E]) Rank(item E) int {
+	if s == nil {
+		return 0
+	}
+	rank := 0
+	for n := s.root; n != nil; {
+		if cmp.Less(n.item, item) {
+			rank += n.left.len() + 1
+			n = n.right
+		} else {
+			n = n.left
+		}
+	}
+	return rank
+}
+
+// Select returns the element at index k in increasing order, starting at 0,
+// or false if k is out of range.
+func (s *Sorted[E]) Select(k int) (res E, found bool) {
+	if k < 0 || k >= s.Len() {
+		return res, false
+	}
+	n := s.root
+	for {
+		switch l := n.left.len(); {
+		case k < l:
+			n = n.left
+		case k == l:
+			return n.item, true
+		default:
+			k -= l + 1
+			n = n.right
+		}
+	}
+}
+
+// Union returns a new set containing elements present in either set.
+func (s *Sorted[E]) Union(other container.Set[E]) container.Set[E] {
+	if o, ok := other.(*Sorted[E]); ok {
+		return s.merge(o, opOr)
+	}
+	res := s.filter(nil, false)
+	if other != nil {
+		for item := range other.Items() {
+			res.Add(item)
+		}
+	}
+	return res
+}
+
+// Intersection returns a new set containing elements present in both sets.
+func (s *Sorted[E]) Intersection(other container.Set[E]) container.Set[E] {
+	if o, ok := other.(*Sorted[E]); ok {
+		return s.merge(o, opAnd)
+	}
+	if other == nil {
+		return &Sorted[E]{}
+	}
+	return s.filter(other, true)
+}
+
+// Difference returns a new set containing elements present in this set but not in the other.
+func (s *Sorted[E]) Difference(other container.Set[E]) container.Set[E] {
+	if o, ok := other.(*Sorted[E]); ok {
+		return s.merge(o, opAndNot)
+	}
+	return s.filter(other, false)
+}
+
+// SymmetricDifference returns a new set containing elements present in either set but not in both.
+func (s *Sorted[E]) SymmetricDifference(other container.Set[E]) container.Set[E] {
+	if o, ok := other.(*Sorted[E]); ok {
+		return s.merge(o, opXor)
+	}
+	res := s.filter(other, false)
+	if other != nil {
+		for item := range other.Items() {
+			if !s.Contains(item) {
+				res.Add(item)
+			}
+		}
+	}
+	return res
+}
+
+// IsSubsetOf returns true if all elements in this set are present in the other.
+//
+// The empty set is a subset of any set, and nil sets are considered empty.
+func (s *Sorted[E]) IsSubsetOf(other container.Set[E]) bool {
+	return isSubset(s, other)
+}
+
+// IsSupersetOf returns true if all elements in the other set are present in this one.
+func (s *Sorted[E]) IsSupersetOf(other container.Set[E]) bool {
+	return isSuperset(s, other)
+}
+
+// IsProperSubsetOf returns true if this set is a subset of the other, and the
+// other contains at least one element not in this set.
+func (s *Sorted[E]) IsProperSubsetOf(other container.Set[E]) bool {
+	return isProperSubset(s, other)
+}
+
+// Equal returns true if both sets contain the same elements.
+func (s *Sorted[E]) Equal(other container.Set[E]) bool {
+	return equal(s, other)
+}
+
+// IsDisjoint returns true if the sets have no element in common.
+func (s *Sorted[E]) IsDisjoint(other container.Set[E]) bool {
+	return isDisjoint(s, other)
+}
+
+// merge applies the operation by merging the elements of both sets.
+func (s *Sorted[E]) merge(other *Sorted[E], op setOp) *Sorted[E] {
+	return &Sorted[E]{root: buildSorted(mergeSorted(slices.Collect(s.Items()), slices.Collect(other.Items()), op))}
+}
+
+// filter returns a new set with the elements of this set which are, or are not,
+// in the other. A nil other set is considered empty.
+func (s *Sorted[E]) filter(other container.Set[E], in bool) *Sorted[E] {
+	items := make([]E, 0, s.Len())
+	for item := range s.Items() {
+		if (other != nil && other.Contains(item)) == in {
+			items = append(items, item)
+		}
+	}
+	return &Sorted[E]{root: buildSorted(items)}
+}
+
+// NewSorted returns a ready-for-use container.Set implemented by the Sorted type.
+func NewSorted[E cmp.Ordered]() container.Set[E] {
+	return &Sorted[E]{}
+}
+
+// mergeSorted applies the operation to two sorted slices of distinct elements by merging them.
+func mergeSorted[E cmp.Ordered](a, b []E, op setOp) []E {
+	res := make([]E, 0, max(len(a), len(b)))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch c := cmp.Compare(a[i], b[j]); {
+		case c < 0:
+			if op.keepsLeft() {
+				res = append(res, a[i])
+			}
+			i++
+		case c > 0:
+			if op.keepsRight() {
+				res = append(res, b[j])
+			}
+			j++
+		default:
+			if op.keepsBoth() {
+				res = append(res, a[i])
+			}
+			i++
+			j++
+		}
+	}
+	if op.keepsLeft() {
+		res = append(res, a[i:]...)
+	}
+	if op.keepsRight() {
+		res = append(res, b[j:]...)
+	}
+	return res
+}
+
+// sortedNode is a node in an AVL tree, with the size of its subtree.
+// Its methods accept a nil receiver as an empty tree.
+type sortedNode[E cmp.Ordered] struct {
+	item        E
+	left, right *sortedNode[E]
+	height      int
+	size        int
+}
+
+// buildSorted builds a balanced tree from a sorted slice of distinct elements.
+func buildSorted[E cmp.Ordered](items []E) *sortedNode[E] {
+	if len(items) == 0 {
+		return nil
+	}
+	mid := len(items) / 2
+	n := &sortedNode[E]{item: items[mid], left: buildSorted(items[:mid]), right: buildSorted(items[mid+1:])}
+	n.update()
+	return n
+}
+
+func (n *sortedNode[E]) len() int {
+	if n == nil {
+		return 0
+	}
+	return n.size
+}
+
+func (n *sortedNode[E]) depth() int {
+	if n == nil {
+		return 0
+	}
+	return n.height
+}
+
+func (n *sortedNode[E]) update() {
+	n.height = 1 + max(n.left.depth(), n.right.depth())
+	n.size = 1 + n.left.len() + n.right.len()
+}
+
+func (n *sortedNode[E]) rotateLeft() *sortedNode[E] {
+	r := n.right
+	n.right = r.left
+	n.update()
+	r.left = n
+	r.update()
+	return r
+}
+
+func (n *sortedNode[E]) rotateRight() *sortedNode[E] {
+	l := n.left
+	n.left = l.right
+	n.update()
+	l.right = n
+	l.update()
+	return l
+}
+
+// balance restores the AVL invariant after a change in one of the subtrees.
+func (n *sortedNode[E]) balance() *sortedNode[E] {
+	n.update()
+	switch bf := n.left.depth() - n.right.depth(); {
+	case bf > 1:
+		if n.left.left.depth() < n.left.right.depth() {
+			n.left = n.left.rotateLeft()
+		}
+		return n.rotateRight()
+	case bf < -1:
+		if n.right.right.depth() < n.right.left.depth() {
+			n.right = n.right.rotateRight()
+		}
+		return n.rotateLeft()
+	default:
+		return n
+	}
+}
+
+// insert returns the tree including item, and whether it was added.
+func (n *sortedNode[E]) insert(item E) (*sortedNode[E], bool) {
+	if n == nil {
+		return &sortedNode[E]{item: item, height: 1, size: 1}, true
+	}
+	var added bool
+	switch c := cmp.Compare(item, n.item); {
+	case c < 0:
+		n.left, added = n.left.insert(item)
+	case c > 0:
+		n.right, added = n.right.insert(item)
+	}
+	if !added {
+		return n, false
+	}
+	return n.balance(), true
+}
+
+// remove returns the tree excluding item, and whether it was removed.
+func (n *sortedNode[E]) remove(item E) (*sortedNode[E], bool) {
+	if n == nil {
+		return nil, false
+	}
+	var removed bool
+	switch c := cmp.Compare(item, n.item); {
+	case c < 0:
+		n.left, removed = n.left.remove(item)
+	case c > 0:
+		n.right, removed = n.right.remove(item)
+	default:
+		if n.left == nil {
+			return n.right, true
+		}
+		if n.right == nil {
+			return n.left, true
+		}
+		// Replace the item by its successor, removed from the right subtree.
+		succ := n.right
+		for succ.left != nil {
+			succ = succ.left
+		}
+		n.item = succ.item
+		n.right, removed = n.right.remove(succ.item)
+	}
+	if !removed {
+		return n, false
+	}
+	return n.balance(), true
+}
+
+// all yields the elements of the tree in order, and returns false if yield did.
+func (n *sortedNode[E]) all(yield func(E) bool) bool {
+	return n == nil || n.left.all(yield) && yield(n.item) && n.right.all(yield)
+}
+
+// between yields the elements of the tree in [from, to) in order, and returns false if yield did.
+func (n *sortedNode[E]) between(from, to E, yield func(E) bool) bool {
+	if n == nil {
+		return true
+	}
+	if cmp.Less(from, n.item) && !n.left.between(from, to, yield) {
+		return false
+	}
+	beforeTo := cmp.Less(n.item, to)
+	if beforeTo && !cmp.Less(n.item, from) && !yield(n.item) {
+		return false
+	}
+	return !beforeTo || n.right.between(from, to, yield)
+}
@@ -0,0 +1,225 @@
+package set_test
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/fgm/container"
+	"github.com/fgm/container/set"
+)
+
+// createSorted creates a Sorted set holding the elements.
+func createSorted(tb testing.TB, elements ...int) *set.Sorted[int] {
+	tb.Helper()
+	s, ok := set.NewSorted[int]().(*set.Sorted[int])
+	if !ok {
+		tb.Fatalf("expected a *set.Sorted")
+	}
+	for _, e := range elements {
+		s.Add(e)
+	}
+	return s
+}
+
+func TestSorted(t *testing.T) {
+	var ns *set.Sorted[int]
+	if ns.Len() != 0 || ns.Contains(1) || ns.Add(1) || ns.Remove(1) || ns.Clear() != 0 || ns.String() != "{}" || ns.Rank(1) != 0 {
+		t.Errorf("nil set should be empty and ignore changes")
+	}
+	if _, ok := ns.Floor(1); ok {
+		t.Errorf("nil set should have no floor")
+	}
+
+	s := createSorted(t, 5, 1, 9, 3, 7, 5)
+	if s.String() != "{1, 3, 5, 7, 9}" || s.Len() != 5 {
+		t.Errorf("got %v but expected {1, 3, 5, 7, 9}", s)
+	}
+	if !s.Remove(5) || s.Remove(5) || s.Contains(5) || !s.Contains(7) {
+		t.Errorf("Remove should only return true for existing elements")
+	}
+	if s.Clear() != 4 || s.Len() != 0 {
+		t.Errorf("Clear should remove all elements")
+	}
+
+	// NaN is handled like cmp.Compare does.
+	f := set.NewSorted[float64]().(*set.Sorted[float64])
+	f.Add(math.NaN())
+	f.Add(1)
+	if !f.Contains(math.NaN()) || !f.Add(math.NaN()) || f.Len() != 2 {
+		t.Errorf("NaN should be found in the set")
+	}
+}
+
+func TestSorted_Queries(t *testing.T) {
+	s := createSorted(t, 10, 20, 30, 40)
+	type result struct {
+		value int
+		ok    bool
+	}
+	res := func(v int, ok bool) result { return result{v, ok} }
+	tests := [...]struct {
+		name             string
+		actual, expected result
+	}{
+		{"min", res(s.Min()), result{10, true}},
+		{"max", res(s.Max()), result{40, true}},
+		{"floor below", res(s.Floor(5)), result{0, false}},
+		{"floor between", res(s.Floor(25)), result{20, true}},
+		{"floor exact", res(s.Floor(30)), result{30, true}},
+		{"floor above", res(s.Floor(99)), result{40, true}},
+		{"ceiling below", res(s.Ceiling(5)), result{10, true}},
+		{"ceiling between", res(s.Ceiling(25)), result{30, true}},
+		{"ceiling exact", res(s.Ceiling(30)), result{30, true}},
+		{"ceiling above", res(s.Ceiling(99)), result{0, false}},
+		{"select first", res(s.Select(0)), result{10, true}},
+		{"select middle", res(s.Select(2)), result{30, true}},
+		{"select negative", res(s.Select(-1)), result{0, false}},
+		{"select out of range", res(s.Select(4)), result{0, false}},
+		{"rank below", result{s.Rank(5), true}, result{0, true}},
+		{"rank exact", result{s.Rank(30), true}, result{2, true}},
+		{"rank between", result{s.Rank(35), true}, result{3, true}},
+		{"rank above", result{s.Rank(99), true}, result{4, true}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.actual != test.expected {
+				t.Errorf("got %v but expected %v", test.actual, test.expected)
+			}
+		})
+	}
+	if _, ok := createSorted(t).Max(); ok {
+		t.Errorf("empty set should have no maximum")
+	}
+}
+
+func TestSorted_Range(t *testing.T) {
+	s := createSorted(t)
+	for i := range 100 {
+		s.Add(2 * i)
+	}
+	tests := [...]struct {
+		name     string
+		from, to int
+		expected []int
+	}{
+		{"inside", 10, 17, []int{10, 12, 14, 16}},
+		{"exclusive end", 10, 16, []int{10, 12, 14}},
+		{"odd bounds", 11, 15, []int{12, 14}},
+		{"before", -10, 2, []int{0}},
+		{"after", 197, 1000, []int{198}},
+		{"empty", 20, 20, nil},
+		{"reversed", 30, 20, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := slices.Collect(s.Range(test.from, test.to)); !slices.Equal(actual, test.expected) {
+				t.Errorf("got %v but expected %v", actual, test.expected)
+			}
+		})
+	}
+	// Early termination.
+	for item := range s.Range(0, 100) {
+		if item > 4 {
+			break
+		}
+	}
+}
+
+func TestSorted_SetOperations(t *testing.T) {
+	a, b := createSorted(t, 1, 2, 3, 5), createSorted(t, 3, 4, 5, 6)
+	basic := createSet(t, 3, 4, 5, 6)
+	tests := [...]struct {
+		name     string
+		actual   container.Set[int]
+		expected []int
+	}{
+		{"union", a.Union(b), []int{1, 2, 3, 4, 5, 6}},
+		{"union with basic", a.Union(basic), []int{1, 2, 3, 4, 5, 6}},
+		{"union with nil", a.Union(nil), []int{1, 2, 3, 5}},
+		{"intersection", a.Intersection(b), []int{3, 5}},
+		{"intersection with basic", a.Intersection(basic), []int{3, 5}},
+		{"intersection with nil", a.Intersection(nil), nil},
+		{"difference", a.Difference(b), []int{1, 2}},
+		{"difference with basic", a.Difference(basic), []int{1, 2}},
+		{"symmetric difference", a.SymmetricDifference(b), []int{1, 2, 4, 6}},
+		{"symmetric difference with basic", a.SymmetricDifference(basic), []int{1, 2, 4, 6}},
+		{"of nil", (*set.Sorted[int])(nil).Union(b), []int{3, 4, 5, 6}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, ok := test.actual.(*set.Sorted[int])
+			if !ok {
+				t.Fatalf("got %T but expected a new sorted set", test.actual)
+			}
+			if s == a || s == b {
+				t.Errorf("operations should not return an operand")
+			}
+			if actual := slices.Collect(s.Items()); !slices.Equal(actual, test.expected) {
+				t.Errorf("got %v but expected %v", actual, test.expected)
+			}
+		})
+	}
+	if !a.Intersection(b).IsProperSubsetOf(a) || !a.IsSupersetOf(a.Difference(b)) || a.IsDisjoint(b) || !a.Equal(createSet(t, 5, 3, 2, 1)) {
+		t.Errorf("predicates do not match the set operations")
+	}
+}
+
+func BenchmarkSorted_Add(b *testing.B) {
+	s := createSorted(b)
+	for i := range b.N {
+		s.Add(i * 7919 % (1 << 20))
+	}
+}
+
+func FuzzSorted(f *testing.F) {
+	// Add some seed corpus
+	f.Add([]byte{5, 1, 9, 3}, byte(4))    // Unsorted
+	f.Add([]byte{1, 1, 2, 200}, byte(1))  // Duplicates
+	f.Add([]byte{}, byte(0))              // Empty
+	f.Add([]byte{9, 8, 7, 6, 5}, byte(7)) // Removals
+
+	f.Fuzz(func(t *testing.T, items []byte, probe byte) {
+		// Compare with a sorted slice: odd items are removed after insertion.
+		s := set.NewSorted[byte]().(*set.Sorted[byte])
+		for _, item := range items {
+			s.Add(item)
+		}
+		for _, item := range items {
+			if item%2 == 1 {
+				s.Remove(item)
+			}
+		}
+		var expected []byte
+		for _, item := range items {
+			if item%2 == 0 && !slices.Contains(expected, item) {
+				expected = append(expected, item)
+			}
+		}
+		slices.Sort(expected)
+
+		if actual := slices.Collect(s.Items()); !slices.Equal(actual, expected) {
+			t.Fatalf("got %v but expected %v", actual, expected)
+		}
+		rank, found := slices.BinarySearch(expected, probe)
+		if actual := s.Rank(probe); actual != rank {
+			t.Errorf("Rank(%d): got %d but expected %d", probe, actual, rank)
+		}
+		if actual, ok := s.Select(rank); ok != (rank < len(expected)) || ok && actual != expected[rank] {
+			t.Errorf("Select(%d): got %d, %t", rank, actual, ok)
+		}
+		if actual, ok := s.Ceiling(probe); ok != (rank < len(expected)) || ok && actual != expected[rank] {
+			t.Errorf("Ceiling(%d): got %d, %t", probe, actual, ok)
+		}
+		floor := rank - 1
+		if found {
+			floor = rank
+		}
+		if actual, ok := s.Floor(probe); ok != (floor >= 0) || ok && actual != expected[floor] {
+			t.Errorf("Floor(%d): got %d, %t", probe, actual, ok)
+		}
+		if actual := slices.Collect(s.Range(probe, probe+16)); len(actual) != s.Rank(probe+16)-rank && probe < 240 {
+			t.Errorf("Range(%d, %d): got %v", probe, probe+16, actual)
+		}
+	})
+}
@@ -0,0 +1,46 @@
+package set
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+// checkAVL verifies the AVL and size invariants of a tree, returning its height.
+func checkAVL[E int](t *testing.T, n *sortedNode[E]) int {
+	t.Helper()
+	if n == nil {
+		return 0
+	}
+	lh, rh := checkAVL(t, n.left), checkAVL(t, n.right)
+	if lh-rh > 1 || rh-lh > 1 {
+		t.Fatalf("node %v is unbalanced: %d vs %d", n.item, lh, rh)
+	}
+	if n.height != 1+max(lh, rh) || n.size != 1+n.left.len()+n.right.len() {
+		t.Fatalf("node %v has inconsistent height %d or size %d", n.item, n.height, n.size)
+	}
+	return n.height
+}
+
+func TestSorted_Invariants(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	s := &Sorted[int]{}
+	for range 5000 {
+		if r.IntN(3) == 0 {
+			s.Remove(r.IntN(1000))
+		} else {
+			s.Add(r.IntN(1000))
+		}
+	}
+	checkAVL(t, s.root)
+
+	// Sequential insertions are the worst case for unbalanced trees.
+	s.Clear()
+	for i := range 1 << 10 {
+		s.Add(i)
+	}
+	if h := checkAVL(t, s.root); h > 15 {
+		t.Errorf("got height %d for 1024 sequential elements", h)
+	}
+	// Built from merges.
+	checkAVL(t, s.Union(&Sorted[int]{root: buildSorted([]int{-1, 2000})}).(*Sorted[int]).root)
+}
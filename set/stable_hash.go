@@ -0,0 +1,40 @@
+package set
+
+// Key is the constraint for the elements of probabilistic structures like Bloom.
+//
+// Unlike the sets in this package, these structures only store hashes of their
+// elements. To allow building them in a process and using them in another,
+// they hash the bytes of elements with a stable function, instead of the
+// per-process seeded hash/maphash.
+// Other element types can be converted to bytes, e.g. with encoding/binary.
+type Key interface {
+	~string | ~[]byte
+}
+
+const (
+	fnvOffset = 14695981039346656037
+	fnvPrime  = 1099511628211
+)
+
+// stableHash returns a 64 bits hash of the item which does not change between processes.
+//
+// It is part of the binary formats of the structures using it, so it must never change.
+// It is FNV-1a, finalized by the MurmurHash3 64 bits mixer, for all bits to depend on all input bytes.
+func stableHash[K Key](item K) uint64 {
+	h := uint64(fnvOffset)
+	for i := 0; i < len(item); i++ {
+		h ^= uint64(item[i])
+		h *= fnvPrime
+	}
+	return mix64(h)
+}
+
+// mix64 is the MurmurHash3 64 bits finalizer.
+func mix64(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
@@ -7,7 +7,7 @@ import (
 
 type internalPoolStack[E any] struct {
 	maxPool int
-	pool    []types.ListElement[E]
+	pool    []*types.ListElement[E]
 	top     *types.ListElement[E]
 }
 
@@ -15,7 +15,7 @@ func (ss *internalPoolStack[E]) Push(e E) {
 	var le *types.ListElement[E]
 	if len(ss.pool) != 0 {
 		l := len(ss.pool) - 1
-		le, ss.pool = &ss.pool[l], ss.pool[0:l]
+		le, ss.pool = ss.pool[l], ss.pool[0:l]
 	} else {
 		le = &types.ListElement[E]{}
 	}
@@ -34,8 +34,11 @@ func (ss *internalPoolStack[E]) Pop() (E, bool) {
 	le := ss.top
 	e := le.Value
 	ss.top = ss.top.Next
+	// Recycle the element only once it is no longer linked.
 	if len(ss.pool) <= ss.maxPool {
-		ss.pool = append(ss.pool, *le)
+		le.Value = *new(E) // Prevent memory leak if E is a pointer type
+		le.Next = nil
+		ss.pool = append(ss.pool, le)
 	}
 	return e, true
 }
@@ -44,6 +47,10 @@ func (ss *internalPoolStack[E]) Pop() (E, bool) {
 func NewListInternalPoolStack[E any](sizeHint int) container.Stack[E] {
 	q := &internalPoolStack[E]{}
 	q.maxPool = sizeHint
-	q.pool = make([]types.ListElement[E], sizeHint)
+	q.pool = make([]*types.ListElement[E], sizeHint)
+	slab := make([]types.ListElement[E], sizeHint)
+	for i := range slab {
+		q.pool[i] = &slab[i]
+	}
 	return q
 }
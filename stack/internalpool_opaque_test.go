@@ -57,3 +57,7 @@ func BenchmarkListInternalPoolStack_Push_prealloc(b *testing.B) {
 func TestListInternalPoolStackPop(t *testing.T) {
 	testPop(t, stack.NewListInternalPoolStack[int](0), false)
 }
+
+func TestListInternalPoolStackLIFO(t *testing.T) {
+	testLIFO(t, stack.NewListInternalPoolStack[int](2))
+}
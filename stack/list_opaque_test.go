@@ -72,3 +72,7 @@ func TestListStack(t *testing.T) {
 func TestListStack_Pop(t *testing.T) {
 	testPop(t, stack.NewListStack[int](0), false)
 }
+
+func TestListStack_LIFO(t *testing.T) {
+	testLIFO(t, stack.NewListStack[int](2))
+}
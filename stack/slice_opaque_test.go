@@ -31,3 +31,7 @@ func BenchmarkSliceStack_Push_prealloc(b *testing.B) {
 func TestSliceStack_Pop(t *testing.T) {
 	testPop(t, stack.NewSliceStack[int](0), true)
 }
+
+func TestSliceStack_LIFO(t *testing.T) {
+	testLIFO(t, stack.NewSliceStack[int](2))
+}
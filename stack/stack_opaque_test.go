@@ -29,3 +29,31 @@ func testPop(t *testing.T, s container.Stack[int], expectCountable bool) {
 	}
 
 }
+
+// testLIFO checks ordering with interleaved operations, which exercises element recycling in pooled implementations.
+func testLIFO(t *testing.T, s container.Stack[int]) {
+	var expected []int
+	for round := range 3 {
+		for i := range 5 {
+			s.Push(round*5 + i)
+			expected = append(expected, round*5+i)
+		}
+		for range 3 {
+			actual, ok := s.Pop()
+			if top := expected[len(expected)-1]; !ok || actual != top {
+				t.Fatalf("got %d, %t but expected %d, true", actual, ok, top)
+			}
+			expected = expected[:len(expected)-1]
+		}
+	}
+	for len(expected) > 0 {
+		actual, ok := s.Pop()
+		if top := expected[len(expected)-1]; !ok || actual != top {
+			t.Fatalf("got %d, %t but expected %d, true", actual, ok, top)
+		}
+		expected = expected[:len(expected)-1]
+	}
+	if _, ok := s.Pop(); ok {
+		t.Fatalf("successfully popped empty stack")
+	}
+}
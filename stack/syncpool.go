@@ -29,6 +29,9 @@ func (ss *listSyncPoolStack[E]) Pop() (E, bool) {
 	le := ss.top
 	e := le.Value
 	ss.top = ss.top.Next
+	// Recycle the element only once it is no longer linked.
+	le.Value = *new(E) // Prevent memory leak if E is a pointer type
+	le.Next = nil
 	ss.pool.Put(le)
 
 	return e, true
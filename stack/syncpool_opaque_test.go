@@ -57,3 +57,7 @@ func BenchmarkListSyncPoolStack_Push_prealloc(b *testing.B) {
 func TestListSyncPoolStack_Pop(t *testing.T) {
 	testPop(t, stack.NewListSyncPoolStack[int](0), false)
 }
+
+func TestListSyncPoolStack_LIFO(t *testing.T) {
+	testLIFO(t, stack.NewListSyncPoolStack[int](2))
+}
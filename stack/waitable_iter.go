@@ -0,0 +1,24 @@
+package stack
+
+import (
+	"context"
+	"iter"
+
+	"github.com/fgm/container"
+	types "github.com/fgm/container/internal"
+)
+
+// All returns an iterator over the elements of s, popping them as it goes.
+// It blocks while s is empty, and ends once s is closed and drained,
+// or when the context is canceled.
+//
+// It works with any container.WaitableStack implementation,
+// and may be used by several consumers at once.
+func All[E any](ctx context.Context, s container.WaitableStack[E]) iter.Seq[E] {
+	return types.All(ctx, s.Pop, s.WaitChan())
+}
+
+// AllWithState is like All, but also yields the state of s after each element was popped.
+func AllWithState[E any](ctx context.Context, s container.WaitableStack[E]) iter.Seq2[E, container.WaitableQueueState] {
+	return types.AllWithState(ctx, s.Pop, s.WaitChan())
+}
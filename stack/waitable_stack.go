@@ -0,0 +1,115 @@
+package stack
+
+import (
+	"sync"
+
+	"github.com/fgm/container"
+	types "github.com/fgm/container/internal"
+)
+
+var (
+	ErrCapacityIsNegative                  = types.ErrCapacityIsNegative
+	ErrLowWatermarkIsNegative              = types.ErrLowWatermarkIsNegative
+	ErrHighWatermarkIsNegative             = types.ErrHighWatermarkIsNegative
+	ErrHighWatermarkIsLessThanLowWatermark = types.ErrHighWatermarkIsLessThanLowWatermark
+)
+
+type unit = container.Unit
+
+// waitable implements WaitableStack
+type waitable[E any] struct {
+	types.Watermarks
+	closed bool
+	items  []E
+	mu     sync.Mutex
+	signal chan unit // Used to signal availability or closure
+}
+
+// NewWaitableStack creates a new WaitableStack with the given initial capacity and watermarks.
+//
+// The three arguments are in number of elements, not in bytes.
+// Implementations MAY use the initial capacity to preallocate storage.
+func NewWaitableStack[E any](initialCapacity int, lowWatermark, highWatermark int) (container.WaitableStack[E], error) {
+	wm, err := types.NewWatermarks(initialCapacity, lowWatermark, highWatermark)
+	if err != nil {
+		return nil, err
+	}
+
+	// Like in queue.NewWaitableQueue, the channel of size 1 acts like a latch.
+	return &waitable[E]{
+		Watermarks: wm,
+		closed:     false,
+		items:      make([]E, 0, initialCapacity),
+		signal:     make(chan unit, 1),
+	}, nil
+}
+
+// getState returns the current state of the stack.
+//
+// It MUST only be called while holding the mutex to avoid race conditions.
+func (bs *waitable[E]) getState() container.WaitableQueueState {
+	return bs.State(len(bs.items)) // Do not use bs.Len() here, it would deadlock.
+}
+
+// Push adds an item and signals *if* necessary.
+func (bs *waitable[E]) Push(item E) container.WaitableQueueState {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	if bs.closed {
+		panic("push on closed stack")
+	}
+
+	bs.items = append(bs.items, item)
+
+	// Non-blocking send: if the buffer is full, a signal is already pending.
+	select {
+	case bs.signal <- unit{}:
+	default:
+	}
+	return bs.getState()
+}
+
+// Pop removes and returns the top item if available.
+func (bs *waitable[E]) Pop() (E, bool, container.WaitableQueueState) {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	l := len(bs.items) - 1
+	if l < 0 {
+		var zero E
+		return zero, false, container.QueueIsBelowLowWatermark
+	}
+
+	item := bs.items[l]
+	bs.items[l] = *new(E) // Assign zero value to prevent memory leak if E is a pointer type
+	bs.items = bs.items[:l]
+
+	return item, true, bs.getState()
+}
+
+// Len returns the number of items in the stack.
+//
+// It MUST NOT be called while holding the mutex to avoid deadlocks.
+func (bs *waitable[E]) Len() int {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	return len(bs.items)
+}
+
+// WaitChan returns the signal channel.
+func (bs *waitable[E]) WaitChan() <-chan container.Unit {
+	return bs.signal
+}
+
+// Close marks the stack as closed and closes the signal channel.
+func (bs *waitable[E]) Close() {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	if !bs.closed {
+		bs.closed = true
+		// Close the channel to permanently unblock any waiting Pop operations
+		// and signal that no more items will arrive.
+		close(bs.signal)
+	}
+}
@@ -0,0 +1,111 @@
+package stack
+
+import (
+	"testing"
+
+	"github.com/fgm/container"
+)
+
+const (
+	WSCap   = 10
+	WSLow   = 2
+	WSHigh  = 8
+	WSInput = 42
+)
+
+func TestWaitable_Push(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name          string
+		initialItems  []int
+		expectPanic   bool
+		expectedState container.WaitableQueueState
+		setup         func(*waitable[int])
+	}{
+		{
+			name:          "push to empty stack",
+			initialItems:  []int{},
+			expectedState: container.QueueIsBelowLowWatermark,
+		},
+		{
+			name:          "push to reach low watermark",
+			initialItems:  []int{1},
+			expectedState: container.QueueIsBelowLowWatermark,
+		},
+		{
+			name:          "push to nominal stack",
+			initialItems:  []int{1, 2, 3},
+			expectedState: container.QueueIsNominal,
+		},
+		{
+			name:          "push to reach high watermark",
+			initialItems:  []int{1, 2, 3, 4, 5, 6, 7},
+			expectedState: container.QueueIsAboveHighWatermark,
+		},
+		{
+			name:          "push to reach saturation",
+			initialItems:  []int{1, 2, 3, 4, 5, 6, 7, 8, 9},
+			expectedState: container.QueueIsNearSaturation,
+		},
+		{
+			name:         "push to closed stack",
+			initialItems: []int{1, 2, 3},
+			expectPanic:  true,
+			setup:        func(q *waitable[int]) { q.Close() },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			q, err := NewWaitableStack[int](WSCap, WSLow, WSHigh)
+			if err != nil {
+				t.Fatalf("Failed to create stack: %v", err)
+			}
+			ws := q.(*waitable[int])
+
+			// Add initial items
+			ws.items = append(ws.items, test.initialItems...)
+
+			// Apply the setup function if provided.
+			if test.setup != nil {
+				test.setup(ws)
+			}
+
+			// Handle panic cases
+			if test.expectPanic {
+				defer func() {
+					if r := recover(); r == nil {
+						t.Errorf("Push() expected panic but didn't get one")
+					}
+				}()
+			}
+
+			// Call Push and verify the state.
+			state := q.Push(WSInput)
+
+			// Only check the state if we don't expect a panic.
+			if test.expectPanic {
+				return
+			}
+			if state != test.expectedState {
+				t.Errorf("Push() returned state = %v, want %v", state, test.expectedState)
+			}
+
+			// Verify an item was actually added
+			if ws.items[len(ws.items)-1] != WSInput {
+				t.Errorf("Push() failed to add item to stack")
+			}
+
+			// Verify that the signal channel has a value if the stack was empty before Push.
+			if len(test.initialItems) == 0 {
+				select {
+				case <-ws.signal:
+					// Signal received, as expected
+				default:
+					t.Errorf("Push() failed to send signal when stack was empty")
+				}
+			}
+		})
+	}
+}
@@ -38,13 +38,16 @@ type Unit = struct{}
 // WaitableQueue is a concurrency-safe generic unbounded queue.
 // It is meant to be used in a producer-consumer pattern,
 // where the blocking behavior and capacity limits of channels are an issue.
+//
+// Use queue.All or queue.AllWithState to range over its elements.
 type WaitableQueue[E any] interface {
 	// Close the queue, preventing any further enqueueing, and unblocking all consumers waiting on WaitChan.
 	// In most cases, it only makes sense to have it closed by the producer.
 	Close()
 	// Dequeue removes the first element from the queue if any is present.
 	// If the queue is empty, it returns the zero value of the element type, ok is false, and the result is QueueIsBelowLowWatermark.
-	// QueueIsAboveHighWatermark should be used to scale the consumer up or trigger a producer throttle.
+	// QueueIsAboveHighWatermark should be used to scale the consumer up or trigger a producer throttle:
+	// queue.WorkerPool implements the former.
 	// QueueIsNearSaturation is the same, just more urgent, and is more useful on the Enqueue method.
 	Dequeue() (e E, ok bool, result WaitableQueueState)
 	// Enqueue adds an element to the queue.
@@ -69,6 +72,21 @@ type Stack[E any] interface {
 	Pop() (e E, ok bool)
 }
 
+// WaitableStack is a concurrency-safe generic unbounded stack.
+// It is the LIFO counterpart of WaitableQueue, for freshest-first processing,
+// with the same closing, signaling and watermark semantics.
+type WaitableStack[E any] interface {
+	// Close the stack, preventing any further pushing, and unblocking all consumers waiting on WaitChan.
+	Close()
+	// Pop removes the top element from the stack if any is present.
+	// If the stack is empty, it returns the zero value of the element type, ok is false, and the result is QueueIsBelowLowWatermark.
+	Pop() (e E, ok bool, result WaitableQueueState)
+	// Push adds an element to the top of the stack. See WaitableQueue.Enqueue for the use of its result.
+	Push(E) WaitableQueueState
+	// WaitChan returns a channel that signals when an item might be available to pop or when the stack is closed.
+	WaitChan() <-chan Unit
+}
+
 // Countable MAY be provided by some implementations.
 // For concurrency-safe types, it is not atomic vs other operations,
 // meaning it MUST NOT be used to take decisions, but only as an observability/debugging tool.
@@ -76,6 +94,13 @@ type Countable interface {
 	Len() int
 }
 
+// BandCountable MAY be provided by priority-aware implementations.
+// It returns the number of elements in each priority band.
+// Like Countable, it is not atomic vs other operations on concurrency-safe types.
+type BandCountable interface {
+	BandLens() map[int]int
+}
+
 type Set[E comparable] interface {
 	Add(item E) (found bool)
 	Remove(item E) (found bool)
@@ -86,4 +111,11 @@ type Set[E comparable] interface {
 	Union(other Set[E]) Set[E]
 	Intersection(other Set[E]) Set[E]
 	Difference(other Set[E]) Set[E]
+	SymmetricDifference(other Set[E]) Set[E]
+
+	IsSubsetOf(other Set[E]) bool
+	IsSupersetOf(other Set[E]) bool
+	IsProperSubsetOf(other Set[E]) bool
+	Equal(other Set[E]) bool
+	IsDisjoint(other Set[E]) bool
 }